@@ -1,8 +1,11 @@
 import { QueryClient, QueryClientProvider } from '@tanstack/react-query';
-import { BrowserRouter as Router, Routes, Route } from 'react-router-dom';
+import type { ReactNode } from 'react';
+import { BrowserRouter as Router, Routes, Route, Navigate } from 'react-router-dom';
 import { Dashboard } from './components/Dashboard';
 import { ScheduleDetails } from './components/ScheduleDetails';
 import { ClockOut } from './components/ClockOut';
+import { Login } from './components/Login';
+import { getAuthToken } from './lib/api';
 
 // Create a client with optimized settings to prevent redundant calls
 const queryClient = new QueryClient({
@@ -18,15 +21,21 @@ const queryClient = new QueryClient({
   },
 });
 
+// Every API call needs a bearer token, so send signed-out users to the login page
+function RequireAuth({ children }: { children: ReactNode }) {
+  return getAuthToken() ? children : <Navigate to="/login" replace />;
+}
+
 function App() {
   return (
     <QueryClientProvider client={queryClient}>
       <Router>
         <div className="min-h-screen bg-background">
           <Routes>
-            <Route path="/" element={<Dashboard />} />
-            <Route path="/schedule/:id" element={<ScheduleDetails />} />
-            <Route path="/schedule/:id/clock-out" element={<ClockOut />} />
+            <Route path="/login" element={<Login />} />
+            <Route path="/" element={<RequireAuth><Dashboard /></RequireAuth>} />
+            <Route path="/schedule/:id" element={<RequireAuth><ScheduleDetails /></RequireAuth>} />
+            <Route path="/schedule/:id/clock-out" element={<RequireAuth><ClockOut /></RequireAuth>} />
           </Routes>
         </div>
       </Router>
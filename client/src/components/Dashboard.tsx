@@ -1,14 +1,25 @@
-import { useQuery } from '@tanstack/react-query';
-import { apiClient } from '@/lib/api';
+import { useQuery, useQueryClient } from '@tanstack/react-query';
+import { useNavigate } from 'react-router-dom';
+import { apiClient, getCurrentUser } from '@/lib/api';
 import { Badge } from '@/components/ui/badge';
 import { Button } from '@/components/ui/button';
 import { Avatar, AvatarFallback, AvatarImage } from '@/components/ui/avatar';
-import { MoreHorizontal } from 'lucide-react';
+import { LogOut, MoreHorizontal } from 'lucide-react';
 import { StatsCards } from './StatsCards';
 import { ScheduleItem } from './ScheduleItem';
 import { ActiveVisit } from './ActiveVisit';
 
 export function Dashboard() {
+  const navigate = useNavigate();
+  const queryClient = useQueryClient();
+  const user = getCurrentUser();
+
+  const handleSignOut = () => {
+    apiClient.logout();
+    queryClient.clear();
+    navigate('/login', { replace: true });
+  };
+
   const { data: stats, isLoading: statsLoading } = useQuery({
     queryKey: ['stats'],
     queryFn: () => apiClient.getStats(),
@@ -33,11 +44,14 @@ export function Dashboard() {
             <h1 className="text-xl font-semibold text-slate-900">Careviah</h1>
           </div>
           <div className="flex items-center space-x-3">
-            <span className="text-sm text-slate-600">Admin A</span>
+            <span className="text-sm text-slate-600">{user?.email}</span>
             <Avatar className="w-8 h-8">
               <AvatarImage src="/api/placeholder/32/32" />
-              <AvatarFallback>AA</AvatarFallback>
+              <AvatarFallback>{user?.email.slice(0, 2).toUpperCase()}</AvatarFallback>
             </Avatar>
+            <Button variant="ghost" size="sm" onClick={handleSignOut} aria-label="Sign out">
+              <LogOut className="w-4 h-4" />
+            </Button>
           </div>
         </div>
       </header>
@@ -106,6 +106,8 @@ export function ScheduleItem({ schedule }: ScheduleItemProps) {
     switch (status) {
       case 'scheduled':
         return <Badge className="bg-blue-100 text-blue-800 hover:bg-blue-100">Scheduled</Badge>;
+      case 'late':
+        return <Badge className="bg-amber-100 text-amber-800 hover:bg-amber-100">Late</Badge>;
       case 'in_progress':
         return <Badge className="bg-orange-100 text-orange-800 hover:bg-orange-100">In Progress</Badge>;
       case 'completed':
@@ -198,7 +200,7 @@ export function ScheduleItem({ schedule }: ScheduleItemProps) {
       </div>
 
       <div className="flex flex-col items-center space-x-2 space-y-2">
-        {schedule.status === 'scheduled' && (
+        {(schedule.status === 'scheduled' || schedule.status === 'late') && (
           <Button
             onClick={handleStartVisit}
             disabled={isStartingVisit}
@@ -239,7 +241,7 @@ export function ScheduleItem({ schedule }: ScheduleItemProps) {
           </Button>
         )}
 
-        {schedule.status === 'scheduled' && (
+        {(schedule.status === 'scheduled' || schedule.status === 'late') && (
           <Button 
             variant="outline" 
             size="sm"
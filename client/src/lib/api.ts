@@ -1,6 +1,7 @@
 import { z } from 'zod';
 import {
   ScheduleSchema,
+  SchedulePageSchema,
   TaskSchema,
   ActivitySchema,
   StatsSchema,
@@ -9,7 +10,10 @@ import {
   UpdateTaskRequestSchema,
   CreateActivityRequestSchema,
   UpdateActivityRequestSchema,
+  LoginRequestSchema,
+  LoginResponseSchema,
   type Schedule,
+  type SchedulePage,
   type Task,
   type Activity,
   type Stats,
@@ -18,11 +22,58 @@ import {
   type UpdateTaskRequest,
   type CreateActivityRequest,
   type UpdateActivityRequest,
+  type LoginRequest,
+  type LoginResponse,
+  type User,
 } from '@/lib/schemas';
 
 const API_BASE_URL = import.meta.env.VITE_API_BASE_URL || 'http://server.31.97.179.158.sslip.io/api/v1';
 
+// The bearer token from /auth/login and the signed-in user are kept across reloads
+const TOKEN_STORAGE_KEY = 'visit-tracker.token';
+const USER_STORAGE_KEY = 'visit-tracker.user';
 
+export const getAuthToken = (): string | null => localStorage.getItem(TOKEN_STORAGE_KEY);
+
+export const getCurrentUser = (): User | null => {
+  const stored = localStorage.getItem(USER_STORAGE_KEY);
+  return stored ? (JSON.parse(stored) as User) : null;
+};
+
+export const clearAuth = (): void => {
+  localStorage.removeItem(TOKEN_STORAGE_KEY);
+  localStorage.removeItem(USER_STORAGE_KEY);
+};
+
+export class ApiError extends Error {
+  status: number;
+  code?: string;
+
+  constructor(status: number, message: string, code?: string) {
+    super(message);
+    this.name = 'ApiError';
+    this.status = status;
+    this.code = code;
+  }
+}
+
+// Clock-in, clock-out and new activities are sent with an Idempotency-Key and retried
+// under the same key, with the same body, when the response is lost or the server fails.
+// The server replays the first successful response instead of applying the request twice.
+const IDEMPOTENT_RETRIES = 2;
+
+// crypto.randomUUID needs a secure context; fall back to random bytes over plain http
+const newIdempotencyKey = (): string => {
+  if (typeof crypto.randomUUID === 'function') {
+    return crypto.randomUUID();
+  }
+  const bytes = crypto.getRandomValues(new Uint8Array(16));
+  return Array.from(bytes, (byte) => byte.toString(16).padStart(2, '0')).join('');
+};
+
+const isRetryable = (error: unknown): boolean =>
+  error instanceof TypeError || // the request never got a response
+  (error instanceof ApiError && (error.status >= 500 || error.code === 'IDEMPOTENCY_KEY_IN_PROGRESS'));
 
 class ApiClient {
   private async request<T>(
@@ -31,17 +82,26 @@ class ApiClient {
     schema?: z.ZodSchema<T>
   ): Promise<T> {
     const url = `${API_BASE_URL}${endpoint}`;
+    const token = getAuthToken();
     
     const response = await fetch(url, {
+      ...options,
       headers: {
         'Content-Type': 'application/json',
+        ...(token ? { Authorization: `Bearer ${token}` } : {}),
         ...options.headers,
       },
-      ...options,
     });
 
     if (!response.ok) {
-      throw new Error(`API Error: ${response.status} ${response.statusText}`);
+      // An expired token or deactivated account signs the user out
+      if (response.status === 401 && endpoint !== '/auth/login') {
+        clearAuth();
+        window.location.assign('/login');
+      }
+      const body = await response.json().catch(() => null);
+      const message = body?.error?.details || body?.error?.message || response.statusText;
+      throw new ApiError(response.status, `API Error: ${response.status} ${message}`, body?.error?.code);
     }
 
     const data = await response.json();
@@ -53,10 +113,57 @@ class ApiClient {
     return data;
   }
 
+  // Sends a request that must not be applied twice, with one Idempotency-Key for the
+  // user's action that every retry reuses
+  private async idempotentRequest<T>(endpoint: string, options: RequestInit): Promise<T> {
+    const headers = { ...options.headers, 'Idempotency-Key': newIdempotencyKey() };
+    for (let attempt = 0; ; attempt++) {
+      try {
+        return await this.request<T>(endpoint, { ...options, headers });
+      } catch (error) {
+        if (attempt >= IDEMPOTENT_RETRIES || !isRetryable(error)) {
+          throw error;
+        }
+        await new Promise((resolve) => setTimeout(resolve, 1000 * 2 ** attempt));
+      }
+    }
+  }
+
+  // Auth endpoints
+  async login(credentials: LoginRequest): Promise<LoginResponse> {
+    LoginRequestSchema.parse(credentials);
+
+    const response = await this.request('/auth/login', {
+      method: 'POST',
+      body: JSON.stringify(credentials),
+    });
+    const data = LoginResponseSchema.parse(response);
+    localStorage.setItem(TOKEN_STORAGE_KEY, data.token);
+    localStorage.setItem(USER_STORAGE_KEY, JSON.stringify(data.user));
+    return data;
+  }
+
+  logout(): void {
+    clearAuth();
+  }
+
   // Schedule endpoints
+  async getSchedulePage(cursor?: string): Promise<SchedulePage> {
+    const query = cursor ? `?cursor=${encodeURIComponent(cursor)}` : '';
+    const data = await this.request(`/schedules${query}`);
+    return SchedulePageSchema.parse(data);
+  }
+
+  // Follows next_cursor until every page has been fetched
   async getAllSchedules(): Promise<Schedule[]> {
-    const data = await this.request('/schedules');
-    return z.array(ScheduleSchema).parse(data);
+    const schedules: Schedule[] = [];
+    let cursor: string | undefined;
+    do {
+      const page = await this.getSchedulePage(cursor);
+      schedules.push(...page.data);
+      cursor = page.pagination.next_cursor ?? undefined;
+    } while (cursor);
+    return schedules;
   }
 
   async getTodaySchedules(): Promise<Schedule[]> {
@@ -79,7 +186,7 @@ class ApiClient {
     // Validate the request data
     StartVisitRequestSchema.parse(location);
     
-    return this.request(`/schedules/${scheduleId}/start`, {
+    return this.idempotentRequest(`/schedules/${scheduleId}/start`, {
       method: 'POST',
       body: JSON.stringify(location),
     });
@@ -89,7 +196,7 @@ class ApiClient {
     // Validate the request data
     EndVisitRequestSchema.parse(location);
     
-    return this.request(`/schedules/${scheduleId}/end`, {
+    return this.idempotentRequest(`/schedules/${scheduleId}/end`, {
       method: 'POST',
       body: JSON.stringify(location),
     });
@@ -121,7 +228,7 @@ class ApiClient {
     // Validate the request data
     CreateActivityRequestSchema.parse(activity);
     
-    const data = await this.request(`/schedules/${scheduleId}/activities`, {
+    const data = await this.idempotentRequest(`/schedules/${scheduleId}/activities`, {
       method: 'POST',
       body: JSON.stringify(activity),
     });
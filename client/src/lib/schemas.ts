@@ -12,7 +12,7 @@ export const ScheduleSchema = z.object({
   shift_end: z.string(),
   latitude: z.number(),
   longitude: z.number(),
-  status: z.enum(['upcoming', 'in_progress', 'completed', 'missed', 'cancelled']),
+  status: z.enum(['upcoming', 'late', 'in_progress', 'completed', 'missed', 'cancelled']),
   created_at: z.string(),
   updated_at: z.string(),
 }).transform((data) => {
@@ -26,6 +26,16 @@ export const ScheduleSchema = z.object({
   };
 });
 
+// GET /schedules returns one page at a time, with the cursor of the next page beside it
+export const SchedulePageSchema = z.object({
+  data: z.array(ScheduleSchema),
+  pagination: z.object({
+    limit: z.number(),
+    total: z.number(),
+    next_cursor: z.string().nullable(),
+  }),
+});
+
 export const TaskSchema = z.object({
   id: z.number(),
   schedule_id: z.number(),
@@ -125,8 +135,31 @@ export const UpdateActivityRequestSchema = z.object({
   reason: z.string().optional(),
 });
 
+export const UserSchema = z.object({
+  id: z.number(),
+  email: z.string(),
+  role: z.enum(['caregiver', 'coordinator', 'admin']),
+  caregiver_id: z.number().optional(),
+  active: z.boolean(),
+});
+
+export const LoginRequestSchema = z.object({
+  email: z.string().email(),
+  password: z.string().min(1),
+});
+
+// Login is answered in the server's { data: ... } envelope
+export const LoginResponseSchema = z.object({
+  data: z.object({
+    token: z.string(),
+    expires_at: z.string(),
+    user: UserSchema,
+  }),
+}).transform((response) => response.data);
+
 export type Location = z.infer<typeof LocationSchema>;
 export type Schedule = z.infer<typeof ScheduleSchema>;
+export type SchedulePage = z.infer<typeof SchedulePageSchema>;
 export type Task = z.infer<typeof TaskSchema>;
 export type Activity = z.infer<typeof ActivitySchema>;
 export type Visit = z.infer<typeof VisitSchema>;
@@ -135,4 +168,7 @@ export type StartVisitRequest = z.infer<typeof StartVisitRequestSchema>;
 export type EndVisitRequest = z.infer<typeof EndVisitRequestSchema>;
 export type UpdateTaskRequest = z.infer<typeof UpdateTaskRequestSchema>;
 export type CreateActivityRequest = z.infer<typeof CreateActivityRequestSchema>;
-export type UpdateActivityRequest = z.infer<typeof UpdateActivityRequestSchema>; 
\ No newline at end of file
+export type UpdateActivityRequest = z.infer<typeof UpdateActivityRequestSchema>;
+export type User = z.infer<typeof UserSchema>;
+export type LoginRequest = z.infer<typeof LoginRequestSchema>;
+export type LoginResponse = z.infer<typeof LoginResponseSchema>; 
\ No newline at end of file
@@ -0,0 +1,560 @@
+// Package config loads the server settings documented in .env.example. Values come from
+// command-line flags, then the process environment, then a .env file, then defaults, and
+// are validated before anything is started.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Config holds every setting the server reads at startup
+type Config struct {
+	Port    string
+	GinMode string
+	// Timezone is the agency's IANA time zone, used for clients without one of their own
+	Timezone string
+	App      AppConfig
+	API      APIConfig
+	Database DatabaseConfig
+	CORS     CORSConfig
+	Log      LogConfig
+	Swagger  SwaggerConfig
+	EVV      EVVConfig
+	Auth     AuthConfig
+	// Idempotency controls how long Idempotency-Key responses are kept for replay
+	Idempotency IdempotencyConfig
+	Geofence    GeofenceConfig
+	Templates   TemplateConfig
+	MissedVisit MissedVisitConfig
+	Tasks       TaskConfig
+	Sync        SyncConfig
+}
+
+// AppConfig describes the running service
+type AppConfig struct {
+	Name        string
+	Version     string
+	Description string
+}
+
+// APIConfig controls where the API routes are mounted
+type APIConfig struct {
+	Version  string
+	BasePath string
+}
+
+// DatabaseConfig selects the database and whether sample data is loaded into it
+type DatabaseConfig struct {
+	// Path is the SQLite database file, used when PostgresURL is empty
+	Path           string
+	PostgresURL    string
+	SeedSampleData bool
+	// AdminEmail and AdminPassword name an admin account created at startup if it does
+	// not exist yet; both or neither must be set
+	AdminEmail    string
+	AdminPassword string
+}
+
+// CORSConfig lists the cross-origin requests browsers are allowed to make
+type CORSConfig struct {
+	AllowOrigins []string
+	AllowMethods []string
+	AllowHeaders []string
+}
+
+// AllowAllOrigins reports whether any origin may call the API
+func (c CORSConfig) AllowAllOrigins() bool {
+	return len(c.AllowOrigins) == 1 && c.AllowOrigins[0] == "*"
+}
+
+// LogConfig controls the level and format of log output
+type LogConfig struct {
+	Level  string
+	Format string
+	// Debug forces debug-level output whatever Level is set to
+	Debug bool
+}
+
+// SwaggerConfig controls the interactive API documentation
+type SwaggerConfig struct {
+	Enabled     bool
+	Host        string
+	BasePath    string
+	Title       string
+	Description string
+}
+
+// EVVConfig identifies the agency in EVV exports and picks the aggregator layout
+type EVVConfig struct {
+	ProviderID string
+	// DefaultServiceCode is exported for schedules without a service code of their own
+	DefaultServiceCode string
+	// Layout is the aggregator layout used when an export does not name one
+	Layout string
+	// LayoutsFile is a JSON file of additional layouts, optional
+	LayoutsFile string
+}
+
+// AuthConfig holds the settings used to sign and verify access tokens
+type AuthConfig struct {
+	// JWTSecret signs tokens; it is required in release mode, elsewhere a random secret is
+	// generated when it is empty
+	JWTSecret string
+	TokenTTL  time.Duration
+}
+
+// IdempotencyConfig controls how long idempotency keys are remembered
+type IdempotencyConfig struct {
+	TTL time.Duration
+}
+
+// GeofenceConfig controls how clock-in/out locations are verified against the client's home
+type GeofenceConfig struct {
+	RadiusMeters float64
+	Mode         string
+}
+
+// TemplateConfig controls how far ahead and how often schedules are materialised from templates
+type TemplateConfig struct {
+	HorizonDays int
+	Interval    time.Duration
+}
+
+// MissedVisitConfig controls when a schedule that was never started counts as missed
+type MissedVisitConfig struct {
+	GracePeriod time.Duration
+	Interval    time.Duration
+}
+
+// SyncConfig bounds the times a device may record offline clock-ins and clock-outs at
+type SyncConfig struct {
+	// MaxEventAge is how long a device may hold an event before syncing it
+	MaxEventAge time.Duration
+	// EarlyClockIn is how long before shift_start a clock-in may be recorded
+	EarlyClockIn time.Duration
+	// LateClockOut is how long after shift_end a clock-in or clock-out may be recorded
+	LateClockOut time.Duration
+}
+
+// TaskConfig controls what ending a visit does with tasks still pending
+type TaskConfig struct {
+	CompletionPolicy string
+}
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// Geofence enforcement modes
+const (
+	GeofenceModeFlag   = "flag"   // accept out-of-range locations but record them as outside
+	GeofenceModeReject = "reject" // refuse out-of-range locations unless an override reason is given
+)
+
+// Task completion policies for ending a visit while tasks are still pending
+const (
+	TaskPolicyReject    = "reject"     // refuse to end the visit until every task is resolved
+	TaskPolicyAutoClose = "auto_close" // end the visit and mark the pending tasks not_completed
+)
+
+// envFlags are the command-line flags that override an environment variable
+var envFlags = []struct {
+	name  string
+	env   string
+	usage string
+}{
+	{"port", "PORT", "port to listen on"},
+	{"db-path", "DB_PATH", "SQLite database file"},
+	{"postgres-url", "POSTGRES_URL", "PostgreSQL connection URL"},
+	{"log-level", "LOG_LEVEL", "debug, info, warn or error"},
+	{"log-format", "LOG_FORMAT", "text or json"},
+	{"seed", "SEED_SAMPLE_DATA", "load sample data into an empty database"},
+	{"swagger", "ENABLE_SWAGGER", "serve the Swagger UI"},
+}
+
+var (
+	ginModes      = []string{"debug", "release", "test"}
+	logLevels     = []string{"debug", "info", "warn", "error"}
+	logFormats    = []string{LogFormatText, LogFormatJSON}
+	geofenceModes = []string{GeofenceModeFlag, GeofenceModeReject}
+	taskPolicies  = []string{TaskPolicyReject, TaskPolicyAutoClose}
+)
+
+// Default returns the settings used when nothing is configured
+func Default() Config {
+	return Config{
+		Port:     "8080",
+		GinMode:  "debug",
+		Timezone: "UTC",
+		App: AppConfig{
+			Name:        "visit-tracker-api",
+			Version:     "1.0.0",
+			Description: "RESTful API for caregiver visit tracking and Electronic Visit Verification (EVV) compliance",
+		},
+		API: APIConfig{
+			Version:  "v1",
+			BasePath: "/api/v1",
+		},
+		Database: DatabaseConfig{
+			Path: "visits.db",
+		},
+		CORS: CORSConfig{
+			AllowOrigins: []string{"*"},
+			AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "HEAD", "PATCH"},
+			AllowHeaders: []string{
+				"Origin", "Content-Type", "Accept", "Authorization", "X-Request-ID", "Idempotency-Key",
+				"X-Requested-With", "Access-Control-Allow-Origin", "Access-Control-Allow-Headers",
+				"Access-Control-Allow-Methods", "Access-Control-Expose-Headers", "Access-Control-Max-Age",
+				"Access-Control-Allow-Credentials", "Cache-Control", "Pragma",
+			},
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: LogFormatText,
+		},
+		Swagger: SwaggerConfig{
+			Enabled:     true,
+			Host:        "localhost:8080",
+			BasePath:    "/api/v1",
+			Title:       "Visit Tracker API",
+			Description: "RESTful API for caregiver visit tracking and Electronic Visit Verification (EVV) compliance",
+		},
+		EVV: EVVConfig{
+			DefaultServiceCode: "T1019",
+			Layout:             "standard",
+		},
+		Auth: AuthConfig{
+			TokenTTL: 24 * time.Hour,
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: 24 * time.Hour,
+		},
+		Geofence: GeofenceConfig{
+			RadiusMeters: 150,
+			Mode:         GeofenceModeFlag,
+		},
+		Templates: TemplateConfig{
+			HorizonDays: 28,
+			Interval:    time.Hour,
+		},
+		MissedVisit: MissedVisitConfig{
+			GracePeriod: 30 * time.Minute,
+			Interval:    5 * time.Minute,
+		},
+		Tasks: TaskConfig{
+			CompletionPolicy: TaskPolicyReject,
+		},
+		Sync: SyncConfig{
+			MaxEventAge:  72 * time.Hour,
+			EarlyClockIn: time.Hour,
+			LateClockOut: 2 * time.Hour,
+		},
+	}
+}
+
+// Load parses the command-line flags in args, loads the .env file they name into the
+// environment without overriding variables that are already set, and builds the
+// validated configuration. It also returns the arguments left after the flags, such as
+// a subcommand.
+func Load(args []string) (Config, []string, error) {
+	flags := flag.NewFlagSet("visit-tracker-api", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	envFile := flags.String("env-file", ".env", "file to load environment variables from")
+	values := make(map[string]*string, len(envFlags))
+	for _, f := range envFlags {
+		values[f.name] = flags.String(f.name, "", f.usage+" ("+f.env+")")
+	}
+	if err := flags.Parse(args); err != nil {
+		return Config{}, nil, fmt.Errorf("%w\n\nFlags:\n%s", err, usage(flags))
+	}
+
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	// A missing .env is fine unless it was asked for by name
+	if err := godotenv.Load(*envFile); err != nil && (set["env-file"] || !errors.Is(err, os.ErrNotExist)) {
+		return Config{}, nil, fmt.Errorf("load %s: %w", *envFile, err)
+	}
+
+	// Flags take precedence over the environment
+	for _, f := range envFlags {
+		if set[f.name] {
+			os.Setenv(f.env, *values[f.name])
+		}
+	}
+
+	cfg, err := FromEnv()
+	return cfg, flags.Args(), err
+}
+
+// FromEnv builds the configuration from environment variables alone
+func FromEnv() (Config, error) {
+	cfg := Default()
+	env := envReader{}
+
+	env.string("PORT", &cfg.Port)
+	env.string("GIN_MODE", &cfg.GinMode)
+	env.string("AGENCY_TIMEZONE", &cfg.Timezone)
+
+	env.string("APP_NAME", &cfg.App.Name)
+	env.string("APP_VERSION", &cfg.App.Version)
+	env.string("APP_DESCRIPTION", &cfg.App.Description)
+
+	// The base path and Swagger settings follow the API version and app description
+	// unless they are set themselves
+	env.string("API_VERSION", &cfg.API.Version)
+	cfg.API.BasePath = "/api/" + cfg.API.Version
+	env.string("API_BASE_PATH", &cfg.API.BasePath)
+
+	env.string("DB_PATH", &cfg.Database.Path)
+	env.string("POSTGRES_URL", &cfg.Database.PostgresURL)
+	env.bool("SEED_SAMPLE_DATA", &cfg.Database.SeedSampleData)
+	env.string("ADMIN_EMAIL", &cfg.Database.AdminEmail)
+	env.string("ADMIN_PASSWORD", &cfg.Database.AdminPassword)
+
+	env.list("CORS_ALLOW_ORIGINS", &cfg.CORS.AllowOrigins)
+	env.list("CORS_ALLOW_METHODS", &cfg.CORS.AllowMethods)
+	env.list("CORS_ALLOW_HEADERS", &cfg.CORS.AllowHeaders)
+
+	if cfg.GinMode == "release" {
+		cfg.Log.Format = LogFormatJSON
+	}
+	env.string("LOG_LEVEL", &cfg.Log.Level)
+	env.string("LOG_FORMAT", &cfg.Log.Format)
+	env.bool("ENABLE_DEBUG_LOGS", &cfg.Log.Debug)
+
+	cfg.Swagger.Host = "localhost:" + cfg.Port
+	cfg.Swagger.BasePath = cfg.API.BasePath
+	cfg.Swagger.Description = cfg.App.Description
+	env.bool("ENABLE_SWAGGER", &cfg.Swagger.Enabled)
+	env.string("SWAGGER_HOST", &cfg.Swagger.Host)
+	env.string("SWAGGER_BASE_PATH", &cfg.Swagger.BasePath)
+	env.string("SWAGGER_TITLE", &cfg.Swagger.Title)
+	env.string("SWAGGER_DESCRIPTION", &cfg.Swagger.Description)
+
+	env.string("EVV_PROVIDER_ID", &cfg.EVV.ProviderID)
+	env.string("EVV_DEFAULT_SERVICE_CODE", &cfg.EVV.DefaultServiceCode)
+	env.string("EVV_LAYOUT", &cfg.EVV.Layout)
+	env.string("EVV_LAYOUTS_FILE", &cfg.EVV.LayoutsFile)
+
+	env.string("JWT_SECRET", &cfg.Auth.JWTSecret)
+	env.duration("JWT_EXPIRE_HOURS", time.Hour, &cfg.Auth.TokenTTL)
+	env.duration("IDEMPOTENCY_KEY_TTL_HOURS", time.Hour, &cfg.Idempotency.TTL)
+
+	env.float("GEOFENCE_RADIUS_METERS", &cfg.Geofence.RadiusMeters)
+	env.string("GEOFENCE_MODE", &cfg.Geofence.Mode)
+
+	env.int("SCHEDULE_HORIZON_DAYS", &cfg.Templates.HorizonDays)
+	env.duration("SCHEDULE_GENERATION_INTERVAL_MINUTES", time.Minute, &cfg.Templates.Interval)
+
+	env.duration("MISSED_VISIT_GRACE_MINUTES", time.Minute, &cfg.MissedVisit.GracePeriod)
+	env.duration("MISSED_VISIT_CHECK_INTERVAL_MINUTES", time.Minute, &cfg.MissedVisit.Interval)
+
+	env.string("TASK_COMPLETION_POLICY", &cfg.Tasks.CompletionPolicy)
+
+	env.duration("SYNC_MAX_EVENT_AGE_HOURS", time.Hour, &cfg.Sync.MaxEventAge)
+	env.duration("SYNC_EARLY_CLOCK_IN_MINUTES", time.Minute, &cfg.Sync.EarlyClockIn)
+	env.duration("SYNC_LATE_CLOCK_OUT_MINUTES", time.Minute, &cfg.Sync.LateClockOut)
+
+	return cfg, errors.Join(append(env.errs, cfg.Validate())...)
+}
+
+// Validate checks that every setting holds a usable value, reporting every problem found
+func (c Config) Validate() error {
+	var errs []error
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT must be a number between 1 and 65535, got %q", c.Port))
+	}
+	errs = appendIfInvalid(errs, "GIN_MODE", c.GinMode, ginModes)
+	if _, err := time.LoadLocation(c.Timezone); err != nil || c.Timezone == "" || c.Timezone == "Local" {
+		errs = append(errs, fmt.Errorf("AGENCY_TIMEZONE must be an IANA time zone such as America/New_York, got %q", c.Timezone))
+	}
+	errs = appendIfInvalid(errs, "LOG_LEVEL", c.Log.Level, logLevels)
+	errs = appendIfInvalid(errs, "LOG_FORMAT", c.Log.Format, logFormats)
+
+	if !strings.HasPrefix(c.API.BasePath, "/") {
+		errs = append(errs, fmt.Errorf("API_BASE_PATH must start with /, got %q", c.API.BasePath))
+	}
+	if c.Database.PostgresURL == "" && c.Database.Path == "" {
+		errs = append(errs, errors.New("DB_PATH must be set when POSTGRES_URL is not"))
+	}
+	if c.Database.PostgresURL != "" && !strings.HasPrefix(c.Database.PostgresURL, "postgres://") &&
+		!strings.HasPrefix(c.Database.PostgresURL, "postgresql://") {
+		errs = append(errs, errors.New("POSTGRES_URL must be a postgres:// URL"))
+	}
+
+	if len(c.CORS.AllowOrigins) == 0 {
+		errs = append(errs, errors.New("CORS_ALLOW_ORIGINS must list at least one origin"))
+	}
+	for _, origin := range c.CORS.AllowOrigins {
+		if origin == "*" && len(c.CORS.AllowOrigins) > 1 {
+			errs = append(errs, errors.New("CORS_ALLOW_ORIGINS cannot mix * with specific origins"))
+			break
+		}
+		if origin != "*" && !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			errs = append(errs, fmt.Errorf("CORS_ALLOW_ORIGINS entry %q must start with http:// or https://", origin))
+		}
+	}
+	if len(c.CORS.AllowMethods) == 0 {
+		errs = append(errs, errors.New("CORS_ALLOW_METHODS must list at least one method"))
+	}
+	if (c.Database.AdminEmail == "") != (c.Database.AdminPassword == "") {
+		errs = append(errs, errors.New("ADMIN_EMAIL and ADMIN_PASSWORD must be set together"))
+	}
+	// The sample data signs in with published demo passwords
+	if c.GinMode == "release" && c.Database.SeedSampleData {
+		errs = append(errs, errors.New("SEED_SAMPLE_DATA cannot be enabled when GIN_MODE is release"))
+	}
+
+	// A generated secret would sign out every user on restart and differ between instances
+	if c.GinMode == "release" && c.Auth.JWTSecret == "" {
+		errs = append(errs, errors.New("JWT_SECRET must be set when GIN_MODE is release"))
+	}
+	errs = appendIfNotPositive(errs, "JWT_EXPIRE_HOURS", c.Auth.TokenTTL)
+	errs = appendIfNotPositive(errs, "IDEMPOTENCY_KEY_TTL_HOURS", c.Idempotency.TTL)
+
+	if c.Geofence.RadiusMeters <= 0 {
+		errs = append(errs, fmt.Errorf("GEOFENCE_RADIUS_METERS must be greater than 0, got %g", c.Geofence.RadiusMeters))
+	}
+	errs = appendIfInvalid(errs, "GEOFENCE_MODE", c.Geofence.Mode, geofenceModes)
+
+	if c.Templates.HorizonDays <= 0 {
+		errs = append(errs, fmt.Errorf("SCHEDULE_HORIZON_DAYS must be greater than 0, got %d", c.Templates.HorizonDays))
+	}
+	errs = appendIfNotPositive(errs, "SCHEDULE_GENERATION_INTERVAL_MINUTES", c.Templates.Interval)
+
+	if c.MissedVisit.GracePeriod < 0 {
+		errs = append(errs, fmt.Errorf("MISSED_VISIT_GRACE_MINUTES cannot be negative, got %s", c.MissedVisit.GracePeriod))
+	}
+	errs = appendIfNotPositive(errs, "MISSED_VISIT_CHECK_INTERVAL_MINUTES", c.MissedVisit.Interval)
+	errs = appendIfInvalid(errs, "TASK_COMPLETION_POLICY", c.Tasks.CompletionPolicy, taskPolicies)
+
+	errs = appendIfNotPositive(errs, "SYNC_MAX_EVENT_AGE_HOURS", c.Sync.MaxEventAge)
+	if c.Sync.EarlyClockIn < 0 {
+		errs = append(errs, fmt.Errorf("SYNC_EARLY_CLOCK_IN_MINUTES cannot be negative, got %s", c.Sync.EarlyClockIn))
+	}
+	if c.Sync.LateClockOut < 0 {
+		errs = append(errs, fmt.Errorf("SYNC_LATE_CLOCK_OUT_MINUTES cannot be negative, got %s", c.Sync.LateClockOut))
+	}
+
+	return errors.Join(errs...)
+}
+
+// appendIfInvalid adds an error when value is not one of allowed
+func appendIfInvalid(errs []error, key, value string, allowed []string) []error {
+	for _, option := range allowed {
+		if value == option {
+			return errs
+		}
+	}
+	return append(errs, fmt.Errorf("%s must be one of %s, got %q", key, strings.Join(allowed, ", "), value))
+}
+
+// appendIfNotPositive adds an error when a duration is zero or negative
+func appendIfNotPositive(errs []error, key string, value time.Duration) []error {
+	if value > 0 {
+		return errs
+	}
+	return append(errs, fmt.Errorf("%s must be greater than 0, got %s", key, value))
+}
+
+// usage lists the flags a FlagSet accepts
+func usage(flags *flag.FlagSet) string {
+	var b strings.Builder
+	flags.VisitAll(func(f *flag.Flag) {
+		fmt.Fprintf(&b, "  -%s\n    \t%s\n", f.Name, f.Usage)
+	})
+	return b.String()
+}
+
+// envReader reads typed environment variables, collecting parse errors so they can all
+// be reported at once
+type envReader struct {
+	errs []error
+}
+
+// string sets target to the variable's value when it is set and not empty
+func (r *envReader) string(key string, target *string) {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		*target = value
+	}
+}
+
+// bool sets target from a true/false variable
+func (r *envReader) bool(key string, target *bool) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be true or false, got %q", key, value))
+		return
+	}
+	*target = parsed
+}
+
+// list sets target from a comma-separated variable, ignoring empty entries
+func (r *envReader) list(key string, target *[]string) {
+	value := os.Getenv(key)
+	if strings.TrimSpace(value) == "" {
+		return
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*target = items
+}
+
+// int sets target from a whole-number variable
+func (r *envReader) int(key string, target *int) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be a whole number, got %q", key, value))
+		return
+	}
+	*target = parsed
+}
+
+// float sets target from a numeric variable
+func (r *envReader) float(key string, target *float64) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be a number, got %q", key, value))
+		return
+	}
+	*target = parsed
+}
+
+// duration sets target from a variable counting whole units, such as hours or minutes
+func (r *envReader) duration(key string, unit time.Duration, target *time.Duration) {
+	var count int
+	before := len(r.errs)
+	r.int(key, &count)
+	if len(r.errs) > before || strings.TrimSpace(os.Getenv(key)) == "" {
+		return
+	}
+	*target = time.Duration(count) * unit
+}
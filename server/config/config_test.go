@@ -0,0 +1,145 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// clearEnv unsets the variables these tests read, restoring them when the test ends, so
+// neither the caller's environment nor an earlier Load leaks into a test
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, key := range []string{
+		"PORT", "GIN_MODE", "LOG_LEVEL", "LOG_FORMAT", "DB_PATH", "POSTGRES_URL", "SEED_SAMPLE_DATA",
+		"JWT_SECRET", "GEOFENCE_MODE", "TASK_COMPLETION_POLICY", "ADMIN_EMAIL", "ADMIN_PASSWORD",
+	} {
+		value, ok := os.LookupEnv(key)
+		os.Unsetenv(key)
+		t.Cleanup(func() {
+			if ok {
+				os.Setenv(key, value)
+			} else {
+				os.Unsetenv(key)
+			}
+		})
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t)
+	envFile := filepath.Join(t.TempDir(), ".env")
+	content := "PORT=7000\nLOG_LEVEL=debug\nGEOFENCE_MODE=reject\n"
+	if err := os.WriteFile(envFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("PORT", "8000")
+	os.Setenv("LOG_LEVEL", "warn")
+
+	cfg, rest, err := Load([]string{"-env-file", envFile, "-port", "9000", "migrate", "status"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Port != "9000" {
+		t.Errorf("Port = %s, want the flag's 9000 over the environment and .env", cfg.Port)
+	}
+	if cfg.Log.Level != "warn" {
+		t.Errorf("Log.Level = %s, want the environment's warn over .env", cfg.Log.Level)
+	}
+	if cfg.Geofence.Mode != GeofenceModeReject {
+		t.Errorf("Geofence.Mode = %s, want .env's reject", cfg.Geofence.Mode)
+	}
+	if cfg.Tasks.CompletionPolicy != TaskPolicyReject {
+		t.Errorf("Tasks.CompletionPolicy = %s, want the default %s", cfg.Tasks.CompletionPolicy, TaskPolicyReject)
+	}
+	if strings.Join(rest, " ") != "migrate status" {
+		t.Errorf("remaining args = %v, want [migrate status]", rest)
+	}
+}
+
+func TestLoadEnvFile(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+
+	if _, _, err := Load([]string{"-env-file", filepath.Join(dir, "missing.env")}); err == nil {
+		t.Error("Load with a missing -env-file succeeded, want an error")
+	}
+
+	// The default .env is optional
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	if _, _, err := Load(nil); err != nil {
+		t.Errorf("Load without a .env: %v", err)
+	}
+}
+
+func TestFromEnvValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		wantErrs []string // every problem reported; none when the configuration is valid
+	}{
+		{name: "defaults"},
+		{
+			name: "release with a JWT secret",
+			env:  map[string]string{"GIN_MODE": "release", "JWT_SECRET": "secret"},
+		},
+		{
+			name:     "release without a JWT secret",
+			env:      map[string]string{"GIN_MODE": "release"},
+			wantErrs: []string{"JWT_SECRET must be set when GIN_MODE is release"},
+		},
+		{
+			name:     "release with sample data",
+			env:      map[string]string{"GIN_MODE": "release", "JWT_SECRET": "secret", "SEED_SAMPLE_DATA": "true"},
+			wantErrs: []string{"SEED_SAMPLE_DATA cannot be enabled when GIN_MODE is release"},
+		},
+		{
+			name:     "unknown geofence mode",
+			env:      map[string]string{"GEOFENCE_MODE": "warn"},
+			wantErrs: []string{`GEOFENCE_MODE must be one of flag, reject, got "warn"`},
+		},
+		{
+			name:     "unknown task completion policy",
+			env:      map[string]string{"TASK_COMPLETION_POLICY": "ignore"},
+			wantErrs: []string{`TASK_COMPLETION_POLICY must be one of reject, auto_close, got "ignore"`},
+		},
+		{
+			name:     "every problem is reported",
+			env:      map[string]string{"GEOFENCE_MODE": "warn", "TASK_COMPLETION_POLICY": "ignore"},
+			wantErrs: []string{"GEOFENCE_MODE must be one of", "TASK_COMPLETION_POLICY must be one of"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := FromEnv()
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("FromEnv: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("FromEnv succeeded, want %q", tt.wantErrs)
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("FromEnv error = %v, want %q", err, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"time"
+
+	"visit-tracker-api/utils"
+)
+
+// dataMigrations run after the SQL of the migration with the same version, in the same
+// transaction, for changes SQL alone cannot make
+var dataMigrations = map[int]func(tx *Tx) error{
+	7: convertLocalTimestamps,
+}
+
+// localTimestampColumns lists, by table, the columns releases before 0007 wrote in the
+// agency's local time from the server clock. Bookkeeping created_at and updated_at
+// columns were also filled by CURRENT_TIMESTAMP, which SQLite writes in UTC, so their
+// values cannot be told apart and are left as they are, as is the audit log, whose hash
+// chain covers its timestamps.
+var localTimestampColumns = []struct {
+	table   string
+	columns []string
+}{
+	{"schedules", []string{"shift_start", "shift_end", "cancelled_at"}},
+	{"visits", []string{"start_time", "end_time"}},
+	{"schedule_status_history", []string{"changed_at"}},
+	{"sync_events", []string{"occurred_at"}},
+	{"visit_adjustments", []string{"original_start_time", "original_end_time", "adjusted_start_time", "adjusted_end_time", "created_at"}},
+	{"idempotency_keys", []string{"created_at", "completed_at"}},
+}
+
+// convertLocalTimestamps rewrites the timestamps written before they were stored in UTC
+// from AGENCY_TIMEZONE to UTC. It runs with migration 0007, which switched to UTC, so
+// every value it finds was written in local time.
+func convertLocalTimestamps(tx *Tx) error {
+	loc := utils.AgencyLocation()
+	if loc == time.UTC {
+		return nil
+	}
+
+	for _, table := range localTimestampColumns {
+		for _, column := range table.columns {
+			if err := convertLocalColumn(tx, table.table, column, loc); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// convertLocalColumn rewrites one column's values from loc to UTC
+func convertLocalColumn(tx *Tx, table, column string, loc *time.Location) error {
+	rows, err := tx.Query("SELECT id, " + column + " FROM " + table + " WHERE " + column + " IS NOT NULL")
+	if err != nil {
+		return err
+	}
+	values := map[int]string{}
+	for rows.Next() {
+		var id int
+		var value string
+		if err := rows.Scan(&id, &value); err != nil {
+			rows.Close()
+			return err
+		}
+		values[id] = value
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, value := range values {
+		wall := utils.ParseTime(value)
+		if wall.IsZero() {
+			continue
+		}
+		local := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0, loc)
+		if _, err := tx.Exec("UPDATE "+table+" SET "+column+" = ? WHERE id = ?", utils.FormatTime(local), id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"visit-tracker-api/config"
+	"visit-tracker-api/utils"
+)
+
+func TestConvertLocalTimestamps(t *testing.T) {
+	Open(config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "visits.db")})
+	db := DB
+	t.Cleanup(func() { db.Close() })
+	if _, err := MigrateUp(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	utils.SetAgencyLocation(newYork)
+	t.Cleanup(func() { utils.SetAgencyLocation(time.UTC) })
+
+	if _, err := db.Exec("INSERT INTO clients (id, name, latitude, longitude) VALUES (1, 'Client', 0, 0)"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		shiftStart string
+		want       string
+	}{
+		{"standard time", "2026-01-15 09:00:00", "2026-01-15 14:00:00"},
+		{"daylight saving time", "2026-07-15 09:00:00", "2026-07-15 13:00:00"},
+		{"local evening is the next day in UTC", "2026-07-15 22:30:00", "2026-07-16 02:30:00"},
+	}
+	for i, tt := range tests {
+		_, err := db.Exec(
+			"INSERT INTO schedules (id, client_id, shift_start, shift_end, created_at) VALUES (?, 1, ?, ?, '2026-01-01 00:00:00')",
+			i+1, tt.shiftStart, tt.shiftStart)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := inTransaction(db, convertLocalTimestamps); err != nil {
+		t.Fatalf("convertLocalTimestamps() error = %v", err)
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var shiftStart, createdAt string
+			err := db.QueryRow("SELECT shift_start, created_at FROM schedules WHERE id = ?", i+1).Scan(&shiftStart, &createdAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := utils.FormatTime(utils.ParseTime(shiftStart)); got != tt.want {
+				t.Errorf("shift_start = %s, want %s", got, tt.want)
+			}
+			if got := utils.FormatTime(utils.ParseTime(createdAt)); got != "2026-01-01 00:00:00" {
+				t.Errorf("created_at = %s, want it left as written", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package database
+
+import "database/sql"
+
+// Conn is an open database that rewrites queries for its dialect, so callers can write
+// every query with ? placeholders whichever backend is configured
+type Conn struct {
+	*sql.DB
+	Dialect Dialect
+}
+
+// Tx is a transaction on a Conn that rewrites queries the same way
+type Tx struct {
+	*sql.Tx
+	Dialect Dialect
+}
+
+// Exec runs a statement that returns no rows
+func (c *Conn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.DB.Exec(c.Dialect.Rebind(query), args...)
+}
+
+// Query runs a query that returns rows
+func (c *Conn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.DB.Query(c.Dialect.Rebind(query), args...)
+}
+
+// QueryRow runs a query that returns at most one row
+func (c *Conn) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.DB.QueryRow(c.Dialect.Rebind(query), args...)
+}
+
+// Begin starts a transaction
+func (c *Conn) Begin() (*Tx, error) {
+	tx, err := c.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, Dialect: c.Dialect}, nil
+}
+
+// Insert runs an INSERT and returns the id of the new row
+func (c *Conn) Insert(query string, args ...interface{}) (int64, error) {
+	return insert(c, c.Dialect, query, args)
+}
+
+// Exec runs a statement that returns no rows
+func (tx *Tx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.Tx.Exec(tx.Dialect.Rebind(query), args...)
+}
+
+// Query runs a query that returns rows
+func (tx *Tx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.Tx.Query(tx.Dialect.Rebind(query), args...)
+}
+
+// QueryRow runs a query that returns at most one row
+func (tx *Tx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return tx.Tx.QueryRow(tx.Dialect.Rebind(query), args...)
+}
+
+// Insert runs an INSERT and returns the id of the new row
+func (tx *Tx) Insert(query string, args ...interface{}) (int64, error) {
+	return insert(tx, tx.Dialect, query, args)
+}
+
+// execQueryer is implemented by both *Conn and *Tx
+type execQueryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// insert reads the new row's id back with RETURNING on Postgres, whose driver does not
+// support LastInsertId
+func insert(db execQueryer, dialect Dialect, query string, args []interface{}) (int64, error) {
+	var id int64
+	if dialect == DialectPostgres {
+		err := db.QueryRow(query+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
@@ -9,89 +9,66 @@ import (
 	"strings"
 	"time"
 
+	"visit-tracker-api/config"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
 	_ "github.com/mattn/go-sqlite3"
+	"golang.org/x/crypto/bcrypt"
 )
 
-var DB *sql.DB
+var DB *Conn
+
+// Open connects to the database without changing its schema. A PostgreSQL URL selects a
+// shared PostgreSQL database; otherwise the SQLite file at cfg.Path is used.
+func Open(cfg config.DatabaseConfig) {
+	dialect := DialectSQLite
+	// Background jobs write alongside request handlers: take the write lock when a
+	// transaction begins and wait for it instead of failing with "database is locked"
+	dsn := cfg.Path + "?_busy_timeout=5000&_txlock=immediate"
+	if cfg.PostgresURL != "" {
+		dialect = DialectPostgres
+		dsn = withUTCSession(cfg.PostgresURL)
+	}
 
-// Initialize sets up the SQLite database and creates tables
-func Initialize() {
-	var err error
-	DB, err = sql.Open("sqlite3", "./visits.db")
+	db, err := sql.Open(dialect.driverName(), dsn)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
+	DB = &Conn{DB: db, Dialect: dialect}
 
 	if err = DB.Ping(); err != nil {
 		log.Fatal("Failed to ping database:", err)
 	}
+	log.Printf("Connected to %s database", dialect)
+}
 
-	createTables()
-	seedData()
-	log.Println("Database initialized successfully")
+// withUTCSession sets the PostgreSQL session time zone to UTC unless the URL sets one, so
+// CURRENT_TIMESTAMP and NOW() are written in UTC like every other timestamp
+func withUTCSession(url string) string {
+	if strings.Contains(url, "timezone=") {
+		return url
+	}
+	if strings.Contains(url, "?") {
+		return url + "&timezone=UTC"
+	}
+	return url + "?timezone=UTC"
 }
 
-// createTables creates the necessary tables
-func createTables() {
-	scheduleTable := `
-	CREATE TABLE IF NOT EXISTS schedules (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		client_name TEXT NOT NULL,
-		shift_start DATETIME NOT NULL,
-		shift_end DATETIME NOT NULL,
-		latitude REAL NOT NULL,
-		longitude REAL NOT NULL,
-		status TEXT NOT NULL DEFAULT 'upcoming',
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);`
-
-	taskTable := `
-	CREATE TABLE IF NOT EXISTS tasks (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		schedule_id INTEGER NOT NULL,
-		description TEXT NOT NULL,
-		status TEXT NOT NULL DEFAULT 'pending',
-		reason TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (schedule_id) REFERENCES schedules (id)
-	);`
-
-	visitTable := `
-	CREATE TABLE IF NOT EXISTS visits (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		schedule_id INTEGER NOT NULL,
-		start_time DATETIME,
-		end_time DATETIME,
-		start_lat REAL,
-		start_lng REAL,
-		end_lat REAL,
-		end_lng REAL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (schedule_id) REFERENCES schedules (id)
-	);`
-
-	activityTable := `
-	CREATE TABLE IF NOT EXISTS activities (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		schedule_id INTEGER NOT NULL,
-		title TEXT NOT NULL,
-		description TEXT NOT NULL,
-		is_resolved BOOLEAN NOT NULL DEFAULT 0,
-		reason TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (schedule_id) REFERENCES schedules (id)
-	);`
-
-	tables := []string{scheduleTable, taskTable, visitTable, activityTable}
-	for _, table := range tables {
-		if _, err := DB.Exec(table); err != nil {
-			log.Fatal("Failed to create table:", err)
-		}
+// Initialize connects to the database, applies pending migrations and loads sample data,
+// with its demo accounts, only when it was asked for
+func Initialize(cfg config.DatabaseConfig) {
+	Open(cfg)
+
+	if _, err := MigrateUp(DB); err != nil {
+		log.Fatal("Failed to migrate database:", err)
 	}
+
+	if cfg.SeedSampleData {
+		seedData()
+	} else {
+		log.Println("Sample data disabled, skipping seed")
+	}
+	log.Println("Database initialized successfully")
 }
 
 // seedData loads and executes the comprehensive seed data from SQL file
@@ -114,12 +91,16 @@ func seedData() {
 		log.Fatal("Failed to get current directory:", err)
 	}
 
-	sqlFilePath := filepath.Join(currentDir, "database", "seed_data.sql")
+	seedFile := "seed_data.sql"
+	if DB.Dialect == DialectPostgres {
+		seedFile = "seed_data.postgres.sql"
+	}
+	sqlFilePath := filepath.Join(currentDir, "database", seedFile)
 	
 	// Read the SQL file
 	sqlBytes, err := ioutil.ReadFile(sqlFilePath)
 	if err != nil {
-		log.Printf("Warning: Could not read seed_data.sql file at %s: %v", sqlFilePath, err)
+		log.Printf("Warning: Could not read %s file at %s: %v", seedFile, sqlFilePath, err)
 		log.Println("Falling back to minimal seed data...")
 		seedMinimalData()
 		return
@@ -149,7 +130,7 @@ func seedData() {
 	cleanedSQL := strings.Join(cleanedLines, "\n")
 
 	// Split by semicolon and execute each statement
-	statements := strings.Split(cleanedSQL, ";")
+	statements := splitSQLStatements(cleanedSQL)
 	
 	for _, statement := range statements {
 		statement = strings.TrimSpace(statement)
@@ -187,9 +168,66 @@ func min(a, b int) int {
 	return b
 }
 
+// splitSQLStatements splits a script on semicolons that are not inside quoted strings,
+// so values such as RRULEs can contain semicolons
+func splitSQLStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	inQuote := false
+
+	for _, r := range script {
+		switch {
+		case r == '\'':
+			inQuote = !inQuote
+		case r == ';' && !inQuote:
+			statements = append(statements, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	return append(statements, current.String())
+}
+
 // seedMinimalData provides fallback minimal data if SQL file can't be loaded
 func seedMinimalData() {
-	now := time.Now()
+	now := time.Now().UTC()
+
+	// Sample caregiver who works every fallback shift
+	var caregiverID interface{}
+	id, err := DB.Insert(`
+		INSERT INTO caregivers (name, email, phone)
+		VALUES (?, ?, ?)`,
+		"Sarah Johnson", "sarah.johnson@example.com", "555-0101")
+	if err != nil {
+		log.Printf("Failed to insert caregiver: %v", err)
+	} else {
+		caregiverID = id
+	}
+
+	// Sample accounts for each role, all using the demo password
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("Failed to hash demo password: %v", err)
+	}
+	users := []struct {
+		email       string
+		role        string
+		caregiverID interface{}
+	}{
+		{"admin@example.com", "admin", nil},
+		{"coordinator@example.com", "coordinator", nil},
+		{"sarah.johnson@example.com", "caregiver", caregiverID},
+	}
+	for _, user := range users {
+		_, err := DB.Exec(`
+			INSERT INTO users (email, password_hash, role, caregiver_id)
+			VALUES (?, ?, ?, ?)`,
+			user.email, string(passwordHash), user.role, user.caregiverID)
+		if err != nil {
+			log.Printf("Failed to insert user: %v", err)
+		}
+	}
 	
 	// Create proper time.Time values for different dates
 	todayMorning := time.Date(now.Year(), now.Month(), now.Day(), 9, 0, 0, 0, now.Location())
@@ -209,6 +247,7 @@ func seedMinimalData() {
 	schedules := []map[string]interface{}{
 		{
 			"client_name": "John Smith",
+			"address":     "12 Park Row, New York, NY 10038",
 			"shift_start": todayMorning.Format("2006-01-02 15:04:05"),
 			"shift_end":   todayMorningEnd.Format("2006-01-02 15:04:05"),
 			"latitude":    40.7128,
@@ -217,6 +256,7 @@ func seedMinimalData() {
 		},
 		{
 			"client_name": "Mary Johnson",
+			"address":     "1501 Broadway, New York, NY 10036",
 			"shift_start": todayAfternoon.Format("2006-01-02 15:04:05"),
 			"shift_end":   todayAfternoonEnd.Format("2006-01-02 15:04:05"),
 			"latitude":    40.7589,
@@ -225,6 +265,7 @@ func seedMinimalData() {
 		},
 		{
 			"client_name": "Robert Davis",
+			"address":     "1 Liberty Island, New York, NY 10004",
 			"shift_start": yesterdayMorning.Format("2006-01-02 15:04:05"),
 			"shift_end":   yesterdayMorningEnd.Format("2006-01-02 15:04:05"),
 			"latitude":    40.6892,
@@ -233,6 +274,7 @@ func seedMinimalData() {
 		},
 		{
 			"client_name": "Sarah Wilson",
+			"address":     "200 Central Park West, New York, NY 10024",
 			"shift_start": tomorrowMorning.Format("2006-01-02 15:04:05"),
 			"shift_end":   tomorrowMorningEnd.Format("2006-01-02 15:04:05"),
 			"latitude":    40.7831,
@@ -242,32 +284,52 @@ func seedMinimalData() {
 	}
 
 	for _, schedule := range schedules {
-		result, err := DB.Exec(`
-			INSERT INTO schedules (client_name, shift_start, shift_end, latitude, longitude, status)
-			VALUES (?, ?, ?, ?, ?, ?)`,
-			schedule["client_name"], schedule["shift_start"], schedule["shift_end"],
-			schedule["latitude"], schedule["longitude"], schedule["status"])
+		clientID, err := DB.Insert(`
+			INSERT INTO clients (name, address, latitude, longitude)
+			VALUES (?, ?, ?, ?)`,
+			schedule["client_name"], schedule["address"], schedule["latitude"], schedule["longitude"])
+		if err != nil {
+			log.Printf("Failed to insert client: %v", err)
+			continue
+		}
+
+		scheduleID, err := DB.Insert(`
+			INSERT INTO schedules (caregiver_id, client_id, shift_start, shift_end, status)
+			VALUES (?, ?, ?, ?, ?)`,
+			caregiverID, clientID, schedule["shift_start"], schedule["shift_end"], schedule["status"])
 		if err != nil {
 			log.Printf("Failed to insert schedule: %v", err)
 			continue
 		}
 
-		scheduleID, _ := result.LastInsertId()
 
-		// Sample tasks for each schedule
-		tasks := []string{
-			"Assist with morning medication",
-			"Help with personal hygiene",
-			"Prepare light meal",
-			"Check vital signs",
-			"Light housekeeping",
+		// Sample care plan for each client, copied onto the schedule's tasks
+		carePlan := []struct {
+			description string
+			required    bool
+		}{
+			{"Assist with morning medication", true},
+			{"Help with personal hygiene", true},
+			{"Prepare light meal", true},
+			{"Check vital signs", true},
+			{"Light housekeeping", false},
 		}
 
-		for _, task := range tasks {
-			_, err := DB.Exec(`
-				INSERT INTO tasks (schedule_id, description, status)
-				VALUES (?, ?, 'pending')`,
-				scheduleID, task)
+		for _, task := range carePlan {
+			carePlanTaskID, err := DB.Insert(`
+				INSERT INTO care_plan_tasks (client_id, description, frequency, required, created_at, updated_at)
+				VALUES (?, ?, 'every_visit', ?, ?, ?)`,
+				clientID, task.description, task.required,
+				now.Format("2006-01-02 15:04:05"), now.Format("2006-01-02 15:04:05"))
+			if err != nil {
+				log.Printf("Failed to insert care plan task: %v", err)
+				continue
+			}
+
+			_, err = DB.Exec(`
+				INSERT INTO tasks (schedule_id, description, status, required, care_plan_task_id)
+				VALUES (?, ?, 'pending', ?, ?)`,
+				scheduleID, task.description, task.required, carePlanTaskID)
 			if err != nil {
 				log.Printf("Failed to insert task: %v", err)
 			}
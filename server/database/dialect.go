@@ -0,0 +1,66 @@
+package database
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect identifies the SQL flavour of the connected database
+type Dialect string
+
+const (
+	DialectSQLite   Dialect = "sqlite"
+	DialectPostgres Dialect = "postgres"
+)
+
+// driverName returns the database/sql driver registered for the dialect
+func (d Dialect) driverName() string {
+	if d == DialectPostgres {
+		return "pgx"
+	}
+	return "sqlite3"
+}
+
+// Rebind rewrites the ? placeholders queries are written with into the dialect's own
+// style. Question marks inside quoted strings are left alone.
+func (d Dialect) Rebind(query string) string {
+	if d != DialectPostgres || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var rebound strings.Builder
+	rebound.Grow(len(query) + 16)
+	inQuote := false
+	n := 0
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inQuote = !inQuote
+		case r == '?' && !inQuote:
+			n++
+			rebound.WriteByte('$')
+			rebound.WriteString(strconv.Itoa(n))
+			continue
+		}
+		rebound.WriteRune(r)
+	}
+	return rebound.String()
+}
+
+// ForUpdate returns the clause that locks the rows a SELECT reads until the transaction
+// ends. SQLite transactions are opened with _txlock=immediate and so already hold the
+// database's write lock, which makes the clause unnecessary there.
+func (d Dialect) ForUpdate() string {
+	if d == DialectPostgres {
+		return " FOR UPDATE"
+	}
+	return ""
+}
+
+// Date returns an expression for the calendar date of a timestamp column
+func (d Dialect) Date(expr string) string {
+	if d == DialectPostgres {
+		return "CAST(" + expr + " AS DATE)"
+	}
+	return "DATE(" + expr + ")"
+}
@@ -0,0 +1,27 @@
+package database
+
+import "testing"
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect Dialect
+		query   string
+		want    string
+	}{
+		{"sqlite is unchanged", DialectSQLite, "SELECT id FROM users WHERE email = ? AND active = ?", "SELECT id FROM users WHERE email = ? AND active = ?"},
+		{"no placeholders", DialectPostgres, "SELECT COUNT(*) FROM users", "SELECT COUNT(*) FROM users"},
+		{"numbered in order", DialectPostgres, "UPDATE tasks SET status = ?, reason = ? WHERE id = ?", "UPDATE tasks SET status = $1, reason = $2 WHERE id = $3"},
+		{"inside a string literal", DialectPostgres, "SELECT id FROM clients WHERE name = 'Who?' AND id = ?", "SELECT id FROM clients WHERE name = 'Who?' AND id = $1"},
+		{"after an escaped quote", DialectPostgres, "SELECT ? FROM notes WHERE body = 'it''s ok?' AND id = ?", "SELECT $1 FROM notes WHERE body = 'it''s ok?' AND id = $2"},
+		{"next to multi-byte text", DialectPostgres, "SELECT 'café' || ?", "SELECT 'café' || $1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.dialect.Rebind(tt.query); got != tt.want {
+				t.Errorf("Rebind(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+	"hash/fnv"
+)
+
+// TryLock runs fn while holding the named lock and reports whether it ran. When another
+// connection holds the lock, fn is skipped and TryLock returns false. On PostgreSQL this
+// is a session advisory lock, so API instances sharing the database take turns running
+// background jobs; a SQLite database belongs to a single instance, so fn always runs.
+func (c *Conn) TryLock(name string, fn func() error) (bool, error) {
+	if c.Dialect != DialectPostgres {
+		return true, fn()
+	}
+
+	// Advisory locks belong to a session, so lock and unlock on the same connection
+	ctx := context.Background()
+	conn, err := c.DB.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	key := lockKey(name)
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked); err != nil {
+		return false, err
+	}
+	if !locked {
+		return false, nil
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", key)
+
+	return true, fn()
+}
+
+// lockKey maps a lock name to the 64-bit key PostgreSQL advisory locks take
+func lockKey(name string) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(name))
+	return int64(hash.Sum64())
+}
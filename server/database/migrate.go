@@ -0,0 +1,338 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed migrations/sqlite/*.sql migrations/postgres/*.sql
+var migrationFiles embed.FS
+
+// migrationFilePattern matches migration files such as 0002_add_incidents.up.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a numbered schema change with the SQL to apply and revert it
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied and when
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// Migrations returns the migrations shipped with the binary for a dialect, oldest first.
+// Each dialect has its own copy of every migration under migrations/<dialect>.
+func Migrations(dialect Dialect) ([]Migration, error) {
+	dir := path.Join("migrations", string(dialect))
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+
+		content, err := migrationFiles.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// MigrateUp applies every pending migration in order and returns the ones it applied
+func MigrateUp(db *Conn) ([]Migration, error) {
+	migrations, err := Migrations(db.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	legacy, err := isLegacySchema(db)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		err := inTransaction(db, func(tx *Tx) error {
+			// Scripts are run as written: they have no placeholders to rebind
+			if _, err := tx.Tx.Exec(migration.Up); err != nil {
+				return err
+			}
+			if legacy && migration.Version == 1 {
+				if err := upgradeLegacySchema(tx); err != nil {
+					return err
+				}
+			}
+			if backfill, ok := dataMigrations[migration.Version]; ok {
+				if err := backfill(tx); err != nil {
+					return err
+				}
+			}
+			_, err := tx.Exec(
+				"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+				migration.Version, migration.Name, time.Now().UTC().Format("2006-01-02 15:04:05"))
+			return err
+		})
+		if err != nil {
+			return ran, fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+		ran = append(ran, migration)
+	}
+	return ran, nil
+}
+
+// MigrateDown reverts the most recently applied migrations, newest first, and returns
+// the ones it reverted
+func MigrateDown(db *Conn, steps int) ([]Migration, error) {
+	migrations, err := Migrations(db.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+		migration := migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		err := inTransaction(db, func(tx *Tx) error {
+			if _, err := tx.Tx.Exec(migration.Down); err != nil {
+				return err
+			}
+			_, err := tx.Exec("DELETE FROM schema_migrations WHERE version = ?", migration.Version)
+			return err
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		log.Printf("Reverted migration %04d_%s", migration.Version, migration.Name)
+		reverted = append(reverted, migration)
+	}
+	return reverted, nil
+}
+
+// MigrationStatuses lists every known migration and whether it has been applied
+func MigrationStatuses(db *Conn) ([]MigrationStatus, error) {
+	migrations, err := Migrations(db.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := MigrationStatus{Migration: migration}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// appliedVersions creates the schema_migrations table if needed and returns when each
+// applied migration ran, by version
+func appliedVersions(db *Conn) (map[int]time.Time, error) {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL
+		)`)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// inTransaction runs fn in a transaction, committing only if it succeeds
+func inTransaction(db *Conn, fn func(tx *Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// isLegacySchema reports whether the database was created before versioned migrations:
+// it has tables but no record of which migrations ran. Only SQLite databases predate them.
+func isLegacySchema(db *Conn) (bool, error) {
+	if db.Dialect != DialectSQLite {
+		return false, nil
+	}
+
+	var migrationsTable, schedulesTable int
+	err := db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'),
+			(SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schedules')`).
+		Scan(&migrationsTable, &schedulesTable)
+	return migrationsTable == 0 && schedulesTable > 0, err
+}
+
+// upgradeLegacySchema brings a database created before versioned migrations up to the
+// baseline schema. The baseline's CREATE TABLE IF NOT EXISTS leaves existing tables
+// untouched, so columns added since they were created are added here, and the free-text
+// client details older releases copied into every schedule row move to the clients table.
+func upgradeLegacySchema(tx *Tx) error {
+	columns := []struct {
+		table      string
+		name       string
+		definition string
+	}{
+		{"visits", "start_distance_meters", "REAL"},
+		{"visits", "start_geofence_status", "TEXT"},
+		{"visits", "start_override_reason", "TEXT"},
+		{"visits", "end_distance_meters", "REAL"},
+		{"visits", "end_geofence_status", "TEXT"},
+		{"visits", "end_override_reason", "TEXT"},
+		{"schedules", "caregiver_id", "INTEGER REFERENCES caregivers (id)"},
+		{"schedules", "client_id", "INTEGER REFERENCES clients (id)"},
+		{"schedules", "cancellation_reason", "TEXT"},
+		{"schedules", "cancelled_at", "DATETIME"},
+		{"schedules", "template_id", "INTEGER REFERENCES schedule_templates (id)"},
+	}
+
+	for _, column := range columns {
+		exists, err := columnExists(tx, column.table, column.name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		statement := "ALTER TABLE " + column.table + " ADD COLUMN " + column.name + " " + column.definition
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+		log.Printf("Added column %s.%s", column.table, column.name)
+	}
+
+	legacyClients, err := columnExists(tx, "schedules", "client_name")
+	if err != nil || !legacyClients {
+		return err
+	}
+
+	statements := []string{
+		`INSERT INTO clients (name, latitude, longitude)
+		SELECT client_name, latitude, longitude FROM schedules
+		WHERE client_id IS NULL
+		GROUP BY client_name`,
+		`UPDATE schedules SET client_id = (
+			SELECT c.id FROM clients c WHERE c.name = schedules.client_name ORDER BY c.id LIMIT 1
+		) WHERE client_id IS NULL`,
+		`ALTER TABLE schedules DROP COLUMN client_name`,
+		`ALTER TABLE schedules DROP COLUMN latitude`,
+		`ALTER TABLE schedules DROP COLUMN longitude`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	log.Println("Migrated schedule client details into the clients table")
+	return nil
+}
+
+// columnExists reports whether a table already has the named column
+func columnExists(tx *Tx, table, column string) (bool, error) {
+	rows, err := tx.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
@@ -0,0 +1,242 @@
+package database
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"visit-tracker-api/config"
+)
+
+// openSQLite opens an empty SQLite database in a temporary directory
+func openSQLite(t *testing.T) *Conn {
+	t.Helper()
+	Open(config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "visits.db")})
+	db := DB
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// schema returns the statements SQLite keeps for every table and index, by name
+func schema(t *testing.T, db *Conn) map[string]string {
+	t.Helper()
+	rows, err := db.Query("SELECT name, COALESCE(sql, '') FROM sqlite_master WHERE name NOT LIKE 'sqlite_%' AND name != 'schema_migrations'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	statements := map[string]string{}
+	for rows.Next() {
+		var name, statement string
+		if err := rows.Scan(&name, &statement); err != nil {
+			t.Fatal(err)
+		}
+		statements[name] = statement
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return statements
+}
+
+func TestMigrationsRevertCleanly(t *testing.T) {
+	db := openSQLite(t)
+	migrations, err := Migrations(db.Dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MigrateUp(db); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	want := schema(t, db)
+
+	// Revert and reapply each migration in turn, newest first, leaving the ones before it
+	for i := len(migrations) - 1; i >= 0; i-- {
+		steps := len(migrations) - i
+		reverted, err := MigrateDown(db, steps)
+		if err != nil {
+			t.Fatalf("revert %d migrations: %v", steps, err)
+		}
+		if len(reverted) != steps || reverted[steps-1].Version != migrations[i].Version {
+			t.Fatalf("reverted %d migrations down to %v, want %d down to %d", len(reverted), reverted, steps, migrations[i].Version)
+		}
+		if _, err := MigrateUp(db); err != nil {
+			t.Fatalf("reapply from %04d_%s: %v", migrations[i].Version, migrations[i].Name, err)
+		}
+		if got := schema(t, db); !reflect.DeepEqual(got, want) {
+			t.Errorf("schema after reapplying from %04d_%s differs from a fresh migration", migrations[i].Version, migrations[i].Name)
+		}
+	}
+
+	if _, err := MigrateDown(db, len(migrations)); err != nil {
+		t.Fatalf("revert every migration: %v", err)
+	}
+	if got := schema(t, db); len(got) != 0 {
+		t.Errorf("tables left after reverting every migration: %v", got)
+	}
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if status.Applied {
+			t.Errorf("migration %04d_%s still recorded as applied", status.Version, status.Name)
+		}
+	}
+}
+
+func TestMigrationsMatchAcrossDialects(t *testing.T) {
+	sqlite, err := Migrations(DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	postgres, err := Migrations(DialectPostgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sqlite) != len(postgres) {
+		t.Fatalf("%d SQLite migrations, %d PostgreSQL migrations", len(sqlite), len(postgres))
+	}
+	for i := range sqlite {
+		if sqlite[i].Version != postgres[i].Version || sqlite[i].Name != postgres[i].Name {
+			t.Errorf("migration %d is %04d_%s on SQLite but %04d_%s on PostgreSQL",
+				i, sqlite[i].Version, sqlite[i].Name, postgres[i].Version, postgres[i].Name)
+		}
+	}
+}
+
+func TestUpgradeLegacySchema(t *testing.T) {
+	db := openSQLite(t)
+
+	// The schema releases before versioned migrations created, with each schedule
+	// carrying its client's name and location
+	for _, statement := range []string{
+		`CREATE TABLE schedules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			client_name TEXT NOT NULL,
+			shift_start DATETIME NOT NULL,
+			shift_end DATETIME NOT NULL,
+			latitude REAL NOT NULL,
+			longitude REAL NOT NULL,
+			status TEXT NOT NULL DEFAULT 'upcoming',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE tasks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			schedule_id INTEGER NOT NULL,
+			description TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			reason TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (schedule_id) REFERENCES schedules (id)
+		)`,
+		`CREATE TABLE visits (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			schedule_id INTEGER NOT NULL,
+			start_time DATETIME,
+			end_time DATETIME,
+			start_lat REAL,
+			start_lng REAL,
+			end_lat REAL,
+			end_lng REAL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (schedule_id) REFERENCES schedules (id)
+		)`,
+		`CREATE TABLE activities (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			schedule_id INTEGER NOT NULL,
+			title TEXT NOT NULL,
+			description TEXT NOT NULL,
+			is_resolved BOOLEAN NOT NULL DEFAULT 0,
+			reason TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (schedule_id) REFERENCES schedules (id)
+		)`,
+		`INSERT INTO schedules (id, client_name, shift_start, shift_end, latitude, longitude, status) VALUES
+			(1, 'Alice Johnson', '2026-01-05 09:00:00', '2026-01-05 11:00:00', 40.7128, -74.0060, 'completed'),
+			(2, 'Bob Smith', '2026-01-05 13:00:00', '2026-01-05 15:00:00', 40.7580, -73.9855, 'upcoming'),
+			(3, 'Alice Johnson', '2026-01-06 09:00:00', '2026-01-06 11:00:00', 40.7128, -74.0060, 'upcoming')`,
+		`INSERT INTO tasks (schedule_id, description, status) VALUES (1, 'Medication', 'completed'), (2, 'Lunch', 'pending')`,
+		`INSERT INTO visits (schedule_id, start_time, end_time) VALUES (1, '2026-01-05 09:02:00', '2026-01-05 10:58:00'), (2, NULL, NULL), (3, NULL, NULL)`,
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+
+	if legacy, err := isLegacySchema(db); err != nil || !legacy {
+		t.Fatalf("isLegacySchema() = %v, %v; want true", legacy, err)
+	}
+	if _, err := MigrateUp(db); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+
+	rows, err := db.Query(`
+		SELECT s.id, c.name, c.latitude, c.longitude
+		FROM schedules s
+		JOIN clients c ON c.id = s.client_id
+		ORDER BY s.id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	type scheduleClient struct {
+		scheduleID int
+		name       string
+		latitude   float64
+		longitude  float64
+	}
+	var got []scheduleClient
+	for rows.Next() {
+		var row scheduleClient
+		if err := rows.Scan(&row.scheduleID, &row.name, &row.latitude, &row.longitude); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, row)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []scheduleClient{
+		{1, "Alice Johnson", 40.7128, -74.0060},
+		{2, "Bob Smith", 40.7580, -73.9855},
+		{3, "Alice Johnson", 40.7128, -74.0060},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("schedules with their clients = %v, want %v", got, want)
+	}
+
+	var clients, tasks int
+	if err := db.QueryRow("SELECT (SELECT COUNT(*) FROM clients), (SELECT COUNT(*) FROM tasks)").Scan(&clients, &tasks); err != nil {
+		t.Fatal(err)
+	}
+	if clients != 2 || tasks != 2 {
+		t.Errorf("%d clients and %d tasks, want 2 clients, one per name, and the 2 tasks kept", clients, tasks)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	for _, column := range []struct{ table, name string }{
+		{"schedules", "client_name"}, {"schedules", "latitude"}, {"schedules", "longitude"},
+	} {
+		if exists, err := columnExists(tx, column.table, column.name); err != nil || exists {
+			t.Errorf("column %s.%s exists = %v, %v; want it dropped", column.table, column.name, exists, err)
+		}
+	}
+	for _, column := range []struct{ table, name string }{
+		{"schedules", "caregiver_id"}, {"schedules", "template_id"}, {"visits", "start_geofence_status"},
+	} {
+		if exists, err := columnExists(tx, column.table, column.name); err != nil || !exists {
+			t.Errorf("column %s.%s exists = %v, %v; want it added", column.table, column.name, exists, err)
+		}
+	}
+}
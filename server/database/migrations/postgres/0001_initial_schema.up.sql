@@ -0,0 +1,156 @@
+-- Baseline schema: the PostgreSQL equivalent of sqlite/0001_initial_schema.up.sql.
+
+CREATE TABLE IF NOT EXISTS caregivers (
+	id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	email TEXT NOT NULL UNIQUE,
+	phone TEXT,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS users (
+	id SERIAL PRIMARY KEY,
+	email TEXT NOT NULL UNIQUE,
+	password_hash TEXT NOT NULL,
+	role TEXT NOT NULL,
+	caregiver_id INTEGER,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (caregiver_id) REFERENCES caregivers (id)
+);
+
+CREATE TABLE IF NOT EXISTS clients (
+	id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	address TEXT NOT NULL DEFAULT '',
+	latitude DOUBLE PRECISION NOT NULL,
+	longitude DOUBLE PRECISION NOT NULL,
+	care_plan_notes TEXT,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS emergency_contacts (
+	id SERIAL PRIMARY KEY,
+	client_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	relationship TEXT,
+	phone TEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (client_id) REFERENCES clients (id)
+);
+
+CREATE TABLE IF NOT EXISTS schedule_templates (
+	id SERIAL PRIMARY KEY,
+	client_id INTEGER NOT NULL,
+	caregiver_id INTEGER,
+	rrule TEXT NOT NULL,
+	start_time TEXT NOT NULL,
+	end_time TEXT NOT NULL,
+	starts_on DATE NOT NULL,
+	ends_on DATE,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (client_id) REFERENCES clients (id),
+	FOREIGN KEY (caregiver_id) REFERENCES caregivers (id)
+);
+
+CREATE TABLE IF NOT EXISTS schedule_template_tasks (
+	id SERIAL PRIMARY KEY,
+	template_id INTEGER NOT NULL,
+	description TEXT NOT NULL,
+	position INTEGER NOT NULL DEFAULT 0,
+	FOREIGN KEY (template_id) REFERENCES schedule_templates (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS schedule_template_exceptions (
+	id SERIAL PRIMARY KEY,
+	template_id INTEGER NOT NULL,
+	exception_date DATE NOT NULL,
+	reason TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (template_id, exception_date),
+	FOREIGN KEY (template_id) REFERENCES schedule_templates (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS schedules (
+	id SERIAL PRIMARY KEY,
+	caregiver_id INTEGER,
+	client_id INTEGER NOT NULL,
+	shift_start TIMESTAMP NOT NULL,
+	shift_end TIMESTAMP NOT NULL,
+	status TEXT NOT NULL DEFAULT 'upcoming',
+	cancellation_reason TEXT,
+	cancelled_at TIMESTAMP,
+	template_id INTEGER,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (caregiver_id) REFERENCES caregivers (id),
+	FOREIGN KEY (client_id) REFERENCES clients (id),
+	FOREIGN KEY (template_id) REFERENCES schedule_templates (id)
+);
+
+CREATE TABLE IF NOT EXISTS schedule_status_history (
+	id SERIAL PRIMARY KEY,
+	schedule_id INTEGER NOT NULL,
+	from_status TEXT,
+	to_status TEXT NOT NULL,
+	changed_by INTEGER,
+	actor_role TEXT NOT NULL,
+	reason TEXT,
+	latitude DOUBLE PRECISION,
+	longitude DOUBLE PRECISION,
+	ip_address TEXT,
+	request_id TEXT,
+	changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (schedule_id) REFERENCES schedules (id),
+	FOREIGN KEY (changed_by) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS tasks (
+	id SERIAL PRIMARY KEY,
+	schedule_id INTEGER NOT NULL,
+	description TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	reason TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (schedule_id) REFERENCES schedules (id)
+);
+
+CREATE TABLE IF NOT EXISTS visits (
+	id SERIAL PRIMARY KEY,
+	schedule_id INTEGER NOT NULL,
+	start_time TIMESTAMP,
+	end_time TIMESTAMP,
+	start_lat DOUBLE PRECISION,
+	start_lng DOUBLE PRECISION,
+	end_lat DOUBLE PRECISION,
+	end_lng DOUBLE PRECISION,
+	start_distance_meters DOUBLE PRECISION,
+	start_geofence_status TEXT,
+	start_override_reason TEXT,
+	end_distance_meters DOUBLE PRECISION,
+	end_geofence_status TEXT,
+	end_override_reason TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (schedule_id) REFERENCES schedules (id)
+);
+
+CREATE TABLE IF NOT EXISTS activities (
+	id SERIAL PRIMARY KEY,
+	schedule_id INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	description TEXT NOT NULL,
+	is_resolved BOOLEAN NOT NULL DEFAULT FALSE,
+	reason TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (schedule_id) REFERENCES schedules (id)
+);
@@ -0,0 +1,17 @@
+-- Offline events received through POST /sync, keyed by the idempotency key the device
+-- generated so a retried batch is not applied twice.
+
+CREATE TABLE sync_events (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL,
+	idempotency_key TEXT NOT NULL,
+	device_id TEXT,
+	event_type TEXT NOT NULL,
+	schedule_id INTEGER,
+	occurred_at TIMESTAMP NOT NULL,
+	status TEXT NOT NULL,
+	result TEXT,
+	received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (user_id, idempotency_key),
+	FOREIGN KEY (user_id) REFERENCES users (id)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE schedules DROP COLUMN service_code;
+ALTER TABLE clients DROP COLUMN medicaid_id;
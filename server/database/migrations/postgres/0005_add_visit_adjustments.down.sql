@@ -0,0 +1,2 @@
+DROP TABLE visit_adjustments;
+DROP FUNCTION visit_adjustments_immutable();
@@ -0,0 +1,33 @@
+-- Coordinator corrections to clock-in and clock-out times. Each row keeps the times it
+-- replaced, so the values the device recorded are never lost; rows cannot be changed.
+
+CREATE TABLE visit_adjustments (
+	id SERIAL PRIMARY KEY,
+	visit_id INTEGER NOT NULL,
+	schedule_id INTEGER NOT NULL,
+	reason_code TEXT NOT NULL,
+	note TEXT,
+	original_start_time TIMESTAMP,
+	original_end_time TIMESTAMP,
+	adjusted_start_time TIMESTAMP,
+	adjusted_end_time TIMESTAMP,
+	adjusted_by INTEGER,
+	actor_role TEXT NOT NULL,
+	ip_address TEXT,
+	request_id TEXT,
+	created_at TIMESTAMP NOT NULL,
+	FOREIGN KEY (visit_id) REFERENCES visits (id),
+	FOREIGN KEY (schedule_id) REFERENCES schedules (id),
+	FOREIGN KEY (adjusted_by) REFERENCES users (id)
+);
+
+CREATE INDEX idx_visit_adjustments_schedule ON visit_adjustments (schedule_id);
+
+CREATE FUNCTION visit_adjustments_immutable() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'visit adjustments cannot be changed or deleted';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER visit_adjustments_immutable BEFORE UPDATE OR DELETE ON visit_adjustments
+FOR EACH ROW EXECUTE FUNCTION visit_adjustments_immutable();
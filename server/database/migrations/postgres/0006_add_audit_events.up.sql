@@ -0,0 +1,30 @@
+-- Append-only log of every write, recorded in the same transaction as the write. Each
+-- event's hash covers its fields and the previous event's hash, so edits are detected.
+
+CREATE TABLE audit_events (
+	id SERIAL PRIMARY KEY,
+	entity TEXT NOT NULL,
+	entity_id INTEGER NOT NULL,
+	action TEXT NOT NULL,
+	actor_user_id INTEGER,
+	actor_role TEXT NOT NULL,
+	request_id TEXT,
+	ip_address TEXT,
+	before_json TEXT,
+	after_json TEXT,
+	created_at TIMESTAMP NOT NULL,
+	prev_hash TEXT NOT NULL,
+	hash TEXT NOT NULL UNIQUE
+);
+
+CREATE INDEX idx_audit_events_entity ON audit_events (entity, entity_id);
+CREATE INDEX idx_audit_events_created_at ON audit_events (created_at);
+
+CREATE FUNCTION audit_events_immutable() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit events cannot be changed or deleted';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_immutable BEFORE UPDATE OR DELETE ON audit_events
+FOR EACH ROW EXECUTE FUNCTION audit_events_immutable();
@@ -0,0 +1,6 @@
+-- IANA time zone of each client, e.g. America/Chicago. Clients without one use the
+-- agency's AGENCY_TIMEZONE. Timestamps are stored in UTC; the zone decides which
+-- local date a visit falls on. Timestamps written before this migration were in local
+-- time; applying it converts them from AGENCY_TIMEZONE to UTC (see database/backfill.go).
+
+ALTER TABLE clients ADD COLUMN timezone TEXT;
@@ -0,0 +1,22 @@
+-- Each client's care plan: the tasks copied onto their shifts when a schedule is created
+-- or generated from a template, every visit, once a day or once a week. Tasks remember
+-- the care-plan task they came from and whether they must be done.
+
+CREATE TABLE care_plan_tasks (
+	id SERIAL PRIMARY KEY,
+	client_id INTEGER NOT NULL,
+	description TEXT NOT NULL,
+	frequency TEXT NOT NULL DEFAULT 'every_visit',
+	required BOOLEAN NOT NULL DEFAULT TRUE,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	FOREIGN KEY (client_id) REFERENCES clients (id)
+);
+
+CREATE INDEX idx_care_plan_tasks_client ON care_plan_tasks (client_id);
+
+ALTER TABLE tasks ADD COLUMN care_plan_task_id INTEGER REFERENCES care_plan_tasks (id);
+ALTER TABLE tasks ADD COLUMN required BOOLEAN NOT NULL DEFAULT TRUE;
+
+CREATE INDEX idx_tasks_care_plan_task ON tasks (care_plan_task_id);
@@ -0,0 +1,27 @@
+-- Narrative notes written about a visit: what the caregiver observed, incidents and
+-- conversations with the family. Notes are only ever appended; rows cannot be changed.
+
+CREATE TABLE visit_notes (
+	id SERIAL PRIMARY KEY,
+	visit_id INTEGER NOT NULL,
+	schedule_id INTEGER NOT NULL,
+	category TEXT NOT NULL DEFAULT 'general',
+	body TEXT NOT NULL,
+	author_id INTEGER,
+	author_role TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	FOREIGN KEY (visit_id) REFERENCES visits (id),
+	FOREIGN KEY (schedule_id) REFERENCES schedules (id),
+	FOREIGN KEY (author_id) REFERENCES users (id)
+);
+
+CREATE INDEX idx_visit_notes_schedule ON visit_notes (schedule_id);
+
+CREATE FUNCTION visit_notes_immutable() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'visit notes cannot be changed or deleted';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER visit_notes_immutable BEFORE UPDATE OR DELETE ON visit_notes
+FOR EACH ROW EXECUTE FUNCTION visit_notes_immutable();
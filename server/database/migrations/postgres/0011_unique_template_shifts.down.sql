@@ -0,0 +1 @@
+DROP INDEX idx_schedules_template_shift;
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS activities;
+DROP TABLE IF EXISTS visits;
+DROP TABLE IF EXISTS tasks;
+DROP TABLE IF EXISTS schedule_status_history;
+DROP TABLE IF EXISTS schedules;
+DROP TABLE IF EXISTS schedule_template_exceptions;
+DROP TABLE IF EXISTS schedule_template_tasks;
+DROP TABLE IF EXISTS schedule_templates;
+DROP TABLE IF EXISTS emergency_contacts;
+DROP TABLE IF EXISTS clients;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS caregivers;
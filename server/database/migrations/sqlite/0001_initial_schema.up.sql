@@ -0,0 +1,157 @@
+-- Baseline schema: every table the API used before versioned migrations.
+-- IF NOT EXISTS lets databases created by earlier releases adopt it.
+
+CREATE TABLE IF NOT EXISTS caregivers (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	email TEXT NOT NULL UNIQUE,
+	phone TEXT,
+	active BOOLEAN NOT NULL DEFAULT 1,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	email TEXT NOT NULL UNIQUE,
+	password_hash TEXT NOT NULL,
+	role TEXT NOT NULL,
+	caregiver_id INTEGER,
+	active BOOLEAN NOT NULL DEFAULT 1,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (caregiver_id) REFERENCES caregivers (id)
+);
+
+CREATE TABLE IF NOT EXISTS clients (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	address TEXT NOT NULL DEFAULT '',
+	latitude REAL NOT NULL,
+	longitude REAL NOT NULL,
+	care_plan_notes TEXT,
+	active BOOLEAN NOT NULL DEFAULT 1,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS emergency_contacts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	client_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	relationship TEXT,
+	phone TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (client_id) REFERENCES clients (id)
+);
+
+CREATE TABLE IF NOT EXISTS schedule_templates (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	client_id INTEGER NOT NULL,
+	caregiver_id INTEGER,
+	rrule TEXT NOT NULL,
+	start_time TEXT NOT NULL,
+	end_time TEXT NOT NULL,
+	starts_on DATE NOT NULL,
+	ends_on DATE,
+	active BOOLEAN NOT NULL DEFAULT 1,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (client_id) REFERENCES clients (id),
+	FOREIGN KEY (caregiver_id) REFERENCES caregivers (id)
+);
+
+CREATE TABLE IF NOT EXISTS schedule_template_tasks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	template_id INTEGER NOT NULL,
+	description TEXT NOT NULL,
+	position INTEGER NOT NULL DEFAULT 0,
+	FOREIGN KEY (template_id) REFERENCES schedule_templates (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS schedule_template_exceptions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	template_id INTEGER NOT NULL,
+	exception_date DATE NOT NULL,
+	reason TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (template_id, exception_date),
+	FOREIGN KEY (template_id) REFERENCES schedule_templates (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS schedules (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	caregiver_id INTEGER,
+	client_id INTEGER NOT NULL,
+	shift_start DATETIME NOT NULL,
+	shift_end DATETIME NOT NULL,
+	status TEXT NOT NULL DEFAULT 'upcoming',
+	cancellation_reason TEXT,
+	cancelled_at DATETIME,
+	template_id INTEGER,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (caregiver_id) REFERENCES caregivers (id),
+	FOREIGN KEY (client_id) REFERENCES clients (id),
+	FOREIGN KEY (template_id) REFERENCES schedule_templates (id)
+);
+
+CREATE TABLE IF NOT EXISTS schedule_status_history (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	schedule_id INTEGER NOT NULL,
+	from_status TEXT,
+	to_status TEXT NOT NULL,
+	changed_by INTEGER,
+	actor_role TEXT NOT NULL,
+	reason TEXT,
+	latitude REAL,
+	longitude REAL,
+	ip_address TEXT,
+	request_id TEXT,
+	changed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (schedule_id) REFERENCES schedules (id),
+	FOREIGN KEY (changed_by) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS tasks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	schedule_id INTEGER NOT NULL,
+	description TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	reason TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (schedule_id) REFERENCES schedules (id)
+);
+
+CREATE TABLE IF NOT EXISTS visits (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	schedule_id INTEGER NOT NULL,
+	start_time DATETIME,
+	end_time DATETIME,
+	start_lat REAL,
+	start_lng REAL,
+	end_lat REAL,
+	end_lng REAL,
+	start_distance_meters REAL,
+	start_geofence_status TEXT,
+	start_override_reason TEXT,
+	end_distance_meters REAL,
+	end_geofence_status TEXT,
+	end_override_reason TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (schedule_id) REFERENCES schedules (id)
+);
+
+CREATE TABLE IF NOT EXISTS activities (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	schedule_id INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	description TEXT NOT NULL,
+	is_resolved BOOLEAN NOT NULL DEFAULT 0,
+	reason TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (schedule_id) REFERENCES schedules (id)
+);
@@ -0,0 +1,18 @@
+-- Requests made with an Idempotency-Key header and the responses they returned, so a
+-- retried request gets the original response instead of running again.
+
+CREATE TABLE idempotency_keys (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	idempotency_key TEXT NOT NULL,
+	request_method TEXT NOT NULL,
+	request_path TEXT NOT NULL,
+	request_fingerprint TEXT NOT NULL,
+	response_status INTEGER,
+	response_content_type TEXT,
+	response_body TEXT,
+	created_at DATETIME NOT NULL,
+	completed_at DATETIME,
+	UNIQUE (user_id, idempotency_key),
+	FOREIGN KEY (user_id) REFERENCES users (id)
+);
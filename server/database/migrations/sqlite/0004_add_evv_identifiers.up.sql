@@ -0,0 +1,5 @@
+-- Identifiers state EVV aggregators require: the recipient's Medicaid ID and the
+-- service (procedure) code billed for each visit.
+
+ALTER TABLE clients ADD COLUMN medicaid_id TEXT;
+ALTER TABLE schedules ADD COLUMN service_code TEXT;
@@ -0,0 +1,34 @@
+-- Coordinator corrections to clock-in and clock-out times. Each row keeps the times it
+-- replaced, so the values the device recorded are never lost; rows cannot be changed.
+
+CREATE TABLE visit_adjustments (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	visit_id INTEGER NOT NULL,
+	schedule_id INTEGER NOT NULL,
+	reason_code TEXT NOT NULL,
+	note TEXT,
+	original_start_time DATETIME,
+	original_end_time DATETIME,
+	adjusted_start_time DATETIME,
+	adjusted_end_time DATETIME,
+	adjusted_by INTEGER,
+	actor_role TEXT NOT NULL,
+	ip_address TEXT,
+	request_id TEXT,
+	created_at DATETIME NOT NULL,
+	FOREIGN KEY (visit_id) REFERENCES visits (id),
+	FOREIGN KEY (schedule_id) REFERENCES schedules (id),
+	FOREIGN KEY (adjusted_by) REFERENCES users (id)
+);
+
+CREATE INDEX idx_visit_adjustments_schedule ON visit_adjustments (schedule_id);
+
+CREATE TRIGGER visit_adjustments_no_update BEFORE UPDATE ON visit_adjustments
+BEGIN
+	SELECT RAISE(ABORT, 'visit adjustments cannot be changed');
+END;
+
+CREATE TRIGGER visit_adjustments_no_delete BEFORE DELETE ON visit_adjustments
+BEGIN
+	SELECT RAISE(ABORT, 'visit adjustments cannot be deleted');
+END;
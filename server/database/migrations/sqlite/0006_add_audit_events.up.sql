@@ -0,0 +1,31 @@
+-- Append-only log of every write, recorded in the same transaction as the write. Each
+-- event's hash covers its fields and the previous event's hash, so edits are detected.
+
+CREATE TABLE audit_events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	entity TEXT NOT NULL,
+	entity_id INTEGER NOT NULL,
+	action TEXT NOT NULL,
+	actor_user_id INTEGER,
+	actor_role TEXT NOT NULL,
+	request_id TEXT,
+	ip_address TEXT,
+	before_json TEXT,
+	after_json TEXT,
+	created_at DATETIME NOT NULL,
+	prev_hash TEXT NOT NULL,
+	hash TEXT NOT NULL UNIQUE
+);
+
+CREATE INDEX idx_audit_events_entity ON audit_events (entity, entity_id);
+CREATE INDEX idx_audit_events_created_at ON audit_events (created_at);
+
+CREATE TRIGGER audit_events_no_update BEFORE UPDATE ON audit_events
+BEGIN
+	SELECT RAISE(ABORT, 'audit events cannot be changed');
+END;
+
+CREATE TRIGGER audit_events_no_delete BEFORE DELETE ON audit_events
+BEGIN
+	SELECT RAISE(ABORT, 'audit events cannot be deleted');
+END;
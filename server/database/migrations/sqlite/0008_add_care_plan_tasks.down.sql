@@ -0,0 +1,4 @@
+DROP INDEX idx_tasks_care_plan_task;
+ALTER TABLE tasks DROP COLUMN required;
+ALTER TABLE tasks DROP COLUMN care_plan_task_id;
+DROP TABLE care_plan_tasks;
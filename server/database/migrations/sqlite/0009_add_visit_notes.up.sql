@@ -0,0 +1,28 @@
+-- Narrative notes written about a visit: what the caregiver observed, incidents and
+-- conversations with the family. Notes are only ever appended; rows cannot be changed.
+
+CREATE TABLE visit_notes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	visit_id INTEGER NOT NULL,
+	schedule_id INTEGER NOT NULL,
+	category TEXT NOT NULL DEFAULT 'general',
+	body TEXT NOT NULL,
+	author_id INTEGER,
+	author_role TEXT NOT NULL,
+	created_at DATETIME NOT NULL,
+	FOREIGN KEY (visit_id) REFERENCES visits (id),
+	FOREIGN KEY (schedule_id) REFERENCES schedules (id),
+	FOREIGN KEY (author_id) REFERENCES users (id)
+);
+
+CREATE INDEX idx_visit_notes_schedule ON visit_notes (schedule_id);
+
+CREATE TRIGGER visit_notes_no_update BEFORE UPDATE ON visit_notes
+BEGIN
+	SELECT RAISE(ABORT, 'visit notes cannot be changed');
+END;
+
+CREATE TRIGGER visit_notes_no_delete BEFORE DELETE ON visit_notes
+BEGIN
+	SELECT RAISE(ABORT, 'visit notes cannot be deleted');
+END;
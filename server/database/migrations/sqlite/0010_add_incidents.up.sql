@@ -0,0 +1,31 @@
+-- Incidents filed against a visit, such as falls, medication errors and injuries, with
+-- their severity and the coordinator follow-up until they are resolved.
+
+CREATE TABLE incidents (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	schedule_id INTEGER NOT NULL,
+	client_id INTEGER NOT NULL,
+	caregiver_id INTEGER,
+	category TEXT NOT NULL,
+	severity TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'open',
+	description TEXT NOT NULL,
+	occurred_at DATETIME NOT NULL,
+	injury BOOLEAN NOT NULL DEFAULT 0,
+	injury_details TEXT,
+	follow_up_notes TEXT,
+	reported_by INTEGER,
+	reporter_role TEXT NOT NULL,
+	resolved_by INTEGER,
+	resolved_at DATETIME,
+	created_at DATETIME NOT NULL,
+	updated_at DATETIME NOT NULL,
+	FOREIGN KEY (schedule_id) REFERENCES schedules (id),
+	FOREIGN KEY (client_id) REFERENCES clients (id),
+	FOREIGN KEY (caregiver_id) REFERENCES caregivers (id),
+	FOREIGN KEY (reported_by) REFERENCES users (id),
+	FOREIGN KEY (resolved_by) REFERENCES users (id)
+);
+
+CREATE INDEX idx_incidents_schedule ON incidents (schedule_id);
+CREATE INDEX idx_incidents_status ON incidents (status);
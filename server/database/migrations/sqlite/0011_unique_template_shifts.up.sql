@@ -0,0 +1,26 @@
+-- A template generates each shift once. Shifts generated twice, by API instances
+-- racing each other, are detached from the template, keeping the first one, and the
+-- duplicates that have not started are cancelled with their history kept.
+
+CREATE TEMP TABLE duplicate_template_shifts AS
+SELECT id FROM schedules
+WHERE template_id IS NOT NULL
+  AND id NOT IN (SELECT MIN(id) FROM schedules WHERE template_id IS NOT NULL GROUP BY template_id, shift_start);
+
+INSERT INTO schedule_status_history (schedule_id, from_status, to_status, actor_role, reason, changed_at)
+SELECT id, status, 'cancelled', 'system', 'Duplicate of a shift generated from the same template', strftime('%Y-%m-%d %H:%M:%S', 'now')
+FROM schedules
+WHERE id IN (SELECT id FROM duplicate_template_shifts) AND status IN ('upcoming', 'late');
+
+UPDATE schedules
+SET status = 'cancelled',
+	cancellation_reason = 'Duplicate of a shift generated from the same template',
+	cancelled_at = strftime('%Y-%m-%d %H:%M:%S', 'now'),
+	updated_at = strftime('%Y-%m-%d %H:%M:%S', 'now')
+WHERE id IN (SELECT id FROM duplicate_template_shifts) AND status IN ('upcoming', 'late');
+
+UPDATE schedules SET template_id = NULL WHERE id IN (SELECT id FROM duplicate_template_shifts);
+
+DROP TABLE duplicate_template_shifts;
+
+CREATE UNIQUE INDEX idx_schedules_template_shift ON schedules (template_id, shift_start);
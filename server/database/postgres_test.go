@@ -0,0 +1,111 @@
+package database
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"visit-tracker-api/config"
+)
+
+// openPostgres connects to the PostgreSQL database named by POSTGRES_URL and migrates it,
+// or skips the test when it is not set. Tests share the database, so the rows they add
+// must not collide with each other and are removed afterwards.
+func openPostgres(t *testing.T) *Conn {
+	t.Helper()
+	url := os.Getenv("POSTGRES_URL")
+	if url == "" {
+		t.Skip("POSTGRES_URL not set")
+	}
+
+	Open(config.DatabaseConfig{PostgresURL: url})
+	db := DB
+	t.Cleanup(func() { db.Close() })
+	if _, err := MigrateUp(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func TestPostgresTryLock(t *testing.T) {
+	db := openPostgres(t)
+	name := "test-lock-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	var heldElsewhere, otherLockRan bool
+	ran, err := db.TryLock(name, func() error {
+		// A second session, as another API instance would use, cannot take the same lock
+		innerRan, err := db.TryLock(name, func() error { return nil })
+		if err != nil {
+			return err
+		}
+		heldElsewhere = !innerRan
+		otherLockRan, err = db.TryLock(name+"-other", func() error { return nil })
+		return err
+	})
+	if err != nil {
+		t.Fatalf("TryLock: %v", err)
+	}
+	if !ran {
+		t.Fatal("TryLock did not run fn on a free lock")
+	}
+	if !heldElsewhere {
+		t.Error("a second session took a lock that was already held")
+	}
+	if !otherLockRan {
+		t.Error("a lock with another name was blocked")
+	}
+
+	if ran, err := db.TryLock(name, func() error { return nil }); err != nil || !ran {
+		t.Errorf("TryLock after release = %v, %v; want the lock to be free again", ran, err)
+	}
+}
+
+func TestPostgresOnConflictInsert(t *testing.T) {
+	db := openPostgres(t)
+	key := "test-key-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	t.Cleanup(func() { db.Exec("DELETE FROM idempotency_keys WHERE idempotency_key = ?", key) })
+
+	insert := `
+		INSERT INTO idempotency_keys (user_id, idempotency_key, request_method, request_path, request_fingerprint, created_at)
+		VALUES (?, ?, 'POST', '/schedules/1/start', ?, ?)
+		ON CONFLICT (user_id, idempotency_key) DO NOTHING`
+	created := time.Now().UTC().Format("2006-01-02 15:04:05")
+
+	for i, want := range []int64{1, 0} {
+		result, err := db.Exec(insert, 1, key, "fingerprint-"+strconv.Itoa(i), created)
+		if err != nil {
+			t.Fatalf("insert %d: %v", i+1, err)
+		}
+		if affected, err := result.RowsAffected(); err != nil || affected != want {
+			t.Errorf("insert %d affected %d rows (%v), want %d", i+1, affected, err, want)
+		}
+	}
+
+	var fingerprint string
+	if err := db.QueryRow("SELECT request_fingerprint FROM idempotency_keys WHERE idempotency_key = ?", key).Scan(&fingerprint); err != nil {
+		t.Fatal(err)
+	}
+	if fingerprint != "fingerprint-0" {
+		t.Errorf("fingerprint = %s, want the first insert's to be kept", fingerprint)
+	}
+}
+
+func TestPostgresInsertAndRebind(t *testing.T) {
+	db := openPostgres(t)
+	email := "test-" + strconv.FormatInt(time.Now().UnixNano(), 10) + "@example.com"
+	t.Cleanup(func() { db.Exec("DELETE FROM caregivers WHERE email = ?", email) })
+
+	id, err := db.Insert("INSERT INTO caregivers (name, email) VALUES ('Who?', ?)", email)
+	if err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if id == 0 {
+		t.Error("Insert returned no id")
+	}
+
+	var name string
+	if err := db.QueryRow("SELECT name FROM caregivers WHERE id = ? AND name = 'Who?'", id).Scan(&name); err != nil {
+		t.Fatalf("select with ? in a literal: %v", err)
+	}
+}
@@ -5,88 +5,189 @@
 DELETE FROM activities;
 DELETE FROM visits;
 DELETE FROM tasks;
+DELETE FROM care_plan_tasks;
+DELETE FROM schedule_status_history;
 DELETE FROM schedules;
+DELETE FROM schedule_template_exceptions;
+DELETE FROM schedule_template_tasks;
+DELETE FROM schedule_templates;
+DELETE FROM emergency_contacts;
+DELETE FROM clients;
+DELETE FROM users;
+DELETE FROM caregivers;
+
+-- Insert caregivers
+INSERT INTO caregivers (name, email, phone, active) VALUES
+('Sarah Johnson', 'sarah.johnson@example.com', '555-0101', 1),
+('Michael Brown', 'michael.brown@example.com', '555-0102', 1),
+('Linda Martinez', 'linda.martinez@example.com', '555-0103', 1);
+
+-- Insert user accounts (demo password for all: password123)
+INSERT INTO users (email, password_hash, role, caregiver_id) VALUES
+('admin@example.com', '$2a$10$Q5HfoEdTyjnXZZmpqtW.SuFp8QCgkxZd5cHdU2wU.khw0zMd.eK7C', 'admin', NULL),
+('coordinator@example.com', '$2a$10$Q5HfoEdTyjnXZZmpqtW.SuFp8QCgkxZd5cHdU2wU.khw0zMd.eK7C', 'coordinator', NULL),
+('sarah.johnson@example.com', '$2a$10$Q5HfoEdTyjnXZZmpqtW.SuFp8QCgkxZd5cHdU2wU.khw0zMd.eK7C', 'caregiver', (SELECT id FROM caregivers WHERE email = 'sarah.johnson@example.com')),
+('michael.brown@example.com', '$2a$10$Q5HfoEdTyjnXZZmpqtW.SuFp8QCgkxZd5cHdU2wU.khw0zMd.eK7C', 'caregiver', (SELECT id FROM caregivers WHERE email = 'michael.brown@example.com')),
+('linda.martinez@example.com', '$2a$10$Q5HfoEdTyjnXZZmpqtW.SuFp8QCgkxZd5cHdU2wU.khw0zMd.eK7C', 'caregiver', (SELECT id FROM caregivers WHERE email = 'linda.martinez@example.com'));
+
+-- Insert clients (care recipients)
+INSERT INTO clients (name, address, latitude, longitude, care_plan_notes) VALUES
+('Margaret Thompson', '100 Maple Avenue, New York, NY', 40.7128, -74.0060, 'Type 2 diabetes. Check blood glucose before meals. Uses a walker.'),
+('Robert Chen', '107 Oak Street, New York, NY', 40.7589, -73.9851, 'Post-stroke recovery with left-side weakness. Encourage prescribed exercises.'),
+('Eleanor Rodriguez', '114 Cedar Lane, New York, NY', 40.6892, -74.0445, 'Early-stage dementia. Keep routine consistent and remind about medications.'),
+('James Mitchell', '121 Elm Street, New York, NY', 40.7831, -73.9712, 'Congestive heart failure. Low-sodium diet, weigh daily.'),
+('Dorothy Williams', '128 Pine Road, New York, NY', 40.7505, -73.9934, 'Hip replacement (6 weeks ago). Fall risk, assist with transfers.'),
+('Benjamin Foster', '135 Willow Court, New York, NY', 40.7282, -74.0776, NULL),
+('Catherine Davis', '142 Birch Drive, New York, NY', 40.7614, -73.9776, NULL),
+('Harold Johnson', '149 Spruce Street, New York, NY', 40.6782, -73.9442, NULL),
+('Patricia Garcia', '156 Hawthorn Place, New York, NY', 40.7489, -73.9680, NULL),
+('Frank Anderson', '163 Chestnut Street, New York, NY', 40.7505, -73.9934, NULL),
+('Alice Murphy', '170 Maple Avenue, New York, NY', 40.7328, -74.0076, NULL),
+('George Brown', '177 Oak Street, New York, NY', 40.7549, -73.9840, NULL),
+('Mary O''Connor', '184 Cedar Lane, New York, NY', 40.6912, -74.0402, NULL),
+('Thomas Wilson', '191 Elm Street, New York, NY', 40.7780, -73.9665, NULL),
+('Helen Martinez', '198 Pine Road, New York, NY', 40.7448, -73.9876, NULL),
+('Charles Taylor', '205 Willow Court, New York, NY', 40.7178, -74.0431, NULL),
+('Ruth Jackson', '212 Birch Drive, New York, NY', 40.7690, -73.9653, NULL),
+('William Lee', '219 Spruce Street, New York, NY', 40.6823, -73.9654, NULL),
+('Betty White', '226 Hawthorn Place, New York, NY', 40.7720, -73.9570, NULL),
+('Arthur Harris', '233 Chestnut Street, New York, NY', 40.7377, -74.0059, NULL),
+('Joan Thompson', '240 Maple Avenue, New York, NY', 40.7255, -74.0134, NULL),
+('Edward Clark', '247 Oak Street, New York, NY', 40.7505, -73.9934, NULL),
+('Evelyn Lewis', '254 Cedar Lane, New York, NY', 40.6734, -73.9389, NULL),
+('Joseph Robinson', '261 Elm Street, New York, NY', 40.7831, -73.9665, NULL),
+('Mildred Walker', '268 Pine Road, New York, NY', 40.7282, -73.9776, NULL),
+('Raymond Hall', '275 Willow Court, New York, NY', 40.7420, -74.0124, NULL),
+('Frances Allen', '282 Birch Drive, New York, NY', 40.7648, -73.9776, NULL),
+('Louis Young', '289 Spruce Street, New York, NY', 40.6901, -73.9567, NULL),
+('Marie King', '296 Hawthorn Place, New York, NY', 40.7505, -73.9934, NULL),
+('Kenneth Wright', '303 Chestnut Street, New York, NY', 40.7178, -74.0431, NULL),
+('Florence Lopez', '310 Maple Avenue, New York, NY', 40.7589, -73.9851, NULL),
+('Albert Hill', '317 Oak Street, New York, NY', 40.7282, -74.0776, NULL),
+('Gladys Scott', '324 Cedar Lane, New York, NY', 40.6823, -73.9654, NULL),
+('Victor Green', '331 Elm Street, New York, NY', 40.7720, -73.9570, NULL),
+('Lillian Adams', '338 Pine Road, New York, NY', 40.7377, -74.0059, NULL),
+('Ralph Baker', '345 Willow Court, New York, NY', 40.7255, -74.0134, NULL),
+('Rose Gonzalez', '352 Birch Drive, New York, NY', 40.7505, -73.9934, NULL),
+('Carl Nelson', '359 Spruce Street, New York, NY', 40.6734, -73.9389, NULL),
+('Irene Carter', '366 Hawthorn Place, New York, NY', 40.7831, -73.9665, NULL),
+('Eugene Mitchell', '373 Chestnut Street, New York, NY', 40.7282, -73.9776, NULL);
+
+-- Medicaid IDs for EVV export; the remaining clients are left without one
+UPDATE clients SET medicaid_id = 'NY10000001' WHERE name = 'Margaret Thompson';
+UPDATE clients SET medicaid_id = 'NY10000002' WHERE name = 'Robert Chen';
+UPDATE clients SET medicaid_id = 'NY10000003' WHERE name = 'Eleanor Rodriguez';
+UPDATE clients SET medicaid_id = 'NY10000004' WHERE name = 'James Mitchell';
+UPDATE clients SET medicaid_id = 'NY10000005' WHERE name = 'Dorothy Williams';
+
+-- Insert emergency contacts
+INSERT INTO emergency_contacts (client_id, name, relationship, phone) VALUES
+((SELECT id FROM clients WHERE name = 'Margaret Thompson'), 'Susan Thompson', 'Daughter', '555-0201'),
+((SELECT id FROM clients WHERE name = 'Robert Chen'), 'Amy Chen', 'Spouse', '555-0202'),
+((SELECT id FROM clients WHERE name = 'Robert Chen'), 'David Chen', 'Son', '555-0203'),
+((SELECT id FROM clients WHERE name = 'Eleanor Rodriguez'), 'Carlos Rodriguez', 'Son', '555-0204'),
+((SELECT id FROM clients WHERE name = 'James Mitchell'), 'Karen Mitchell', 'Daughter', '555-0205'),
+((SELECT id FROM clients WHERE name = 'Dorothy Williams'), 'Paul Williams', 'Nephew', '555-0206');
 
 -- Insert schedules for today + 7 days ahead (5 schedules per day)
 -- Day 0 (Today)
-INSERT INTO schedules (client_name, shift_start, shift_end, latitude, longitude, status) VALUES
-('Margaret Thompson', datetime('now', 'start of day', '+8 hours'), datetime('now', 'start of day', '+10 hours'), 40.7128, -74.0060, 'upcoming'),
-('Robert Chen', datetime('now', 'start of day', '+9 hours'), datetime('now', 'start of day', '+11 hours'), 40.7589, -73.9851, 'upcoming'),
-('Eleanor Rodriguez', datetime('now', 'start of day', '+13 hours'), datetime('now', 'start of day', '+15 hours'), 40.6892, -74.0445, 'upcoming'),
-('James Mitchell', datetime('now', 'start of day', '+14 hours'), datetime('now', 'start of day', '+16 hours'), 40.7831, -73.9712, 'upcoming'),
-('Dorothy Williams', datetime('now', 'start of day', '+16 hours'), datetime('now', 'start of day', '+18 hours'), 40.7505, -73.9934, 'upcoming');
+INSERT INTO schedules (client_id, shift_start, shift_end, status) VALUES
+((SELECT id FROM clients WHERE name = 'Margaret Thompson'), datetime('now', 'start of day', '+8 hours'), datetime('now', 'start of day', '+10 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Robert Chen'), datetime('now', 'start of day', '+9 hours'), datetime('now', 'start of day', '+11 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Eleanor Rodriguez'), datetime('now', 'start of day', '+13 hours'), datetime('now', 'start of day', '+15 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'James Mitchell'), datetime('now', 'start of day', '+14 hours'), datetime('now', 'start of day', '+16 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Dorothy Williams'), datetime('now', 'start of day', '+16 hours'), datetime('now', 'start of day', '+18 hours'), 'upcoming');
 
 -- Day 1 (Tomorrow)
-INSERT INTO schedules (client_name, shift_start, shift_end, latitude, longitude, status) VALUES
-('Benjamin Foster', datetime('now', '+1 day', 'start of day', '+7 hours'), datetime('now', '+1 day', 'start of day', '+9 hours'), 40.7282, -74.0776, 'upcoming'),
-('Catherine Davis', datetime('now', '+1 day', 'start of day', '+9 hours'), datetime('now', '+1 day', 'start of day', '+11 hours'), 40.7614, -73.9776, 'upcoming'),
-('Harold Johnson', datetime('now', '+1 day', 'start of day', '+12 hours'), datetime('now', '+1 day', 'start of day', '+14 hours'), 40.6782, -73.9442, 'upcoming'),
-('Patricia Garcia', datetime('now', '+1 day', 'start of day', '+14 hours'), datetime('now', '+1 day', 'start of day', '+16 hours'), 40.7489, -73.9680, 'upcoming'),
-('Frank Anderson', datetime('now', '+1 day', 'start of day', '+17 hours'), datetime('now', '+1 day', 'start of day', '+19 hours'), 40.7505, -73.9934, 'upcoming');
+INSERT INTO schedules (client_id, shift_start, shift_end, status) VALUES
+((SELECT id FROM clients WHERE name = 'Benjamin Foster'), datetime('now', '+1 day', 'start of day', '+7 hours'), datetime('now', '+1 day', 'start of day', '+9 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Catherine Davis'), datetime('now', '+1 day', 'start of day', '+9 hours'), datetime('now', '+1 day', 'start of day', '+11 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Harold Johnson'), datetime('now', '+1 day', 'start of day', '+12 hours'), datetime('now', '+1 day', 'start of day', '+14 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Patricia Garcia'), datetime('now', '+1 day', 'start of day', '+14 hours'), datetime('now', '+1 day', 'start of day', '+16 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Frank Anderson'), datetime('now', '+1 day', 'start of day', '+17 hours'), datetime('now', '+1 day', 'start of day', '+19 hours'), 'upcoming');
 
 -- Day 2
-INSERT INTO schedules (client_name, shift_start, shift_end, latitude, longitude, status) VALUES
-('Alice Murphy', datetime('now', '+2 days', 'start of day', '+8 hours'), datetime('now', '+2 days', 'start of day', '+10 hours'), 40.7328, -74.0076, 'upcoming'),
-('George Brown', datetime('now', '+2 days', 'start of day', '+10 hours'), datetime('now', '+2 days', 'start of day', '+12 hours'), 40.7549, -73.9840, 'upcoming'),
-('Mary O''Connor', datetime('now', '+2 days', 'start of day', '+13 hours'), datetime('now', '+2 days', 'start of day', '+15 hours'), 40.6912, -74.0402, 'upcoming'),
-('Thomas Wilson', datetime('now', '+2 days', 'start of day', '+15 hours'), datetime('now', '+2 days', 'start of day', '+17 hours'), 40.7780, -73.9665, 'upcoming'),
-('Helen Martinez', datetime('now', '+2 days', 'start of day', '+17 hours'), datetime('now', '+2 days', 'start of day', '+19 hours'), 40.7448, -73.9876, 'upcoming');
+INSERT INTO schedules (client_id, shift_start, shift_end, status) VALUES
+((SELECT id FROM clients WHERE name = 'Alice Murphy'), datetime('now', '+2 days', 'start of day', '+8 hours'), datetime('now', '+2 days', 'start of day', '+10 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'George Brown'), datetime('now', '+2 days', 'start of day', '+10 hours'), datetime('now', '+2 days', 'start of day', '+12 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Mary O''Connor'), datetime('now', '+2 days', 'start of day', '+13 hours'), datetime('now', '+2 days', 'start of day', '+15 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Thomas Wilson'), datetime('now', '+2 days', 'start of day', '+15 hours'), datetime('now', '+2 days', 'start of day', '+17 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Helen Martinez'), datetime('now', '+2 days', 'start of day', '+17 hours'), datetime('now', '+2 days', 'start of day', '+19 hours'), 'upcoming');
 
 -- Day 3
-INSERT INTO schedules (client_name, shift_start, shift_end, latitude, longitude, status) VALUES
-('Charles Taylor', datetime('now', '+3 days', 'start of day', '+7 hours'), datetime('now', '+3 days', 'start of day', '+9 hours'), 40.7178, -74.0431, 'upcoming'),
-('Ruth Jackson', datetime('now', '+3 days', 'start of day', '+9 hours'), datetime('now', '+3 days', 'start of day', '+11 hours'), 40.7690, -73.9653, 'upcoming'),
-('William Lee', datetime('now', '+3 days', 'start of day', '+12 hours'), datetime('now', '+3 days', 'start of day', '+14 hours'), 40.6823, -73.9654, 'upcoming'),
-('Betty White', datetime('now', '+3 days', 'start of day', '+14 hours'), datetime('now', '+3 days', 'start of day', '+16 hours'), 40.7720, -73.9570, 'upcoming'),
-('Arthur Harris', datetime('now', '+3 days', 'start of day', '+16 hours'), datetime('now', '+3 days', 'start of day', '+18 hours'), 40.7377, -74.0059, 'upcoming');
+INSERT INTO schedules (client_id, shift_start, shift_end, status) VALUES
+((SELECT id FROM clients WHERE name = 'Charles Taylor'), datetime('now', '+3 days', 'start of day', '+7 hours'), datetime('now', '+3 days', 'start of day', '+9 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Ruth Jackson'), datetime('now', '+3 days', 'start of day', '+9 hours'), datetime('now', '+3 days', 'start of day', '+11 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'William Lee'), datetime('now', '+3 days', 'start of day', '+12 hours'), datetime('now', '+3 days', 'start of day', '+14 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Betty White'), datetime('now', '+3 days', 'start of day', '+14 hours'), datetime('now', '+3 days', 'start of day', '+16 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Arthur Harris'), datetime('now', '+3 days', 'start of day', '+16 hours'), datetime('now', '+3 days', 'start of day', '+18 hours'), 'upcoming');
 
 -- Day 4
-INSERT INTO schedules (client_name, shift_start, shift_end, latitude, longitude, status) VALUES
-('Joan Thompson', datetime('now', '+4 days', 'start of day', '+8 hours'), datetime('now', '+4 days', 'start of day', '+10 hours'), 40.7255, -74.0134, 'upcoming'),
-('Edward Clark', datetime('now', '+4 days', 'start of day', '+10 hours'), datetime('now', '+4 days', 'start of day', '+12 hours'), 40.7505, -73.9934, 'upcoming'),
-('Evelyn Lewis', datetime('now', '+4 days', 'start of day', '+13 hours'), datetime('now', '+4 days', 'start of day', '+15 hours'), 40.6734, -73.9389, 'upcoming'),
-('Joseph Robinson', datetime('now', '+4 days', 'start of day', '+15 hours'), datetime('now', '+4 days', 'start of day', '+17 hours'), 40.7831, -73.9665, 'upcoming'),
-('Mildred Walker', datetime('now', '+4 days', 'start of day', '+17 hours'), datetime('now', '+4 days', 'start of day', '+19 hours'), 40.7282, -73.9776, 'upcoming');
+INSERT INTO schedules (client_id, shift_start, shift_end, status) VALUES
+((SELECT id FROM clients WHERE name = 'Joan Thompson'), datetime('now', '+4 days', 'start of day', '+8 hours'), datetime('now', '+4 days', 'start of day', '+10 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Edward Clark'), datetime('now', '+4 days', 'start of day', '+10 hours'), datetime('now', '+4 days', 'start of day', '+12 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Evelyn Lewis'), datetime('now', '+4 days', 'start of day', '+13 hours'), datetime('now', '+4 days', 'start of day', '+15 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Joseph Robinson'), datetime('now', '+4 days', 'start of day', '+15 hours'), datetime('now', '+4 days', 'start of day', '+17 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Mildred Walker'), datetime('now', '+4 days', 'start of day', '+17 hours'), datetime('now', '+4 days', 'start of day', '+19 hours'), 'upcoming');
 
 -- Day 5
-INSERT INTO schedules (client_name, shift_start, shift_end, latitude, longitude, status) VALUES
-('Raymond Hall', datetime('now', '+5 days', 'start of day', '+7 hours'), datetime('now', '+5 days', 'start of day', '+9 hours'), 40.7420, -74.0124, 'upcoming'),
-('Frances Allen', datetime('now', '+5 days', 'start of day', '+9 hours'), datetime('now', '+5 days', 'start of day', '+11 hours'), 40.7648, -73.9776, 'upcoming'),
-('Louis Young', datetime('now', '+5 days', 'start of day', '+12 hours'), datetime('now', '+5 days', 'start of day', '+14 hours'), 40.6901, -73.9567, 'upcoming'),
-('Marie King', datetime('now', '+5 days', 'start of day', '+14 hours'), datetime('now', '+5 days', 'start of day', '+16 hours'), 40.7505, -73.9934, 'upcoming'),
-('Kenneth Wright', datetime('now', '+5 days', 'start of day', '+16 hours'), datetime('now', '+5 days', 'start of day', '+18 hours'), 40.7178, -74.0431, 'upcoming');
+INSERT INTO schedules (client_id, shift_start, shift_end, status) VALUES
+((SELECT id FROM clients WHERE name = 'Raymond Hall'), datetime('now', '+5 days', 'start of day', '+7 hours'), datetime('now', '+5 days', 'start of day', '+9 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Frances Allen'), datetime('now', '+5 days', 'start of day', '+9 hours'), datetime('now', '+5 days', 'start of day', '+11 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Louis Young'), datetime('now', '+5 days', 'start of day', '+12 hours'), datetime('now', '+5 days', 'start of day', '+14 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Marie King'), datetime('now', '+5 days', 'start of day', '+14 hours'), datetime('now', '+5 days', 'start of day', '+16 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Kenneth Wright'), datetime('now', '+5 days', 'start of day', '+16 hours'), datetime('now', '+5 days', 'start of day', '+18 hours'), 'upcoming');
 
 -- Day 6
-INSERT INTO schedules (client_name, shift_start, shift_end, latitude, longitude, status) VALUES
-('Florence Lopez', datetime('now', '+6 days', 'start of day', '+8 hours'), datetime('now', '+6 days', 'start of day', '+10 hours'), 40.7589, -73.9851, 'upcoming'),
-('Albert Hill', datetime('now', '+6 days', 'start of day', '+10 hours'), datetime('now', '+6 days', 'start of day', '+12 hours'), 40.7282, -74.0776, 'upcoming'),
-('Gladys Scott', datetime('now', '+6 days', 'start of day', '+13 hours'), datetime('now', '+6 days', 'start of day', '+15 hours'), 40.6823, -73.9654, 'upcoming'),
-('Victor Green', datetime('now', '+6 days', 'start of day', '+15 hours'), datetime('now', '+6 days', 'start of day', '+17 hours'), 40.7720, -73.9570, 'upcoming'),
-('Lillian Adams', datetime('now', '+6 days', 'start of day', '+17 hours'), datetime('now', '+6 days', 'start of day', '+19 hours'), 40.7377, -74.0059, 'upcoming');
+INSERT INTO schedules (client_id, shift_start, shift_end, status) VALUES
+((SELECT id FROM clients WHERE name = 'Florence Lopez'), datetime('now', '+6 days', 'start of day', '+8 hours'), datetime('now', '+6 days', 'start of day', '+10 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Albert Hill'), datetime('now', '+6 days', 'start of day', '+10 hours'), datetime('now', '+6 days', 'start of day', '+12 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Gladys Scott'), datetime('now', '+6 days', 'start of day', '+13 hours'), datetime('now', '+6 days', 'start of day', '+15 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Victor Green'), datetime('now', '+6 days', 'start of day', '+15 hours'), datetime('now', '+6 days', 'start of day', '+17 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Lillian Adams'), datetime('now', '+6 days', 'start of day', '+17 hours'), datetime('now', '+6 days', 'start of day', '+19 hours'), 'upcoming');
 
 -- Day 7
-INSERT INTO schedules (client_name, shift_start, shift_end, latitude, longitude, status) VALUES
-('Ralph Baker', datetime('now', '+7 days', 'start of day', '+7 hours'), datetime('now', '+7 days', 'start of day', '+9 hours'), 40.7255, -74.0134, 'upcoming'),
-('Rose Gonzalez', datetime('now', '+7 days', 'start of day', '+9 hours'), datetime('now', '+7 days', 'start of day', '+11 hours'), 40.7505, -73.9934, 'upcoming'),
-('Carl Nelson', datetime('now', '+7 days', 'start of day', '+12 hours'), datetime('now', '+7 days', 'start of day', '+14 hours'), 40.6734, -73.9389, 'upcoming'),
-('Irene Carter', datetime('now', '+7 days', 'start of day', '+14 hours'), datetime('now', '+7 days', 'start of day', '+16 hours'), 40.7831, -73.9665, 'upcoming'),
-('Eugene Mitchell', datetime('now', '+7 days', 'start of day', '+16 hours'), datetime('now', '+7 days', 'start of day', '+18 hours'), 40.7282, -73.9776, 'upcoming');
+INSERT INTO schedules (client_id, shift_start, shift_end, status) VALUES
+((SELECT id FROM clients WHERE name = 'Ralph Baker'), datetime('now', '+7 days', 'start of day', '+7 hours'), datetime('now', '+7 days', 'start of day', '+9 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Rose Gonzalez'), datetime('now', '+7 days', 'start of day', '+9 hours'), datetime('now', '+7 days', 'start of day', '+11 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Carl Nelson'), datetime('now', '+7 days', 'start of day', '+12 hours'), datetime('now', '+7 days', 'start of day', '+14 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Irene Carter'), datetime('now', '+7 days', 'start of day', '+14 hours'), datetime('now', '+7 days', 'start of day', '+16 hours'), 'upcoming'),
+((SELECT id FROM clients WHERE name = 'Eugene Mitchell'), datetime('now', '+7 days', 'start of day', '+16 hours'), datetime('now', '+7 days', 'start of day', '+18 hours'), 'upcoming');
+
+-- Assign caregivers: alternate shifts within a day go to Michael, the rest alternate daily between Sarah and Linda
+UPDATE schedules SET caregiver_id = (
+    SELECT c.id FROM caregivers c WHERE c.email = CASE
+        WHEN (SELECT COUNT(*) FROM schedules s2
+              WHERE DATE(s2.shift_start) = DATE(schedules.shift_start)
+                AND s2.shift_start < schedules.shift_start) % 2 = 1 THEN 'michael.brown@example.com'
+        WHEN CAST(julianday(DATE(schedules.shift_start)) AS INTEGER) % 2 = 0 THEN 'sarah.johnson@example.com'
+        ELSE 'linda.martinez@example.com'
+    END
+);
 
 -- Insert visits for each schedule
 INSERT INTO visits (schedule_id)
 SELECT id FROM schedules ORDER BY id;
 
--- Insert tasks for each schedule (5 tasks per schedule)
-INSERT INTO tasks (schedule_id, description, status) 
-SELECT s.id, task_desc, 'pending'
-FROM schedules s
+-- Insert each client's care plan (5 tasks per visit; housekeeping is optional)
+INSERT INTO care_plan_tasks (client_id, description, frequency, required, active, created_at, updated_at)
+SELECT c.id, task_desc, 'every_visit', required, 1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+FROM clients c
 CROSS JOIN (
-    SELECT 'Assist with morning medication' as task_desc
-    UNION SELECT 'Help with personal hygiene'
-    UNION SELECT 'Prepare nutritious meal'
-    UNION SELECT 'Check vital signs'
-    UNION SELECT 'Light housekeeping'
-) tasks;
+    SELECT 'Assist with morning medication' as task_desc, 1 as required, 1 as position
+    UNION SELECT 'Help with personal hygiene', 1, 2
+    UNION SELECT 'Prepare nutritious meal', 1, 3
+    UNION SELECT 'Check vital signs', 1, 4
+    UNION SELECT 'Light housekeeping', 0, 5
+) tasks
+ORDER BY c.id, position;
+
+-- Insert tasks for each schedule from its client's care plan
+INSERT INTO tasks (schedule_id, description, status, required, care_plan_task_id)
+SELECT s.id, p.description, 'pending', p.required, p.id
+FROM schedules s
+JOIN care_plan_tasks p ON p.client_id = s.client_id
+ORDER BY s.id, p.id;
 
 -- Insert activities for each schedule (7 activities per schedule)
 INSERT INTO activities (schedule_id, title, description, is_resolved, reason)
@@ -102,4 +203,27 @@ CROSS JOIN (
     UNION SELECT 'Mobility Support', 'Assisted with walking and movement around the home'
     UNION SELECT 'Health Monitoring', 'Checked blood pressure, pulse, and general wellness'
     UNION SELECT 'Social Engagement', 'Engaged in conversation and recreational activities'
-) activities; 
\ No newline at end of file
+) activities; 
+
+-- Insert standing weekly visits as recurring templates, picking up after the hand-written week above
+INSERT INTO schedule_templates (client_id, caregiver_id, rrule, start_time, end_time, starts_on, active) VALUES
+((SELECT id FROM clients WHERE name = 'Margaret Thompson'), (SELECT id FROM caregivers WHERE email = 'sarah.johnson@example.com'), 'FREQ=WEEKLY;BYDAY=MO,WE,FR', '09:00', '11:00', date('now', '+8 days'), 1),
+((SELECT id FROM clients WHERE name = 'Robert Chen'), (SELECT id FROM caregivers WHERE email = 'linda.martinez@example.com'), 'FREQ=WEEKLY;BYDAY=TU,TH', '14:00', '16:00', date('now', '+8 days'), 1),
+((SELECT id FROM clients WHERE name = 'Eleanor Rodriguez'), (SELECT id FROM caregivers WHERE email = 'michael.brown@example.com'), 'FREQ=WEEKLY;BYDAY=SA', '10:00', '12:00', date('now', '+8 days'), 1);
+
+-- Insert default tasks for each template
+INSERT INTO schedule_template_tasks (template_id, description, position)
+SELECT t.id, task_desc, position
+FROM schedule_templates t
+CROSS JOIN (
+    SELECT 'Assist with morning medication' as task_desc, 1 as position
+    UNION SELECT 'Help with personal hygiene', 2
+    UNION SELECT 'Prepare nutritious meal', 3
+    UNION SELECT 'Check vital signs', 4
+) tasks;
+
+-- Skip the first Saturday visit for Eleanor Rodriguez
+INSERT INTO schedule_template_exceptions (template_id, exception_date, reason)
+SELECT id, date('now', '+8 days', 'weekday 6'), 'Family visiting'
+FROM schedule_templates
+WHERE client_id = (SELECT id FROM clients WHERE name = 'Eleanor Rodriguez');
\ No newline at end of file
@@ -0,0 +1,214 @@
+package evv
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+)
+
+// Options are the agency settings applied to every exported visit
+type Options struct {
+	ProviderID         string
+	DefaultServiceCode string // used for schedules without a service code
+	IncludeInvalid     bool   // also export the rows of visits that failed validation
+}
+
+// record is a visit with the agency settings applied, ready to be laid out
+type record struct {
+	models.EVVVisit
+	ProviderID string
+}
+
+// fieldValues formats each exportable field of a record for a layout
+var fieldValues = map[string]func(r record, l Layout) string{
+	FieldProviderID:    func(r record, l Layout) string { return r.ProviderID },
+	FieldVisitID:       func(r record, l Layout) string { return strconv.Itoa(r.VisitID) },
+	FieldScheduleID:    func(r record, l Layout) string { return strconv.Itoa(r.ScheduleID) },
+	FieldServiceCode:   func(r record, l Layout) string { return r.ServiceCode },
+	FieldRecipientID:   func(r record, l Layout) string { return r.ClientMedicaidID },
+	FieldRecipientName: func(r record, l Layout) string { return r.ClientName },
+	FieldClientID:      func(r record, l Layout) string { return strconv.Itoa(r.ClientID) },
+	FieldCaregiverID:   func(r record, l Layout) string { return formatID(r.CaregiverID) },
+	FieldCaregiverName: func(r record, l Layout) string { return r.CaregiverName },
+	FieldServiceDate:   func(r record, l Layout) string { return r.formatTime(r.StartTime, l.DateFormat) },
+	FieldStartTime:     func(r record, l Layout) string { return r.formatTime(r.StartTime, l.TimeFormat) },
+	FieldEndTime:       func(r record, l Layout) string { return r.formatTime(r.EndTime, l.TimeFormat) },
+	FieldDurationMinutes: func(r record, l Layout) string {
+		if r.StartTime == nil || r.EndTime == nil {
+			return ""
+		}
+		return strconv.Itoa(int(r.EndTime.Sub(*r.StartTime).Minutes()))
+	},
+	FieldAddress:        func(r record, l Layout) string { return r.Address },
+	FieldStartLatitude:  func(r record, l Layout) string { return formatCoordinate(r.StartLatitude) },
+	FieldStartLongitude: func(r record, l Layout) string { return formatCoordinate(r.StartLongitude) },
+	FieldEndLatitude:    func(r record, l Layout) string { return formatCoordinate(r.EndLatitude) },
+	FieldEndLongitude:   func(r record, l Layout) string { return formatCoordinate(r.EndLongitude) },
+	FieldStartGeofence:  func(r record, l Layout) string { return r.StartGeofence },
+	FieldEndGeofence:    func(r record, l Layout) string { return r.EndGeofence },
+	FieldReasonCode:     func(r record, l Layout) string { return r.AdjustmentReason },
+}
+
+// Build validates the visits and lays them out. Visits that fail validation are listed
+// in the export's errors and, unless opts.IncludeInvalid is set, left out of its records.
+func Build(visits []models.EVVVisit, layout Layout, opts Options, from, to string) models.EVVExport {
+	export := models.EVVExport{
+		Layout:      layout.Name,
+		From:        from,
+		To:          to,
+		GeneratedAt: time.Now().UTC(),
+		Total:       len(visits),
+		Columns:     layout.Headers(),
+		Records:     []map[string]string{},
+		Errors:      []models.EVVVisitErrors{},
+	}
+
+	for _, visit := range visits {
+		r := record{EVVVisit: visit, ProviderID: opts.ProviderID}
+		if r.ServiceCode == "" {
+			r.ServiceCode = opts.DefaultServiceCode
+		}
+
+		problems := validate(r, layout)
+		if len(problems) > 0 {
+			export.Invalid++
+			export.Errors = append(export.Errors, models.EVVVisitErrors{
+				VisitID:    visit.VisitID,
+				ScheduleID: visit.ScheduleID,
+				Errors:     problems,
+			})
+			if !opts.IncludeInvalid {
+				continue
+			}
+		} else {
+			export.Valid++
+		}
+
+		row := make(map[string]string, len(layout.Columns))
+		for _, column := range layout.Columns {
+			row[column.Header] = fieldValues[column.Field](r, layout)
+		}
+		export.Records = append(export.Records, row)
+	}
+
+	return export
+}
+
+// validate lists why a visit cannot be submitted: a missing EVV data element, an
+// unfinished visit, or a location recorded outside the geofence without a reason. A
+// visit whose times were adjusted may lack locations, as its reason code explains them.
+func validate(r record, layout Layout) []string {
+	problems := []string{}
+	add := func(problem string) { problems = append(problems, problem) }
+
+	if layout.uses(FieldProviderID) && r.ProviderID == "" {
+		add("Provider ID is not configured (EVV_PROVIDER_ID)")
+	}
+	if r.ServiceCode == "" {
+		add("Missing service code")
+	}
+	if r.ClientMedicaidID == "" {
+		add("Client has no Medicaid ID")
+	}
+	if r.CaregiverID == nil {
+		add("No caregiver assigned")
+	}
+
+	switch {
+	case r.StartTime == nil:
+		add("Missing clock-in time")
+	case r.EndTime == nil:
+		add("Visit has not been clocked out")
+	case r.EndTime.Before(*r.StartTime):
+		add("Clock-out is before clock-in")
+	}
+
+	adjusted := r.AdjustmentReason != ""
+	if r.StartLatitude == nil || r.StartLongitude == nil {
+		if !adjusted {
+			add("Missing clock-in location")
+		}
+	} else if r.StartGeofence == models.GeofenceOutside {
+		add("Clocked in outside the geofence without an override reason")
+	}
+	if r.EndTime != nil {
+		if r.EndLatitude == nil || r.EndLongitude == nil {
+			if !adjusted {
+				add("Missing clock-out location")
+			}
+		} else if r.EndGeofence == models.GeofenceOutside {
+			add("Clocked out outside the geofence without an override reason")
+		}
+	}
+
+	return problems
+}
+
+// WriteCSV writes an export's records as CSV with a header row
+func WriteCSV(w io.Writer, export models.EVVExport) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(export.Columns); err != nil {
+		return err
+	}
+
+	row := make([]string, len(export.Columns))
+	for _, record := range export.Records {
+		for i, header := range export.Columns {
+			row[i] = record[header]
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatTime formats an optional time in the client's time zone, leaving it blank when
+// missing
+func (r record) formatTime(t *time.Time, layout string) string {
+	if t == nil {
+		return ""
+	}
+	return t.In(utils.Location(r.Timezone)).Format(layout)
+}
+
+// formatCoordinate formats an optional latitude or longitude to six decimal places
+func formatCoordinate(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', 6, 64)
+}
+
+// formatID formats an optional ID, leaving it blank when missing
+func formatID(id *int) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.Itoa(*id)
+}
+
+// ParseDateRange parses the first and last service dates of an export, both YYYY-MM-DD
+// and inclusive, into the [from, to) range of service dates to export. Dates are
+// returned as midnight UTC; each visit's service date is taken in its client's time zone.
+func ParseDateRange(from, to string) (time.Time, time.Time, error) {
+	start, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must be a YYYY-MM-DD date, got %q", from)
+	}
+	end, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("to must be a YYYY-MM-DD date, got %q", to)
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("to must not be before from")
+	}
+	return start, end.AddDate(0, 0, 1), nil
+}
@@ -0,0 +1,341 @@
+package evv
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"visit-tracker-api/models"
+)
+
+var testOptions = Options{ProviderID: "PRV-1", DefaultServiceCode: "T1019"}
+
+// completeVisit is a finished visit with every EVV data element, clocked in and out at
+// the client's home in Chicago on the morning of 2 March 2026
+func completeVisit() models.EVVVisit {
+	caregiverID := 7
+	start := time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+	end := start.Add(2*time.Hour + 30*time.Minute)
+	latitude, longitude := 41.878114, -87.629798
+	return models.EVVVisit{
+		VisitID:          11,
+		ScheduleID:       21,
+		ServiceCode:      "S5125",
+		Status:           models.StatusCompleted,
+		ClientID:         3,
+		ClientName:       "Margaret Thompson",
+		ClientMedicaidID: "MCD-3",
+		Address:          "100 Maple Avenue, Chicago, IL",
+		CaregiverID:      &caregiverID,
+		CaregiverName:    "Sarah Johnson",
+		StartTime:        &start,
+		EndTime:          &end,
+		StartLatitude:    &latitude,
+		StartLongitude:   &longitude,
+		EndLatitude:      &latitude,
+		EndLongitude:     &longitude,
+		StartGeofence:    models.GeofenceInside,
+		EndGeofence:      models.GeofenceInside,
+		Timezone:         "America/Chicago",
+	}
+}
+
+func TestBuildValidatesRequiredElements(t *testing.T) {
+	standard := builtinLayouts[0]
+
+	tests := []struct {
+		name    string
+		opts    Options
+		change  func(v *models.EVVVisit)
+		wantErr string // empty when the visit is valid
+	}{
+		{name: "complete visit", opts: testOptions, change: func(v *models.EVVVisit) {}},
+		{name: "default service code", opts: testOptions, change: func(v *models.EVVVisit) { v.ServiceCode = "" }},
+		{
+			name:    "no provider ID",
+			opts:    Options{DefaultServiceCode: "T1019"},
+			change:  func(v *models.EVVVisit) {},
+			wantErr: "Provider ID is not configured (EVV_PROVIDER_ID)",
+		},
+		{
+			name:    "no service code",
+			opts:    Options{ProviderID: "PRV-1"},
+			change:  func(v *models.EVVVisit) { v.ServiceCode = "" },
+			wantErr: "Missing service code",
+		},
+		{name: "no Medicaid ID", opts: testOptions, change: func(v *models.EVVVisit) { v.ClientMedicaidID = "" }, wantErr: "Client has no Medicaid ID"},
+		{name: "no caregiver", opts: testOptions, change: func(v *models.EVVVisit) { v.CaregiverID = nil }, wantErr: "No caregiver assigned"},
+		{name: "no clock-in", opts: testOptions, change: func(v *models.EVVVisit) { v.StartTime = nil }, wantErr: "Missing clock-in time"},
+		{name: "not clocked out", opts: testOptions, change: func(v *models.EVVVisit) { v.EndTime = nil }, wantErr: "Visit has not been clocked out"},
+		{
+			name:    "clock-out before clock-in",
+			opts:    testOptions,
+			change:  func(v *models.EVVVisit) { before := v.StartTime.Add(-time.Minute); v.EndTime = &before },
+			wantErr: "Clock-out is before clock-in",
+		},
+		{name: "no clock-in location", opts: testOptions, change: func(v *models.EVVVisit) { v.StartLatitude = nil }, wantErr: "Missing clock-in location"},
+		{name: "no clock-out location", opts: testOptions, change: func(v *models.EVVVisit) { v.EndLongitude = nil }, wantErr: "Missing clock-out location"},
+		{
+			name: "adjusted visit without locations",
+			opts: testOptions,
+			change: func(v *models.EVVVisit) {
+				v.StartLatitude, v.EndLatitude, v.AdjustmentReason = nil, nil, models.AdjustmentDeviceIssue
+			},
+		},
+		{
+			name:    "clocked in outside the geofence",
+			opts:    testOptions,
+			change:  func(v *models.EVVVisit) { v.StartGeofence = models.GeofenceOutside },
+			wantErr: "Clocked in outside the geofence without an override reason",
+		},
+		{
+			name:    "clocked out outside the geofence",
+			opts:    testOptions,
+			change:  func(v *models.EVVVisit) { v.EndGeofence = models.GeofenceOutside },
+			wantErr: "Clocked out outside the geofence without an override reason",
+		},
+		{name: "geofence overridden", opts: testOptions, change: func(v *models.EVVVisit) { v.StartGeofence = models.GeofenceOverridden }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			visit := completeVisit()
+			tt.change(&visit)
+
+			export := Build([]models.EVVVisit{visit}, standard, tt.opts, "2026-03-01", "2026-03-07")
+			if tt.wantErr == "" {
+				if export.Valid != 1 || len(export.Errors) != 0 {
+					t.Fatalf("valid = %d, errors = %v; want a valid visit", export.Valid, export.Errors)
+				}
+				return
+			}
+			if export.Invalid != 1 || len(export.Errors) != 1 {
+				t.Fatalf("invalid = %d, errors = %v; want one invalid visit", export.Invalid, export.Errors)
+			}
+			if got := export.Errors[0].Errors; !reflect.DeepEqual(got, []string{tt.wantErr}) {
+				t.Errorf("errors = %q, want [%q]", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildIncludeInvalid(t *testing.T) {
+	valid := completeVisit()
+	invalid := completeVisit()
+	invalid.VisitID, invalid.ScheduleID, invalid.ClientMedicaidID = 12, 22, ""
+	visits := []models.EVVVisit{valid, invalid}
+
+	tests := []struct {
+		includeInvalid bool
+		wantVisitIDs   []string
+	}{
+		{false, []string{"11"}},
+		{true, []string{"11", "12"}},
+	}
+	for _, tt := range tests {
+		opts := testOptions
+		opts.IncludeInvalid = tt.includeInvalid
+
+		export := Build(visits, builtinLayouts[0], opts, "2026-03-01", "2026-03-07")
+		if export.Total != 2 || export.Valid != 1 || export.Invalid != 1 {
+			t.Errorf("IncludeInvalid %v: total/valid/invalid = %d/%d/%d, want 2/1/1",
+				tt.includeInvalid, export.Total, export.Valid, export.Invalid)
+		}
+		if len(export.Errors) != 1 || export.Errors[0].VisitID != 12 || export.Errors[0].ScheduleID != 22 {
+			t.Errorf("IncludeInvalid %v: errors = %+v, want visit 12 of schedule 22", tt.includeInvalid, export.Errors)
+		}
+		var visitIDs []string
+		for _, record := range export.Records {
+			visitIDs = append(visitIDs, record["visit_id"])
+		}
+		if !reflect.DeepEqual(visitIDs, tt.wantVisitIDs) {
+			t.Errorf("IncludeInvalid %v: exported visits %v, want %v", tt.includeInvalid, visitIDs, tt.wantVisitIDs)
+		}
+	}
+}
+
+func TestWriteCSVColumnOrder(t *testing.T) {
+	tests := []struct {
+		layout  string
+		wantCSV [][]string
+	}{
+		{
+			layout: "standard",
+			wantCSV: [][]string{
+				{"provider_id", "visit_id", "schedule_id", "service_code", "recipient_id", "recipient_name", "caregiver_id",
+					"caregiver_name", "service_date", "start_time", "end_time", "address", "start_latitude", "start_longitude",
+					"end_latitude", "end_longitude", "reason_code"},
+				{"PRV-1", "11", "21", "S5125", "MCD-3", "Margaret Thompson", "7",
+					"Sarah Johnson", "2026-03-02", "2026-03-02T09:00:00-06:00", "2026-03-02T11:30:00-06:00", "100 Maple Avenue, Chicago, IL",
+					"41.878114", "-87.629798", "41.878114", "-87.629798", ""},
+			},
+		},
+		{
+			layout: "sandata",
+			wantCSV: [][]string{
+				{"ProviderID", "VisitOtherID", "EmployeeIdentifier", "EmployeeName", "PatientMedicaidID", "PatientName",
+					"ProcedureCode", "VisitDate", "CallInDateTime", "CallOutDateTime", "CallInLatitude", "CallInLongitude",
+					"CallOutLatitude", "CallOutLongitude", "ServiceAddress", "ReasonCode"},
+				{"PRV-1", "21", "7", "Sarah Johnson", "MCD-3", "Margaret Thompson",
+					"S5125", "2026-03-02", "2026-03-02T09:00:00-06:00", "2026-03-02T11:30:00-06:00", "41.878114", "-87.629798",
+					"41.878114", "-87.629798", "100 Maple Avenue, Chicago, IL", ""},
+			},
+		},
+		{
+			layout: "hhaexchange",
+			wantCSV: [][]string{
+				{"AgencyID", "VisitID", "MemberID", "MemberName", "CaregiverCode", "CaregiverName", "ServiceCode",
+					"VisitDate", "VisitStartTime", "VisitEndTime", "ClockInLatitude", "ClockInLongitude", "ClockOutLatitude",
+					"ClockOutLongitude", "ServiceAddress", "EditReasonCode"},
+				{"PRV-1", "11", "MCD-3", "Margaret Thompson", "7", "Sarah Johnson", "S5125",
+					"03/02/2026", "03/02/2026 09:00", "03/02/2026 11:30", "41.878114", "-87.629798", "41.878114",
+					"-87.629798", "100 Maple Avenue, Chicago, IL", ""},
+			},
+		},
+	}
+
+	layouts, err := LoadLayouts("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			export := Build([]models.EVVVisit{completeVisit()}, layouts[tt.layout], testOptions, "2026-03-01", "2026-03-07")
+
+			var out strings.Builder
+			if err := WriteCSV(&out, export); err != nil {
+				t.Fatal(err)
+			}
+			got, err := csv.NewReader(strings.NewReader(out.String())).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.wantCSV) {
+				t.Errorf("CSV =\n%q\nwant\n%q", got, tt.wantCSV)
+			}
+		})
+	}
+}
+
+func TestParseDateRange(t *testing.T) {
+	tests := []struct {
+		from, to string
+		wantFrom string
+		wantTo   string // exclusive
+		wantErr  bool
+	}{
+		{from: "2026-03-01", to: "2026-03-07", wantFrom: "2026-03-01", wantTo: "2026-03-08"},
+		{from: "2026-03-01", to: "2026-03-01", wantFrom: "2026-03-01", wantTo: "2026-03-02"},
+		{from: "2026-02-28", to: "2026-02-28", wantFrom: "2026-02-28", wantTo: "2026-03-01"},
+		{from: "2026-03-07", to: "2026-03-01", wantErr: true},
+		{from: "03/01/2026", to: "2026-03-07", wantErr: true},
+		{from: "2026-03-01", to: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		from, to, err := ParseDateRange(tt.from, tt.to)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDateRange(%q, %q) error = %v, want error %v", tt.from, tt.to, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if got := from.Format(time.RFC3339); got != tt.wantFrom+"T00:00:00Z" {
+			t.Errorf("ParseDateRange(%q, %q) from = %s, want %s midnight UTC", tt.from, tt.to, got, tt.wantFrom)
+		}
+		if got := to.Format(time.RFC3339); got != tt.wantTo+"T00:00:00Z" {
+			t.Errorf("ParseDateRange(%q, %q) to = %s, want %s midnight UTC", tt.from, tt.to, got, tt.wantTo)
+		}
+	}
+}
+
+func TestLoadLayouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		wantErr string
+		check   func(t *testing.T, layouts map[string]Layout)
+	}{
+		{
+			name: "redefined built-in layout",
+			file: `[{"name": "sandata", "time_format": "15:04", "columns": [
+				{"header": "Member", "field": "recipient_id"},
+				{"header": "In", "field": "start_time"}]}]`,
+			check: func(t *testing.T, layouts map[string]Layout) {
+				sandata := layouts["sandata"]
+				if !reflect.DeepEqual(sandata.Headers(), []string{"Member", "In"}) {
+					t.Errorf("sandata headers = %v, want [Member In]", sandata.Headers())
+				}
+				if sandata.DateFormat != "2006-01-02" || sandata.TimeFormat != "15:04" {
+					t.Errorf("sandata formats = %q, %q; want the default date format and 15:04", sandata.DateFormat, sandata.TimeFormat)
+				}
+				if len(layouts["standard"].Columns) != 17 || len(layouts["hhaexchange"].Columns) != 16 {
+					t.Error("the other built-in layouts were changed")
+				}
+			},
+		},
+		{
+			name: "layout that omits columns",
+			file: `[{"name": "state", "columns": [{"header": "Recipient", "field": "recipient_id"}]}]`,
+			check: func(t *testing.T, layouts map[string]Layout) {
+				if got := LayoutNames(layouts); !reflect.DeepEqual(got, []string{"hhaexchange", "sandata", "standard", "state"}) {
+					t.Errorf("layouts = %v", got)
+				}
+				// A provider ID that is not exported is not required
+				export := Build([]models.EVVVisit{completeVisit()}, layouts["state"], Options{DefaultServiceCode: "T1019"}, "", "")
+				if export.Valid != 1 {
+					t.Errorf("errors = %v, want a valid visit", export.Errors)
+				}
+				if !reflect.DeepEqual(export.Records, []map[string]string{{"Recipient": "MCD-3"}}) {
+					t.Errorf("records = %v", export.Records)
+				}
+			},
+		},
+		{
+			name:    "unknown field",
+			file:    `[{"name": "state", "columns": [{"header": "Mood", "field": "mood"}]}]`,
+			wantErr: `layout "state" column "Mood" exports unknown field "mood"`,
+		},
+		{
+			name:    "column without a header",
+			file:    `[{"name": "state", "columns": [{"field": "visit_id"}]}]`,
+			wantErr: `layout "state" has a column without a header`,
+		},
+		{
+			name:    "no columns",
+			file:    `[{"name": "state"}]`,
+			wantErr: `layout "state" has no columns`,
+		},
+		{
+			name:    "unnamed layout",
+			file:    `[{"columns": [{"header": "Visit", "field": "visit_id"}]}]`,
+			wantErr: "layout name is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "layouts.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			layouts, err := LoadLayouts(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadLayouts() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, layouts)
+		})
+	}
+}
@@ -0,0 +1,212 @@
+// Package evv builds Electronic Visit Verification exports for state aggregators. Every
+// visit carries the six data elements the 21st Century Cures Act requires: the type of
+// service, the recipient, the date, the location, the caregiver and the start and end
+// times. Visits are validated and written in the columns an aggregator's layout expects.
+package evv
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Fields a layout column can export
+const (
+	FieldProviderID      = "provider_id"
+	FieldVisitID         = "visit_id"
+	FieldScheduleID      = "schedule_id"
+	FieldServiceCode     = "service_code"
+	FieldRecipientID     = "recipient_id" // the client's Medicaid ID
+	FieldRecipientName   = "recipient_name"
+	FieldClientID        = "client_id"
+	FieldCaregiverID     = "caregiver_id"
+	FieldCaregiverName   = "caregiver_name"
+	FieldServiceDate     = "service_date"
+	FieldStartTime       = "start_time"
+	FieldEndTime         = "end_time"
+	FieldDurationMinutes = "duration_minutes"
+	FieldAddress         = "address"
+	FieldStartLatitude   = "start_latitude"
+	FieldStartLongitude  = "start_longitude"
+	FieldEndLatitude     = "end_latitude"
+	FieldEndLongitude    = "end_longitude"
+	FieldStartGeofence   = "start_geofence_status"
+	FieldEndGeofence     = "end_geofence_status"
+	FieldReasonCode      = "reason_code" // why the visit's times were adjusted, if they were
+)
+
+// Layout is the set of columns, and the date and time formats, an aggregator accepts
+type Layout struct {
+	Name       string   `json:"name"`
+	DateFormat string   `json:"date_format"` // Go reference layout, e.g. 01/02/2006
+	TimeFormat string   `json:"time_format"` // Go reference layout, e.g. 2006-01-02 15:04
+	Columns    []Column `json:"columns"`
+}
+
+// Column is one exported column: its header and the field it holds
+type Column struct {
+	Header string `json:"header"`
+	Field  string `json:"field"`
+}
+
+// Headers returns the layout's column headers in order
+func (l Layout) Headers() []string {
+	headers := make([]string, len(l.Columns))
+	for i, column := range l.Columns {
+		headers[i] = column.Header
+	}
+	return headers
+}
+
+// uses reports whether any column exports the field
+func (l Layout) uses(field string) bool {
+	for _, column := range l.Columns {
+		if column.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// builtinLayouts are always available. The Sandata and HHAeXchange layouts follow the
+// column naming of those aggregators' import files; states configure them differently,
+// so agencies can define their own in EVV_LAYOUTS_FILE.
+var builtinLayouts = []Layout{
+	{
+		Name:       "standard",
+		DateFormat: "2006-01-02",
+		TimeFormat: time.RFC3339,
+		Columns: []Column{
+			{"provider_id", FieldProviderID},
+			{"visit_id", FieldVisitID},
+			{"schedule_id", FieldScheduleID},
+			{"service_code", FieldServiceCode},
+			{"recipient_id", FieldRecipientID},
+			{"recipient_name", FieldRecipientName},
+			{"caregiver_id", FieldCaregiverID},
+			{"caregiver_name", FieldCaregiverName},
+			{"service_date", FieldServiceDate},
+			{"start_time", FieldStartTime},
+			{"end_time", FieldEndTime},
+			{"address", FieldAddress},
+			{"start_latitude", FieldStartLatitude},
+			{"start_longitude", FieldStartLongitude},
+			{"end_latitude", FieldEndLatitude},
+			{"end_longitude", FieldEndLongitude},
+			{"reason_code", FieldReasonCode},
+		},
+	},
+	{
+		Name:       "sandata",
+		DateFormat: "2006-01-02",
+		TimeFormat: "2006-01-02T15:04:05Z07:00",
+		Columns: []Column{
+			{"ProviderID", FieldProviderID},
+			{"VisitOtherID", FieldScheduleID},
+			{"EmployeeIdentifier", FieldCaregiverID},
+			{"EmployeeName", FieldCaregiverName},
+			{"PatientMedicaidID", FieldRecipientID},
+			{"PatientName", FieldRecipientName},
+			{"ProcedureCode", FieldServiceCode},
+			{"VisitDate", FieldServiceDate},
+			{"CallInDateTime", FieldStartTime},
+			{"CallOutDateTime", FieldEndTime},
+			{"CallInLatitude", FieldStartLatitude},
+			{"CallInLongitude", FieldStartLongitude},
+			{"CallOutLatitude", FieldEndLatitude},
+			{"CallOutLongitude", FieldEndLongitude},
+			{"ServiceAddress", FieldAddress},
+			{"ReasonCode", FieldReasonCode},
+		},
+	},
+	{
+		Name:       "hhaexchange",
+		DateFormat: "01/02/2006",
+		TimeFormat: "01/02/2006 15:04",
+		Columns: []Column{
+			{"AgencyID", FieldProviderID},
+			{"VisitID", FieldVisitID},
+			{"MemberID", FieldRecipientID},
+			{"MemberName", FieldRecipientName},
+			{"CaregiverCode", FieldCaregiverID},
+			{"CaregiverName", FieldCaregiverName},
+			{"ServiceCode", FieldServiceCode},
+			{"VisitDate", FieldServiceDate},
+			{"VisitStartTime", FieldStartTime},
+			{"VisitEndTime", FieldEndTime},
+			{"ClockInLatitude", FieldStartLatitude},
+			{"ClockInLongitude", FieldStartLongitude},
+			{"ClockOutLatitude", FieldEndLatitude},
+			{"ClockOutLongitude", FieldEndLongitude},
+			{"ServiceAddress", FieldAddress},
+			{"EditReasonCode", FieldReasonCode},
+		},
+	},
+}
+
+// LoadLayouts returns the built-in layouts together with those defined in file, a JSON
+// array of layouts. An empty file name loads only the built-in ones. A layout in the file
+// replaces a built-in layout of the same name.
+func LoadLayouts(file string) (map[string]Layout, error) {
+	layouts := make(map[string]Layout, len(builtinLayouts))
+	for _, layout := range builtinLayouts {
+		layouts[layout.Name] = layout
+	}
+	if file == "" {
+		return layouts, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var custom []Layout
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", file, err)
+	}
+	for _, layout := range custom {
+		if layout.DateFormat == "" {
+			layout.DateFormat = "2006-01-02"
+		}
+		if layout.TimeFormat == "" {
+			layout.TimeFormat = time.RFC3339
+		}
+		if err := validateLayout(layout); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		layouts[layout.Name] = layout
+	}
+	return layouts, nil
+}
+
+// LayoutNames returns the names of the layouts, sorted
+func LayoutNames(layouts map[string]Layout) []string {
+	names := make([]string, 0, len(layouts))
+	for name := range layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateLayout checks that a layout is named and only exports known fields
+func validateLayout(layout Layout) error {
+	if strings.TrimSpace(layout.Name) == "" {
+		return fmt.Errorf("layout name is required")
+	}
+	if len(layout.Columns) == 0 {
+		return fmt.Errorf("layout %q has no columns", layout.Name)
+	}
+	for _, column := range layout.Columns {
+		if column.Header == "" {
+			return fmt.Errorf("layout %q has a column without a header", layout.Name)
+		}
+		if _, ok := fieldValues[column.Field]; !ok {
+			return fmt.Errorf("layout %q column %q exports unknown field %q", layout.Name, column.Header, column.Field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"visit-tracker-api/config"
+	"visit-tracker-api/database"
+	"visit-tracker-api/evv"
+	"visit-tracker-api/store"
+)
+
+const evvExportUsage = `Usage: %s [flags] evv-export -from YYYY-MM-DD -to YYYY-MM-DD [options]
+
+Writes the visits clocked in between the two dates, inclusive, in an EVV aggregator's
+layout. Validation errors are reported on stderr, and the exit status is 3 when any
+visit failed validation.
+
+Options:
+`
+
+// loadEVVLayouts loads the built-in and configured aggregator layouts and checks that
+// EVV_LAYOUT names one of them
+func loadEVVLayouts(cfg config.EVVConfig) (map[string]evv.Layout, error) {
+	layouts, err := evv.LoadLayouts(cfg.LayoutsFile)
+	if err != nil {
+		return nil, fmt.Errorf("EVV_LAYOUTS_FILE: %w", err)
+	}
+	if _, ok := layouts[cfg.Layout]; !ok {
+		return nil, fmt.Errorf("EVV_LAYOUT %q is not one of %s", cfg.Layout, strings.Join(evv.LayoutNames(layouts), ", "))
+	}
+	return layouts, nil
+}
+
+// runEVVExport handles the evv-export subcommand and returns the process exit code
+func runEVVExport(cfg config.Config, args []string) int {
+	flags := flag.NewFlagSet("evv-export", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), evvExportUsage, os.Args[0])
+		flags.PrintDefaults()
+	}
+	from := flags.String("from", "", "first service date, YYYY-MM-DD")
+	to := flags.String("to", "", "last service date, YYYY-MM-DD (default: same as -from)")
+	format := flags.String("format", "csv", "csv or json")
+	layoutName := flags.String("layout", cfg.EVV.Layout, "aggregator layout")
+	includeInvalid := flags.Bool("include-invalid", false, "also export the rows of visits that failed validation")
+	output := flags.String("o", "", "file to write to (default: stdout)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *to == "" {
+		*to = *from
+	}
+
+	start, end, err := evv.ParseDateRange(*from, *to)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "evv-export:", err)
+		return 2
+	}
+	if *format != "csv" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "evv-export: format must be csv or json, got %q\n", *format)
+		return 2
+	}
+	layouts, err := loadEVVLayouts(cfg.EVV)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "evv-export:", err)
+		return 2
+	}
+	layout, ok := layouts[*layoutName]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "evv-export: unknown layout %q, expected one of %s\n",
+			*layoutName, strings.Join(evv.LayoutNames(layouts), ", "))
+		return 2
+	}
+
+	database.Open(cfg.Database)
+	defer database.Close()
+
+	statuses, err := database.MigrationStatuses(database.DB)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "evv-export:", err)
+		return 1
+	}
+	for _, status := range statuses {
+		if !status.Applied {
+			fmt.Fprintln(os.Stderr, "evv-export: the database has pending migrations; run migrate up first")
+			return 1
+		}
+	}
+
+	visits, err := store.NewSQLStore(database.DB).ListEVVVisits(start, end)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "evv-export:", err)
+		return 1
+	}
+	export := evv.Build(visits, layout, evv.Options{
+		ProviderID:         cfg.EVV.ProviderID,
+		DefaultServiceCode: cfg.EVV.DefaultServiceCode,
+		IncludeInvalid:     *includeInvalid,
+	}, *from, *to)
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "evv-export:", err)
+			return 1
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(export)
+	} else {
+		err = evv.WriteCSV(w, export)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "evv-export:", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d of %d visits in the %s layout\n", len(export.Records), export.Total, layout.Name)
+	for _, visit := range export.Errors {
+		fmt.Fprintf(os.Stderr, "  visit %d (schedule %d): %s\n", visit.VisitID, visit.ScheduleID, strings.Join(visit.Errors, "; "))
+	}
+	if export.Invalid > 0 {
+		return 3
+	}
+	return 0
+}
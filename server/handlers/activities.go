@@ -5,27 +5,38 @@ import (
 	"log"
 	"net/http"
 	"strconv"
-	"time"
 
-	"visit-tracker-api/database"
 	"visit-tracker-api/models"
+	"visit-tracker-api/store"
 
 	"github.com/gin-gonic/gin"
 )
 
+// ActivityHandler serves the activity endpoints
+type ActivityHandler struct {
+	Schedules  store.ScheduleStore
+	Activities store.ActivityStore
+}
+
+// NewActivityHandler returns an activity handler backed by the given stores
+func NewActivityHandler(schedules store.ScheduleStore, activities store.ActivityStore) *ActivityHandler {
+	return &ActivityHandler{Schedules: schedules, Activities: activities}
+}
+
 // GetActivityByID godoc
 // @Summary Get activity by ID
 // @Description Get a specific activity by its ID
 // @Tags activities
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Activity ID"
 // @Success 200 {object} models.Activity
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /activities/{id} [get]
-func GetActivityByID(c *gin.Context) {
+func (h *ActivityHandler) GetActivityByID(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
@@ -33,19 +44,7 @@ func GetActivityByID(c *gin.Context) {
 		return
 	}
 
-	var activity models.Activity
-	var createdAt, updatedAt string
-	var reason sql.NullString
-
-	query := `
-		SELECT id, schedule_id, title, description, is_resolved, reason, created_at, updated_at
-		FROM activities
-		WHERE id = ?`
-
-	err = database.DB.QueryRow(query, id).Scan(
-		&activity.ID, &activity.ScheduleID, &activity.Title, &activity.Description,
-		&activity.IsResolved, &reason, &createdAt, &updatedAt,
-	)
+	activity, err := h.Activities.GetActivity(id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Activity not found"})
@@ -56,15 +55,10 @@ func GetActivityByID(c *gin.Context) {
 		return
 	}
 
-	// Handle nullable reason field
-	if reason.Valid {
-		activity.Reason = reason.String
+	if !authorizeScheduleAccess(c, h.Schedules, activity.ScheduleID) {
+		return
 	}
 
-	// Parse time strings using the same function from schedules.go
-	activity.CreatedAt = parseTime(createdAt)
-	activity.UpdatedAt = parseTime(updatedAt)
-
 	c.JSON(http.StatusOK, activity)
 }
 
@@ -74,12 +68,13 @@ func GetActivityByID(c *gin.Context) {
 // @Tags activities
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Schedule ID"
 // @Success 200 {array} models.Activity
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /schedules/{id}/activities [get]
-func GetActivitiesBySchedule(c *gin.Context) {
+func (h *ActivityHandler) GetActivitiesBySchedule(c *gin.Context) {
 	scheduleIDParam := c.Param("id")
 	scheduleID, err := strconv.Atoi(scheduleIDParam)
 	if err != nil {
@@ -87,47 +82,16 @@ func GetActivitiesBySchedule(c *gin.Context) {
 		return
 	}
 
-	query := `
-		SELECT id, schedule_id, title, description, is_resolved, reason, created_at, updated_at
-		FROM activities
-		WHERE schedule_id = ?
-		ORDER BY created_at ASC`
+	if !authorizeScheduleAccess(c, h.Schedules, scheduleID) {
+		return
+	}
 
-	rows, err := database.DB.Query(query, scheduleID)
+	activities, err := h.Activities.ListActivities(scheduleID)
 	if err != nil {
 		log.Printf("Database query error in GetActivitiesBySchedule: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch activities"})
 		return
 	}
-	defer rows.Close()
-
-	var activities []models.Activity
-	for rows.Next() {
-		var activity models.Activity
-		var createdAt, updatedAt string
-		var reason sql.NullString
-
-		err := rows.Scan(
-			&activity.ID, &activity.ScheduleID, &activity.Title, &activity.Description,
-			&activity.IsResolved, &reason, &createdAt, &updatedAt,
-		)
-		if err != nil {
-			log.Printf("Error scanning activity data: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse activity data"})
-			return
-		}
-
-		// Handle nullable reason field
-		if reason.Valid {
-			activity.Reason = reason.String
-		}
-
-		// Parse time strings
-		activity.CreatedAt = parseTime(createdAt)
-		activity.UpdatedAt = parseTime(updatedAt)
-
-		activities = append(activities, activity)
-	}
 
 	c.JSON(http.StatusOK, activities)
 }
@@ -138,13 +102,15 @@ func GetActivitiesBySchedule(c *gin.Context) {
 // @Tags activities
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Schedule ID"
 // @Param activity body models.CreateActivityRequest true "Activity data"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
 // @Success 201 {object} models.Activity
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /schedules/{id}/activities [post]
-func CreateActivity(c *gin.Context) {
+func (h *ActivityHandler) CreateActivity(c *gin.Context) {
 	scheduleIDParam := c.Param("id")
 	scheduleID, err := strconv.Atoi(scheduleIDParam)
 	if err != nil {
@@ -152,6 +118,10 @@ func CreateActivity(c *gin.Context) {
 		return
 	}
 
+	if !authorizeScheduleAccess(c, h.Schedules, scheduleID) {
+		return
+	}
+
 	var req models.CreateActivityRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -159,48 +129,23 @@ func CreateActivity(c *gin.Context) {
 	}
 
 	// Verify that the schedule exists
-	var exists int
-	err = database.DB.QueryRow("SELECT COUNT(*) FROM schedules WHERE id = ?", scheduleID).Scan(&exists)
-	if err != nil {
+	if _, err := h.Schedules.GetSchedule(scheduleID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Schedule not found"})
+			return
+		}
 		log.Printf("Database error checking schedule existence: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
-	if exists == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Schedule not found"})
-		return
-	}
 
-	now := time.Now().Format("2006-01-02 15:04:05")
-	result, err := database.DB.Exec(`
-		INSERT INTO activities (schedule_id, title, description, is_resolved, created_at, updated_at)
-		VALUES (?, ?, ?, 0, ?, ?)`,
-		scheduleID, req.Title, req.Description, now, now)
+	activity, err := h.Activities.CreateActivity(scheduleID, req, actorFromContext(c))
 	if err != nil {
 		log.Printf("Database error creating activity: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create activity"})
 		return
 	}
 
-	activityID, err := result.LastInsertId()
-	if err != nil {
-		log.Printf("Error getting last insert ID: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get activity ID"})
-		return
-	}
-
-	// Return the created activity
-	activity := models.Activity{
-		ID:          int(activityID),
-		ScheduleID:  scheduleID,
-		Title:       req.Title,
-		Description: req.Description,
-		IsResolved:  false,
-		Reason:      "",
-		CreatedAt:   parseTime(now),
-		UpdatedAt:   parseTime(now),
-	}
-
 	c.JSON(http.StatusCreated, activity)
 }
 
@@ -210,14 +155,16 @@ func CreateActivity(c *gin.Context) {
 // @Tags activities
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Activity ID"
 // @Param activity body models.UpdateActivityRequest true "Activity update data"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
 // @Success 200 {object} models.Activity
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /activities/{id} [put]
-func UpdateActivity(c *gin.Context) {
+func (h *ActivityHandler) UpdateActivity(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
@@ -238,58 +185,27 @@ func UpdateActivity(c *gin.Context) {
 	}
 
 	// Check if activity exists
-	var exists int
-	err = database.DB.QueryRow("SELECT COUNT(*) FROM activities WHERE id = ?", id).Scan(&exists)
+	existing, err := h.Activities.GetActivity(id)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Activity not found"})
+			return
+		}
 		log.Printf("Database error checking activity existence: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
-	if exists == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Activity not found"})
+
+	if !authorizeScheduleAccess(c, h.Schedules, existing.ScheduleID) {
 		return
 	}
 
-	now := time.Now().Format("2006-01-02 15:04:05")
-	_, err = database.DB.Exec(`
-		UPDATE activities 
-		SET is_resolved = ?, reason = ?, updated_at = ?
-		WHERE id = ?`,
-		req.IsResolved, req.Reason, now, id)
+	activity, err := h.Activities.UpdateActivity(id, req, actorFromContext(c))
 	if err != nil {
 		log.Printf("Database error updating activity: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update activity"})
 		return
 	}
 
-	// Fetch and return the updated activity
-	var activity models.Activity
-	var createdAt, updatedAt string
-	var reason sql.NullString
-
-	query := `
-		SELECT id, schedule_id, title, description, is_resolved, reason, created_at, updated_at
-		FROM activities
-		WHERE id = ?`
-
-	err = database.DB.QueryRow(query, id).Scan(
-		&activity.ID, &activity.ScheduleID, &activity.Title, &activity.Description,
-		&activity.IsResolved, &reason, &createdAt, &updatedAt,
-	)
-	if err != nil {
-		log.Printf("Database error fetching updated activity: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated activity"})
-		return
-	}
-
-	// Handle nullable reason field
-	if reason.Valid {
-		activity.Reason = reason.String
-	}
-
-	// Parse time strings
-	activity.CreatedAt = parseTime(createdAt)
-	activity.UpdatedAt = parseTime(updatedAt)
-
 	c.JSON(http.StatusOK, activity)
 } 
\ No newline at end of file
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+	"visit-tracker-api/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// GetAdjustmentReasons godoc
+// @Summary List visit adjustment reason codes
+// @Description Get the reason codes a coordinator can give when correcting a visit's clock-in or clock-out
+// @Tags visits
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.AdjustmentReason
+// @Failure 401 {object} middleware.ErrorResponse
+// @Failure 403 {object} middleware.ErrorResponse
+// @Router /visit-adjustment-reasons [get]
+func GetAdjustmentReasons(c *gin.Context) {
+	utils.JSONSuccess(c, models.AdjustmentReasons)
+}
+
+// AdjustVisit godoc
+// @Summary Adjust a visit's times
+// @Description Correct the clock-in and/or clock-out time of a visit in progress or completed, giving a reason code. The replaced times are kept in the schedule's adjustment trail. Entering the clock-out of a visit still in progress completes it under the task completion policy: pending tasks refuse the adjustment with TASKS_PENDING (422) under reject and are marked not_completed under auto_close.
+// @Tags visits
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Param adjustment body models.VisitAdjustmentRequest true "Corrected times and reason"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 201 {object} models.VisitAdjustment
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 401 {object} middleware.ErrorResponse
+// @Failure 403 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 422 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /schedules/{id}/adjustments [post]
+func (h *VisitHandler) AdjustVisit(c *gin.Context) {
+	scheduleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "schedule_id")
+		return
+	}
+
+	var req models.VisitAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err, "request_body")
+		return
+	}
+
+	if !models.IsAdjustmentReason(req.ReasonCode) {
+		utils.HandleValidationError(c,
+			&ValidationError{Field: "reason_code", Message: "Unknown reason code; see GET /visit-adjustment-reasons"},
+			"reason_code")
+		return
+	}
+	if req.ReasonCode == models.AdjustmentOther && req.Note == "" {
+		utils.HandleValidationError(c,
+			&ValidationError{Field: "note", Message: "A note is required for reason code other"},
+			"note")
+		return
+	}
+	if req.StartTime == nil && req.EndTime == nil {
+		utils.HandleValidationError(c,
+			&ValidationError{Field: "start_time", Message: "start_time or end_time is required"},
+			"start_time")
+		return
+	}
+
+	schedule, err := h.Schedules.GetSchedule(scheduleID)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "get_schedule_status")
+		return
+	}
+	if schedule.Status != models.StatusInProgress && schedule.Status != models.StatusCompleted {
+		handleAdjustmentError(c, store.ErrVisitNotAdjustable)
+		return
+	}
+
+	visit, err := h.Visits.GetVisit(scheduleID)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "get_visit")
+		return
+	}
+	if !validateAdjustedTimes(c, visit, req) {
+		return
+	}
+
+	adjustment, err := h.Visits.AdjustVisit(scheduleID, store.VisitAdjustmentInput{
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+		ReasonCode: req.ReasonCode,
+		Note:       req.Note,
+		CloseTasks: closesPendingTasks(),
+	}, actorFromContext(c))
+	if err != nil {
+		var pending *store.PendingTasksError
+		if errors.As(err, &pending) {
+			handlePendingTasks(c, scheduleID, pending)
+			return
+		}
+		handleAdjustmentError(c, err)
+		return
+	}
+
+	utils.LogInfo("Visit adjusted", logrus.Fields{
+		"request_id":    c.GetString("request_id"),
+		"schedule_id":   scheduleID,
+		"adjustment_id": adjustment.ID,
+		"reason_code":   adjustment.ReasonCode,
+	})
+
+	utils.JSONCreated(c, adjustment)
+}
+
+// validateAdjustedTimes checks the visit's times as they will be after the adjustment.
+// It reports the error and returns false when they are invalid or unchanged.
+func validateAdjustedTimes(c *gin.Context, visit models.Visit, req models.VisitAdjustmentRequest) bool {
+	start, end := visit.StartTime, visit.EndTime
+	if req.StartTime != nil {
+		start = req.StartTime
+	}
+	if req.EndTime != nil {
+		end = req.EndTime
+	}
+
+	now := time.Now()
+	switch {
+	case start == nil:
+		utils.HandleValidationError(c,
+			&ValidationError{Field: "start_time", Message: "The visit has no clock-in; start_time is required"},
+			"start_time")
+		return false
+	case start.After(now) || (end != nil && end.After(now)):
+		utils.HandleValidationError(c,
+			&ValidationError{Field: "end_time", Message: "Adjusted times cannot be in the future"},
+			"end_time")
+		return false
+	case end != nil && !start.Before(*end):
+		utils.HandleValidationError(c,
+			&ValidationError{Field: "end_time", Message: "start_time must be before end_time"},
+			"end_time")
+		return false
+	case sameTime(start, visit.StartTime) && sameTime(end, visit.EndTime):
+		utils.HandleValidationError(c,
+			&ValidationError{Field: "start_time", Message: "The adjustment does not change the visit's times"},
+			"start_time")
+		return false
+	}
+	return true
+}
+
+// sameTime reports whether two optional times are equal to the second, the precision
+// visit times are stored with
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Truncate(time.Second).Equal(b.Truncate(time.Second))
+}
+
+// handleAdjustmentError reports why a visit could not be adjusted
+func handleAdjustmentError(c *gin.Context, err error) {
+	if errors.Is(err, store.ErrVisitNotAdjustable) {
+		utils.HandleValidationError(c,
+			&ValidationError{Field: "status", Message: err.Error()},
+			"visit_status")
+		return
+	}
+	handleTransitionError(c, err, "adjust_visit")
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"visit-tracker-api/store"
+	"visit-tracker-api/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Default and largest number of audit events returned by one query
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// AuditHandler serves the audit log of writes
+type AuditHandler struct {
+	Events store.AuditStore
+}
+
+// NewAuditHandler returns an audit handler backed by the given store
+func NewAuditHandler(events store.AuditStore) *AuditHandler {
+	return &AuditHandler{Events: events}
+}
+
+// GetAuditEvents godoc
+// @Summary List audit events
+// @Description Get the audit log of writes, newest first: who changed which row, from where, and the row before and after the change
+// @Tags audit
+// @Produce json
+// @Security BearerAuth
+// @Param entity query string false "Only events for this table, e.g. tasks, visits or schedules"
+// @Param entity_id query int false "Only events for this row; requires entity"
+// @Param from query string false "Only events at or after this time, RFC 3339"
+// @Param to query string false "Only events before this time, RFC 3339"
+// @Param limit query int false "Maximum number of events (default 100, max 1000)"
+// @Success 200 {array} models.AuditEvent
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 401 {object} middleware.ErrorResponse
+// @Failure 403 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /audit-events [get]
+func (h *AuditHandler) GetAuditEvents(c *gin.Context) {
+	filter := store.AuditFilter{Entity: c.Query("entity"), Limit: defaultAuditLimit}
+
+	if value := c.Query("entity_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			utils.HandleValidationError(c, fmt.Errorf("entity_id must be an integer"), "entity_id")
+			return
+		}
+		if filter.Entity == "" {
+			utils.HandleValidationError(c,
+				&ValidationError{Field: "entity", Message: "entity is required with entity_id"},
+				"entity")
+			return
+		}
+		filter.EntityID = &id
+	}
+
+	var err error
+	if filter.From, err = timeParam(c, "from"); err != nil {
+		utils.HandleValidationError(c, err, "from")
+		return
+	}
+	if filter.To, err = timeParam(c, "to"); err != nil {
+		utils.HandleValidationError(c, err, "to")
+		return
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		utils.HandleValidationError(c,
+			&ValidationError{Field: "to", Message: "to must be after from"},
+			"to")
+		return
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxAuditLimit {
+			utils.HandleValidationError(c, fmt.Errorf("limit must be between 1 and %d", maxAuditLimit), "limit")
+			return
+		}
+		filter.Limit = limit
+	}
+
+	events, err := h.Events.ListAuditEvents(filter)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "list_audit_events")
+		return
+	}
+
+	utils.JSONSuccess(c, events)
+}
+
+// timeParam parses an optional RFC 3339 query parameter, returning the zero time when
+// it is absent
+func timeParam(c *gin.Context, name string) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return t, fmt.Errorf("%s must be an RFC 3339 time, e.g. 2024-01-15T09:00:00Z", name)
+	}
+	return t, nil
+}
+
+// VerifyAuditChain godoc
+// @Summary Verify the audit log
+// @Description Recompute the audit log's hash chain and report the first event that was changed, removed or inserted out of order. Compare last_hash with a previously saved value to detect events removed from the end.
+// @Tags audit
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.AuditVerification
+// @Failure 401 {object} middleware.ErrorResponse
+// @Failure 403 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /audit-events/verify [get]
+func (h *AuditHandler) VerifyAuditChain(c *gin.Context) {
+	verification, err := h.Events.VerifyAuditChain()
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "verify_audit_chain")
+		return
+	}
+
+	if !verification.Valid {
+		utils.LogWarn("Audit log hash chain is broken", logrus.Fields{
+			"request_id":       c.GetString("request_id"),
+			"first_invalid_id": *verification.FirstInvalidID,
+			"message":          verification.Message,
+		})
+	}
+
+	utils.JSONSuccess(c, verification)
+}
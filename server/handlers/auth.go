@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"visit-tracker-api/middleware"
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+	"visit-tracker-api/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var authConfig middleware.AuthConfig
+
+// SetAuthConfig sets the token settings used by the login handler
+func SetAuthConfig(cfg middleware.AuthConfig) {
+	authConfig = cfg
+}
+
+// AuthHandler serves sign-in and the user account endpoints
+type AuthHandler struct {
+	Users      store.UserStore
+	Caregivers store.CaregiverStore
+}
+
+// NewAuthHandler returns an auth handler for the accounts in users, linking caregiver
+// accounts to the caregivers in the other store
+func NewAuthHandler(users store.UserStore, caregivers store.CaregiverStore) *AuthHandler {
+	return &AuthHandler{Users: users, Caregivers: caregivers}
+}
+
+// Login godoc
+// @Summary Sign in
+// @Description Exchange email and password for a bearer token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body models.LoginRequest true "Login credentials"
+// @Success 200 {object} models.LoginResponse
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 401 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /auth/login [post]
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req models.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err, "request_body")
+		return
+	}
+
+	user, err := h.Users.GetUserByEmail(req.Email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		utils.HandleDatabaseError(c, err, "get_user")
+		return
+	}
+
+	if err != nil || !user.Active ||
+		bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
+		utils.LogWarn("Failed login attempt", logrus.Fields{
+			"request_id": c.GetString("request_id"),
+			"email":      req.Email,
+			"ip":         c.ClientIP(),
+		})
+		unauthorized := *middleware.ErrUnauthorized
+		unauthorized.Message = "Invalid email or password"
+		c.Error(&unauthorized)
+		return
+	}
+
+	token, expiresAt, err := middleware.GenerateToken(authConfig, user)
+	if err != nil {
+		utils.HandleError(c, err, "Failed to sign token")
+		return
+	}
+
+	utils.LogInfo("User logged in", logrus.Fields{
+		"request_id": c.GetString("request_id"),
+		"user_id":    user.ID,
+		"role":       user.Role,
+	})
+
+	utils.JSONSuccess(c, models.LoginResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		User:      user,
+	})
+}
+
+// GetCurrentUser godoc
+// @Summary Get the signed-in user
+// @Description Get the account belonging to the bearer token
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.User
+// @Failure 401 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Router /auth/me [get]
+func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
+	claims, _ := middleware.CurrentClaims(c)
+
+	user, err := h.Users.GetUser(claims.UserID)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "get_user")
+		return
+	}
+
+	utils.JSONSuccess(c, user)
+}
+
+// GetUsers godoc
+// @Summary List users
+// @Description Get all user accounts
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.User
+// @Failure 401 {object} middleware.ErrorResponse
+// @Failure 403 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /users [get]
+func (h *AuthHandler) GetUsers(c *gin.Context) {
+	users, err := h.Users.ListUsers()
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "list_users")
+		return
+	}
+
+	utils.JSONSuccess(c, users)
+}
+
+// CreateUser godoc
+// @Summary Create a user
+// @Description Create a user account; caregiver accounts must be linked to a caregiver
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user body models.CreateUserRequest true "User data"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 201 {object} models.User
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 401 {object} middleware.ErrorResponse
+// @Failure 403 {object} middleware.ErrorResponse
+// @Failure 409 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /users [post]
+func (h *AuthHandler) CreateUser(c *gin.Context) {
+	var req models.CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err, "request_body")
+		return
+	}
+
+	if req.Role == models.RoleCaregiver {
+		if req.CaregiverID == nil {
+			utils.HandleValidationError(c,
+				&ValidationError{Field: "caregiver_id", Message: "caregiver_id is required for caregiver accounts"},
+				"caregiver_id")
+			return
+		}
+		if _, err := h.Caregivers.GetCaregiver(*req.CaregiverID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				utils.HandleValidationError(c,
+					&ValidationError{Field: "caregiver_id", Message: "Caregiver not found"},
+					"caregiver_id")
+				return
+			}
+			utils.HandleDatabaseError(c, err, "get_caregiver")
+			return
+		}
+	} else {
+		req.CaregiverID = nil
+	}
+
+	_, err := h.Users.GetUserByEmail(req.Email)
+	if err == nil {
+		utils.HandleConflictError(c, "A user with this email already exists", gin.H{"email": req.Email})
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		utils.HandleDatabaseError(c, err, "check_user_email")
+		return
+	}
+
+	user, err := h.Users.CreateUser(store.UserInput{
+		Email:       req.Email,
+		Password:    req.Password,
+		Role:        req.Role,
+		CaregiverID: req.CaregiverID,
+	}, actorFromContext(c))
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "create_user")
+		return
+	}
+
+	utils.LogInfo("User created", logrus.Fields{
+		"request_id": c.GetString("request_id"),
+		"user_id":    user.ID,
+		"role":       user.Role,
+	})
+
+	utils.JSONCreated(c, user)
+}
+
+// authorizeScheduleAccess checks that the authenticated user may act on a schedule.
+// Caregivers are limited to their own shifts; coordinators and admins may access any.
+// It reports the error and returns false when access is denied.
+func authorizeScheduleAccess(c *gin.Context, schedules store.ScheduleStore, scheduleID int) bool {
+	claims, ok := middleware.CurrentClaims(c)
+	if !ok {
+		c.Error(middleware.ErrUnauthorized)
+		return false
+	}
+	if claims.Role != models.RoleCaregiver {
+		return true
+	}
+
+	schedule, err := schedules.GetSchedule(scheduleID)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "get_schedule_caregiver")
+		return false
+	}
+
+	if !canAccessSchedule(claims, schedule) {
+		utils.LogWarn("Caregiver attempted to access another caregiver's schedule", logrus.Fields{
+			"request_id":  c.GetString("request_id"),
+			"user_id":     claims.UserID,
+			"schedule_id": scheduleID,
+		})
+		forbidden := *middleware.ErrForbidden
+		forbidden.Details = "Schedule " + strconv.Itoa(scheduleID) + " is not assigned to you"
+		c.Error(&forbidden)
+		return false
+	}
+	return true
+}
+
+// canAccessSchedule reports whether the user may work on a schedule: caregivers only
+// on shifts assigned to them, every other role on all of them
+func canAccessSchedule(claims *middleware.Claims, schedule models.Schedule) bool {
+	if claims.Role != models.RoleCaregiver {
+		return true
+	}
+	assigned := schedule.CaregiverID
+	return claims.CaregiverID != nil && assigned != nil && *assigned == *claims.CaregiverID
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"visit-tracker-api/middleware"
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+	"visit-tracker-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBulkTaskUpdates is the largest number of tasks one bulk update may change
+const maxBulkTaskUpdates = 100
+
+// validateBulkTaskUpdates checks every item of a bulk update against the schedule's
+// tasks and returns one error per problem found
+func validateBulkTaskUpdates(items []models.BulkTaskUpdate, tasks []models.Task) []models.BulkTaskError {
+	known := make(map[int]bool, len(tasks))
+	for _, task := range tasks {
+		known[task.ID] = true
+	}
+
+	seen := make(map[int]bool, len(items))
+	var problems []models.BulkTaskError
+	reject := func(index int, item models.BulkTaskUpdate, field, message string) {
+		problems = append(problems, models.BulkTaskError{
+			Index: index, TaskID: item.TaskID, Field: field, Message: message,
+		})
+	}
+
+	for i, item := range items {
+		switch {
+		case !known[item.TaskID]:
+			reject(i, item, "task_id", "task does not belong to this schedule")
+		case seen[item.TaskID]:
+			reject(i, item, "task_id", "task appears more than once")
+		}
+		seen[item.TaskID] = true
+
+		switch item.Status {
+		case "completed":
+		case "not_completed":
+			if item.Reason == "" {
+				reject(i, item, "reason", "reason is required when marking a task as not completed")
+			}
+		default:
+			reject(i, item, "status", "status must be completed or not_completed")
+		}
+	}
+	return problems
+}
+
+// BulkUpdateTasks godoc
+// @Summary Update several tasks at once
+// @Description Set the status of several of a schedule's tasks in one request. Every item is validated first and either all updates are saved or none is; a reason is required for not_completed. Tasks can only be updated while the visit is in progress.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Param request body models.BulkTaskUpdateRequest true "Task updates"
+// @Success 200 {array} models.Task
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 403 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /schedules/{id}/tasks/bulk [post]
+func (h *TaskHandler) BulkUpdateTasks(c *gin.Context) {
+	scheduleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "schedule_id")
+		return
+	}
+
+	var req models.BulkTaskUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err, "request_body")
+		return
+	}
+	if len(req.Tasks) == 0 {
+		utils.HandleValidationError(c, &ValidationError{Field: "tasks", Message: "tasks must not be empty"}, "tasks")
+		return
+	}
+	if len(req.Tasks) > maxBulkTaskUpdates {
+		utils.HandleValidationError(c, &ValidationError{
+			Field:   "tasks",
+			Message: "at most " + strconv.Itoa(maxBulkTaskUpdates) + " tasks can be updated at once",
+		}, "tasks")
+		return
+	}
+
+	if !authorizeScheduleAccess(c, h.Schedules, scheduleID) {
+		return
+	}
+
+	schedule, err := h.Schedules.GetSchedule(scheduleID)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "get_schedule")
+		return
+	}
+	if schedule.Status != models.StatusInProgress {
+		utils.HandleValidationError(c,
+			&ValidationError{Field: "status", Message: store.ErrVisitNotInProgress.Error()},
+			"visit_status")
+		return
+	}
+
+	tasks, err := h.Tasks.ListTasks(scheduleID)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "list_tasks")
+		return
+	}
+	if problems := validateBulkTaskUpdates(req.Tasks, tasks); len(problems) > 0 {
+		c.Error(&middleware.APIError{
+			Code:       "VALIDATION_ERROR",
+			Message:    "Validation failed",
+			Details:    problems,
+			StatusCode: http.StatusBadRequest,
+		})
+		return
+	}
+
+	updates := make([]store.TaskUpdate, len(req.Tasks))
+	for i, item := range req.Tasks {
+		updates[i] = store.TaskUpdate{ID: item.TaskID, Status: item.Status, Reason: item.Reason}
+	}
+
+	tasks, err = h.Tasks.UpdateTasks(scheduleID, updates, actorFromContext(c))
+	if err != nil {
+		if errors.Is(err, store.ErrVisitNotInProgress) {
+			utils.HandleValidationError(c,
+				&ValidationError{Field: "status", Message: err.Error()},
+				"visit_status")
+			return
+		}
+		utils.HandleDatabaseError(c, err, "update_tasks")
+		return
+	}
+
+	utils.JSONSuccess(c, tasks)
+}
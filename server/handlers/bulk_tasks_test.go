@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+)
+
+func TestValidateBulkTaskUpdates(t *testing.T) {
+	tasks := []models.Task{{ID: 1}, {ID: 2}}
+
+	tests := []struct {
+		name      string
+		items     []models.BulkTaskUpdate
+		wantField []string // of each problem, in order
+	}{
+		{
+			name:  "valid",
+			items: []models.BulkTaskUpdate{{TaskID: 1, Status: "completed"}, {TaskID: 2, Status: "not_completed", Reason: "Declined"}},
+		},
+		{
+			name:      "duplicate task",
+			items:     []models.BulkTaskUpdate{{TaskID: 1, Status: "completed"}, {TaskID: 1, Status: "completed"}},
+			wantField: []string{"task_id"},
+		},
+		{
+			name:      "task of another schedule",
+			items:     []models.BulkTaskUpdate{{TaskID: 1, Status: "completed"}, {TaskID: 9, Status: "completed"}},
+			wantField: []string{"task_id"},
+		},
+		{
+			name:      "not_completed without a reason",
+			items:     []models.BulkTaskUpdate{{TaskID: 1, Status: "not_completed"}},
+			wantField: []string{"reason"},
+		},
+		{
+			name:      "unknown status",
+			items:     []models.BulkTaskUpdate{{TaskID: 1, Status: "pending"}},
+			wantField: []string{"status"},
+		},
+		{
+			name:      "every problem is reported",
+			items:     []models.BulkTaskUpdate{{TaskID: 9, Status: "completed"}, {TaskID: 2, Status: "not_completed"}},
+			wantField: []string{"task_id", "reason"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validateBulkTaskUpdates(tt.items, tasks)
+			if len(problems) != len(tt.wantField) {
+				t.Fatalf("problems = %+v, want fields %v", problems, tt.wantField)
+			}
+			for i, problem := range problems {
+				if problem.Field != tt.wantField[i] {
+					t.Errorf("problem %d field = %s, want %s", i, problem.Field, tt.wantField[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBulkUpdateTasksSavesAllOrNothing(t *testing.T) {
+	shiftStart := time.Now().Add(-time.Hour).Truncate(time.Second)
+	f := newSyncFixture(t, shiftStart)
+	if err := f.store.StartVisit(f.scheduleID, store.VisitCheckpoint{Time: shiftStart, Latitude: homeLatitude, Longitude: homeLongitude}, f.actor); err != nil {
+		t.Fatal(err)
+	}
+	tasks, err := f.store.ListTasks(f.scheduleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskID := strconv.Itoa(tasks[0].ID)
+
+	handler := NewTaskHandler(f.store, f.store).BulkUpdateTasks
+	serve := func(body string) *httptest.ResponseRecorder {
+		path := "/schedules/" + strconv.Itoa(f.scheduleID) + "/tasks/bulk"
+		return f.serve(t, "/schedules/:id/tasks/bulk", handler, http.MethodPost, path, body)
+	}
+
+	rec := serve(`{"tasks": [{"task_id": ` + taskID + `, "status": "completed"}, {"task_id": 999, "status": "completed"}]}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body.String())
+	}
+	var response struct {
+		Error struct {
+			Details []models.BulkTaskError `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if details := response.Error.Details; len(details) != 1 || details[0].Index != 1 || details[0].TaskID != 999 {
+		t.Errorf("details = %+v, want task 999 at index 1", details)
+	}
+	if task, err := f.store.GetTask(tasks[0].ID); err != nil || task.Status != "pending" {
+		t.Errorf("task = %+v, %v after a rejected bulk update, want pending", task, err)
+	}
+
+	rec = serve(`{"tasks": [{"task_id": ` + taskID + `, "status": "not_completed", "reason": "Client declined"}]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if task, err := f.store.GetTask(tasks[0].ID); err != nil || task.Status != "not_completed" || task.Reason != "Client declined" {
+		t.Errorf("task = %+v, %v, want not_completed with its reason", task, err)
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"time"
+
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// calendarRange returns the first day and the day after the last of the week (Monday to
+// Sunday) or month containing date, as midnight UTC dates
+func calendarRange(view string, date time.Time) (time.Time, time.Time) {
+	if view == models.CalendarMonth {
+		first := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return first, first.AddDate(0, 1, 0)
+	}
+	monday := date.AddDate(0, 0, -(int(date.Weekday())+6)%7)
+	return monday, monday.AddDate(0, 0, 7)
+}
+
+// GetCalendar godoc
+// @Summary Get a schedule calendar
+// @Description Count the schedules starting on each day of a week (Monday to Sunday) or month, in total and by status, for rendering a calendar. Each shift counts on the date it starts in its client's time zone. Caregivers only see their own shifts.
+// @Tags schedules
+// @Produce json
+// @Security BearerAuth
+// @Param view query string false "Calendar view (default week)" Enums(week, month)
+// @Param date query string false "YYYY-MM-DD date within the week or month (default today in the agency's time zone)"
+// @Param caregiver_id query int false "Only count shifts assigned to this caregiver"
+// @Param client_id query int false "Only count shifts for this client"
+// @Param status query string false "Only count shifts in these statuses, comma-separated"
+// @Success 200 {object} models.Calendar
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 401 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /calendar [get]
+func (h *ScheduleHandler) GetCalendar(c *gin.Context) {
+	view := c.DefaultQuery("view", models.CalendarWeek)
+	if view != models.CalendarWeek && view != models.CalendarMonth {
+		utils.HandleValidationError(c,
+			&ValidationError{Field: "view", Message: "view must be week or month"},
+			"view")
+		return
+	}
+
+	date := utils.LocalDate(time.Now(), utils.AgencyLocation())
+	if value := c.Query("date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			utils.HandleValidationError(c,
+				&ValidationError{Field: "date", Message: "date must be in YYYY-MM-DD format"},
+				"date")
+			return
+		}
+		date = parsed
+	}
+
+	filter, verr := parseScheduleFilter(c)
+	if verr != nil {
+		utils.HandleValidationError(c, verr, verr.Field)
+		return
+	}
+
+	from, to := calendarRange(view, date)
+	days, err := h.Schedules.CountSchedulesByDay(filter, from, to)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "count_schedules_by_day")
+		return
+	}
+
+	calendar := models.Calendar{
+		View:     view,
+		From:     from.Format("2006-01-02"),
+		To:       to.AddDate(0, 0, -1).Format("2006-01-02"),
+		ByStatus: models.StatusCounts(),
+		Days:     days,
+	}
+	for _, day := range days {
+		calendar.Total += day.Total
+		for status, count := range day.ByStatus {
+			calendar.ByStatus[status] += count
+		}
+	}
+
+	utils.JSONSuccess(c, calendar)
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+	"visit-tracker-api/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// CarePlanHandler serves the care-plan endpoints
+type CarePlanHandler struct {
+	Clients   store.ClientStore
+	CarePlans store.CarePlanStore
+}
+
+// NewCarePlanHandler returns a care-plan handler backed by the given stores
+func NewCarePlanHandler(clients store.ClientStore, carePlans store.CarePlanStore) *CarePlanHandler {
+	return &CarePlanHandler{Clients: clients, CarePlans: carePlans}
+}
+
+// validateCarePlanTaskRequest checks a care-plan task payload and fills in the default
+// frequency
+func validateCarePlanTaskRequest(req *models.CarePlanTaskRequest) *ValidationError {
+	req.Description = strings.TrimSpace(req.Description)
+	if req.Description == "" {
+		return &ValidationError{Field: "description", Message: "description is required"}
+	}
+	if req.Frequency == "" {
+		req.Frequency = models.FrequencyEveryVisit
+	}
+	if !models.IsCarePlanFrequency(req.Frequency) {
+		return &ValidationError{Field: "frequency", Message: "frequency must be every_visit, daily or weekly"}
+	}
+	return nil
+}
+
+// carePlanTaskParams reads the client and care-plan task IDs from the path, reporting
+// an error and returning false when either is invalid
+func carePlanTaskParams(c *gin.Context) (int, int, bool) {
+	clientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "client_id")
+		return 0, 0, false
+	}
+	taskID, err := strconv.Atoi(c.Param("taskId"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "task_id")
+		return 0, 0, false
+	}
+	return clientID, taskID, true
+}
+
+// GetCarePlan godoc
+// @Summary Get a client's care plan
+// @Description Get the tasks of a client's care plan, which are copied onto the client's shifts as they are created or generated from templates
+// @Tags care-plans
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Client ID"
+// @Param active query bool false "Only return active tasks"
+// @Success 200 {array} models.CarePlanTask
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /clients/{id}/care-plan [get]
+func (h *CarePlanHandler) GetCarePlan(c *gin.Context) {
+	clientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "client_id")
+		return
+	}
+	if _, err := h.Clients.GetClient(clientID); err != nil {
+		utils.HandleDatabaseError(c, err, "get_client")
+		return
+	}
+
+	var active *bool
+	if activeParam := c.Query("active"); activeParam != "" {
+		value, err := strconv.ParseBool(activeParam)
+		if err != nil {
+			utils.HandleValidationError(c, err, "active")
+			return
+		}
+		active = &value
+	}
+
+	tasks, err := h.CarePlans.ListCarePlanTasks(clientID, active)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "list_care_plan_tasks")
+		return
+	}
+
+	utils.JSONSuccess(c, tasks)
+}
+
+// CreateCarePlanTask godoc
+// @Summary Add a care-plan task
+// @Description Add a task to a client's care plan. It is added to the client's shifts created or generated from now on: every visit, or the first shift of each day or week in the client's time zone. Existing shifts are not changed.
+// @Tags care-plans
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Client ID"
+// @Param task body models.CarePlanTaskRequest true "Care-plan task"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 201 {object} models.CarePlanTask
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /clients/{id}/care-plan [post]
+func (h *CarePlanHandler) CreateCarePlanTask(c *gin.Context) {
+	clientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "client_id")
+		return
+	}
+
+	var req models.CarePlanTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err, "request_body")
+		return
+	}
+	if verr := validateCarePlanTaskRequest(&req); verr != nil {
+		utils.HandleValidationError(c, verr, verr.Field)
+		return
+	}
+
+	if _, err := h.Clients.GetClient(clientID); err != nil {
+		utils.HandleDatabaseError(c, err, "get_client")
+		return
+	}
+
+	input := store.CarePlanTaskInput{Description: req.Description, Frequency: req.Frequency, Required: true, Active: true}
+	if req.Required != nil {
+		input.Required = *req.Required
+	}
+	if req.Active != nil {
+		input.Active = *req.Active
+	}
+
+	task, err := h.CarePlans.CreateCarePlanTask(clientID, input, actorFromContext(c))
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "create_care_plan_task")
+		return
+	}
+
+	utils.LogInfo("Care-plan task created", logrus.Fields{
+		"request_id":        c.GetString("request_id"),
+		"client_id":         clientID,
+		"care_plan_task_id": task.ID,
+	})
+
+	utils.JSONCreated(c, task)
+}
+
+// UpdateCarePlanTask godoc
+// @Summary Update a care-plan task
+// @Description Change a task of a client's care plan. Shifts created from now on use the new details; tasks already on shifts are not changed.
+// @Tags care-plans
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Client ID"
+// @Param taskId path int true "Care-plan task ID"
+// @Param task body models.CarePlanTaskRequest true "Care-plan task"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 200 {object} models.CarePlanTask
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /clients/{id}/care-plan/{taskId} [put]
+func (h *CarePlanHandler) UpdateCarePlanTask(c *gin.Context) {
+	clientID, taskID, ok := carePlanTaskParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.CarePlanTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err, "request_body")
+		return
+	}
+	if verr := validateCarePlanTaskRequest(&req); verr != nil {
+		utils.HandleValidationError(c, verr, verr.Field)
+		return
+	}
+
+	existing, err := h.CarePlans.GetCarePlanTask(clientID, taskID)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "get_care_plan_task")
+		return
+	}
+
+	input := store.CarePlanTaskInput{Description: req.Description, Frequency: req.Frequency, Required: existing.Required, Active: existing.Active}
+	if req.Required != nil {
+		input.Required = *req.Required
+	}
+	if req.Active != nil {
+		input.Active = *req.Active
+	}
+
+	task, err := h.CarePlans.UpdateCarePlanTask(clientID, taskID, input, actorFromContext(c))
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "update_care_plan_task")
+		return
+	}
+
+	utils.LogInfo("Care-plan task updated", logrus.Fields{
+		"request_id":        c.GetString("request_id"),
+		"client_id":         clientID,
+		"care_plan_task_id": taskID,
+	})
+
+	utils.JSONSuccess(c, task)
+}
+
+// DeleteCarePlanTask godoc
+// @Summary Remove a care-plan task
+// @Description Deactivate a task of a client's care plan so it is no longer added to new shifts; the record is kept so the tasks copied from it stay traceable
+// @Tags care-plans
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Client ID"
+// @Param taskId path int true "Care-plan task ID"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 200 {object} models.CarePlanTask
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /clients/{id}/care-plan/{taskId} [delete]
+func (h *CarePlanHandler) DeleteCarePlanTask(c *gin.Context) {
+	clientID, taskID, ok := carePlanTaskParams(c)
+	if !ok {
+		return
+	}
+
+	existing, err := h.CarePlans.GetCarePlanTask(clientID, taskID)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "get_care_plan_task")
+		return
+	}
+
+	input := store.CarePlanTaskInput{Description: existing.Description, Frequency: existing.Frequency, Required: existing.Required}
+	task, err := h.CarePlans.UpdateCarePlanTask(clientID, taskID, input, actorFromContext(c))
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "deactivate_care_plan_task")
+		return
+	}
+
+	utils.LogInfo("Care-plan task deactivated", logrus.Fields{
+		"request_id":        c.GetString("request_id"),
+		"client_id":         clientID,
+		"care_plan_task_id": taskID,
+	})
+
+	utils.JSONSuccess(c, task)
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"strconv"
+
+	"visit-tracker-api/middleware"
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+	"visit-tracker-api/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// CaregiverHandler serves the caregiver endpoints
+type CaregiverHandler struct {
+	Caregivers store.CaregiverStore
+}
+
+// NewCaregiverHandler returns a caregiver handler backed by the given store
+func NewCaregiverHandler(caregivers store.CaregiverStore) *CaregiverHandler {
+	return &CaregiverHandler{Caregivers: caregivers}
+}
+
+// checkCaregiverEmail reports a conflict and returns false when a caregiver other than
+// excludeID already uses the email
+func (h *CaregiverHandler) checkCaregiverEmail(c *gin.Context, email string, excludeID int) bool {
+	taken, err := h.Caregivers.CaregiverEmailTaken(email, excludeID)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "check_caregiver_email")
+		return false
+	}
+	if taken {
+		utils.HandleConflictError(c, "A caregiver with this email already exists", gin.H{"email": email})
+		return false
+	}
+	return true
+}
+
+// GetCaregivers godoc
+// @Summary List caregivers
+// @Description Get all caregivers, optionally only active ones
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param active query bool false "Only return active caregivers"
+// @Success 200 {array} models.Caregiver
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /caregivers [get]
+func (h *CaregiverHandler) GetCaregivers(c *gin.Context) {
+	var active *bool
+	if activeParam := c.Query("active"); activeParam != "" {
+		value, err := strconv.ParseBool(activeParam)
+		if err != nil {
+			utils.HandleValidationError(c, err, "active")
+			return
+		}
+		active = &value
+	}
+
+	caregivers, err := h.Caregivers.ListCaregivers(active)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "list_caregivers")
+		return
+	}
+
+	utils.JSONSuccess(c, caregivers)
+}
+
+// GetCaregiverByID godoc
+// @Summary Get caregiver by ID
+// @Description Get a specific caregiver
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Caregiver ID"
+// @Success 200 {object} models.Caregiver
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /caregivers/{id} [get]
+func (h *CaregiverHandler) GetCaregiverByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "caregiver_id")
+		return
+	}
+
+	caregiver, err := h.Caregivers.GetCaregiver(id)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "get_caregiver")
+		return
+	}
+
+	utils.JSONSuccess(c, caregiver)
+}
+
+// CreateCaregiver godoc
+// @Summary Create a caregiver
+// @Description Register a new caregiver who can be assigned to schedules
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param caregiver body models.CreateCaregiverRequest true "Caregiver data"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 201 {object} models.Caregiver
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 409 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /caregivers [post]
+func (h *CaregiverHandler) CreateCaregiver(c *gin.Context) {
+	var req models.CreateCaregiverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err, "request_body")
+		return
+	}
+	if !h.checkCaregiverEmail(c, req.Email, 0) {
+		return
+	}
+
+	caregiver, err := h.Caregivers.CreateCaregiver(store.CaregiverInput{
+		Name:   req.Name,
+		Email:  req.Email,
+		Phone:  req.Phone,
+		Active: true,
+	}, actorFromContext(c))
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "create_caregiver")
+		return
+	}
+
+	utils.LogInfo("Caregiver created", logrus.Fields{
+		"request_id":   c.GetString("request_id"),
+		"caregiver_id": caregiver.ID,
+	})
+
+	utils.JSONCreated(c, caregiver)
+}
+
+// UpdateCaregiver godoc
+// @Summary Update a caregiver
+// @Description Update a caregiver's contact details or active flag
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Caregiver ID"
+// @Param caregiver body models.UpdateCaregiverRequest true "Caregiver data"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 200 {object} models.Caregiver
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 409 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /caregivers/{id} [put]
+func (h *CaregiverHandler) UpdateCaregiver(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "caregiver_id")
+		return
+	}
+
+	var req models.UpdateCaregiverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err, "request_body")
+		return
+	}
+
+	existing, err := h.Caregivers.GetCaregiver(id)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "get_caregiver")
+		return
+	}
+	if !h.checkCaregiverEmail(c, req.Email, id) {
+		return
+	}
+
+	active := existing.Active
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	caregiver, err := h.Caregivers.UpdateCaregiver(id, store.CaregiverInput{
+		Name:   req.Name,
+		Email:  req.Email,
+		Phone:  req.Phone,
+		Active: active,
+	}, actorFromContext(c))
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "update_caregiver")
+		return
+	}
+
+	utils.JSONSuccess(c, caregiver)
+}
+
+// DeleteCaregiver godoc
+// @Summary Deactivate a caregiver
+// @Description Deactivate a caregiver; the record is kept so past schedules and visits stay attributable
+// @Tags caregivers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Caregiver ID"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 200 {object} models.Caregiver
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /caregivers/{id} [delete]
+func (h *CaregiverHandler) DeleteCaregiver(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "caregiver_id")
+		return
+	}
+
+	existing, err := h.Caregivers.GetCaregiver(id)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "get_caregiver")
+		return
+	}
+
+	caregiver, err := h.Caregivers.UpdateCaregiver(id, store.CaregiverInput{
+		Name:   existing.Name,
+		Email:  existing.Email,
+		Phone:  existing.Phone,
+		Active: false,
+	}, actorFromContext(c))
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "deactivate_caregiver")
+		return
+	}
+
+	utils.LogInfo("Caregiver deactivated", logrus.Fields{
+		"request_id":   c.GetString("request_id"),
+		"caregiver_id": id,
+	})
+
+	utils.JSONSuccess(c, caregiver)
+}
+
+// requestedCaregiverID returns the caregiver the schedule listing should be scoped to,
+// or nil when every caregiver's shifts were requested. Caregivers are always scoped to themselves.
+func requestedCaregiverID(c *gin.Context) (*int, error) {
+	if claims, ok := middleware.CurrentClaims(c); ok && claims.Role == models.RoleCaregiver {
+		if claims.CaregiverID == nil {
+			return nil, &ValidationError{Field: "caregiver_id", Message: "Account is not linked to a caregiver"}
+		}
+		return claims.CaregiverID, nil
+	}
+
+	param := c.Query("caregiver_id")
+	if param == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.Atoi(param)
+	if err != nil {
+		return nil, &ValidationError{Field: "caregiver_id", Message: "Invalid caregiver ID"}
+	}
+	return &id, nil
+}
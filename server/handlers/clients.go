@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+	"visit-tracker-api/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ClientHandler serves the client registry endpoints
+type ClientHandler struct {
+	Clients store.ClientStore
+}
+
+// NewClientHandler returns a client handler backed by the given store
+func NewClientHandler(clients store.ClientStore) *ClientHandler {
+	return &ClientHandler{Clients: clients}
+}
+
+// clientInput converts a validated client payload to the store's input
+func clientInput(req models.ClientRequest, active bool) store.ClientInput {
+	return store.ClientInput{
+		Name:              req.Name,
+		Address:           req.Address,
+		Latitude:          req.Latitude,
+		Longitude:         req.Longitude,
+		CarePlanNotes:     req.CarePlanNotes,
+		MedicaidID:        req.MedicaidID,
+		Timezone:          req.Timezone,
+		Active:            active,
+		EmergencyContacts: req.EmergencyContacts,
+	}
+}
+
+// validateClientRequest checks fields the binding tags cannot express
+func validateClientRequest(req models.ClientRequest) *ValidationError {
+	if req.Latitude < -90 || req.Latitude > 90 || req.Longitude < -180 || req.Longitude > 180 {
+		return &ValidationError{Field: "coordinates", Message: "Invalid latitude or longitude"}
+	}
+	if req.Timezone != "" {
+		if _, err := time.LoadLocation(req.Timezone); err != nil || req.Timezone == "Local" {
+			return &ValidationError{Field: "timezone", Message: "timezone must be an IANA time zone such as America/Chicago"}
+		}
+	}
+	return nil
+}
+
+// GetClients godoc
+// @Summary List clients
+// @Description Get all care recipients, optionally only active ones
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param active query bool false "Only return active clients"
+// @Success 200 {array} models.Client
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /clients [get]
+func (h *ClientHandler) GetClients(c *gin.Context) {
+	var active *bool
+	if activeParam := c.Query("active"); activeParam != "" {
+		value, err := strconv.ParseBool(activeParam)
+		if err != nil {
+			utils.HandleValidationError(c, err, "active")
+			return
+		}
+		active = &value
+	}
+
+	clients, err := h.Clients.ListClients(active)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "list_clients")
+		return
+	}
+
+	utils.JSONSuccess(c, clients)
+}
+
+// GetClientByID godoc
+// @Summary Get client by ID
+// @Description Get a care recipient's profile with emergency contacts
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Client ID"
+// @Success 200 {object} models.Client
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /clients/{id} [get]
+func (h *ClientHandler) GetClientByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "client_id")
+		return
+	}
+
+	client, err := h.Clients.GetClient(id)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "get_client")
+		return
+	}
+
+	utils.JSONSuccess(c, client)
+}
+
+// CreateClient godoc
+// @Summary Create a client
+// @Description Register a care recipient with address, home coordinates, care plan notes and emergency contacts
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param client body models.ClientRequest true "Client data"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 201 {object} models.Client
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /clients [post]
+func (h *ClientHandler) CreateClient(c *gin.Context) {
+	var req models.ClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err, "request_body")
+		return
+	}
+	if verr := validateClientRequest(req); verr != nil {
+		utils.HandleValidationError(c, verr, verr.Field)
+		return
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	client, err := h.Clients.CreateClient(clientInput(req, active), actorFromContext(c))
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "create_client")
+		return
+	}
+
+	utils.LogInfo("Client created", logrus.Fields{
+		"request_id": c.GetString("request_id"),
+		"client_id":  client.ID,
+	})
+
+	utils.JSONCreated(c, client)
+}
+
+// UpdateClient godoc
+// @Summary Update a client
+// @Description Update a care recipient's profile; every schedule for the client picks up the change
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Client ID"
+// @Param client body models.ClientRequest true "Client data"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 200 {object} models.Client
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /clients/{id} [put]
+func (h *ClientHandler) UpdateClient(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "client_id")
+		return
+	}
+
+	var req models.ClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err, "request_body")
+		return
+	}
+	if verr := validateClientRequest(req); verr != nil {
+		utils.HandleValidationError(c, verr, verr.Field)
+		return
+	}
+
+	existing, err := h.Clients.GetClient(id)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "get_client")
+		return
+	}
+
+	active := existing.Active
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	client, err := h.Clients.UpdateClient(id, clientInput(req, active), actorFromContext(c))
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "update_client")
+		return
+	}
+
+	utils.JSONSuccess(c, client)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"visit-tracker-api/config"
+	"visit-tracker-api/evv"
+	"visit-tracker-api/store"
+	"visit-tracker-api/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// EVVHandler serves the EVV compliance export
+type EVVHandler struct {
+	Visits  store.EVVStore
+	Layouts map[string]evv.Layout
+	Config  config.EVVConfig
+}
+
+// NewEVVHandler returns an EVV handler exporting visits in the given aggregator layouts
+func NewEVVHandler(visits store.EVVStore, layouts map[string]evv.Layout, cfg config.EVVConfig) *EVVHandler {
+	return &EVVHandler{Visits: visits, Layouts: layouts, Config: cfg}
+}
+
+// ExportEVV godoc
+// @Summary Export EVV visit data
+// @Description Export the visits clocked in between two dates with the six EVV data elements (service type, recipient, date, location, caregiver, start and end time), in an aggregator's layout as JSON or CSV. Visits missing an element, not yet clocked out, or recorded outside the geofence are listed with their validation errors and left out of the records unless include_invalid is set. CSV responses report the counts in the X-EVV-Total and X-EVV-Invalid headers.
+// @Tags evv
+// @Produce json
+// @Produce text/csv
+// @Security BearerAuth
+// @Param from query string true "First service date, YYYY-MM-DD"
+// @Param to query string true "Last service date, YYYY-MM-DD"
+// @Param format query string false "Output format" Enums(json, csv)
+// @Param layout query string false "Aggregator layout: standard, sandata, hhaexchange or one from EVV_LAYOUTS_FILE (default EVV_LAYOUT)"
+// @Param include_invalid query bool false "Also export the rows of visits that failed validation"
+// @Success 200 {object} models.EVVExport
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 401 {object} middleware.ErrorResponse
+// @Failure 403 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /evv/export [get]
+func (h *EVVHandler) ExportEVV(c *gin.Context) {
+	from, to := c.Query("from"), c.Query("to")
+	start, end, err := evv.ParseDateRange(from, to)
+	if err != nil {
+		utils.HandleValidationError(c, err, "from")
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		utils.HandleValidationError(c, fmt.Errorf("format must be json or csv, got %q", format), "format")
+		return
+	}
+
+	layoutName := c.DefaultQuery("layout", h.Config.Layout)
+	layout, ok := h.Layouts[layoutName]
+	if !ok {
+		err := fmt.Errorf("unknown layout %q, expected one of %s", layoutName, strings.Join(evv.LayoutNames(h.Layouts), ", "))
+		utils.HandleValidationError(c, err, "layout")
+		return
+	}
+
+	includeInvalid, err := strconv.ParseBool(c.DefaultQuery("include_invalid", "false"))
+	if err != nil {
+		utils.HandleValidationError(c, fmt.Errorf("include_invalid must be true or false"), "include_invalid")
+		return
+	}
+
+	visits, err := h.Visits.ListEVVVisits(start, end)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "list_evv_visits")
+		return
+	}
+
+	export := evv.Build(visits, layout, evv.Options{
+		ProviderID:         h.Config.ProviderID,
+		DefaultServiceCode: h.Config.DefaultServiceCode,
+		IncludeInvalid:     includeInvalid,
+	}, from, to)
+
+	utils.LogInfo("EVV export generated", logrus.Fields{
+		"request_id": c.GetString("request_id"),
+		"layout":     layout.Name,
+		"format":     format,
+		"from":       from,
+		"to":         to,
+		"total":      export.Total,
+		"invalid":    export.Invalid,
+	})
+
+	if format == "json" {
+		utils.JSONSuccess(c, export)
+		return
+	}
+
+	var body bytes.Buffer
+	if err := evv.WriteCSV(&body, export); err != nil {
+		utils.HandleError(c, err, "Failed to write EVV export")
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="evv-%s-%s-%s.csv"`, layout.Name, from, to))
+	c.Header("X-EVV-Total", strconv.Itoa(export.Total))
+	c.Header("X-EVV-Invalid", strconv.Itoa(export.Invalid))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", body.Bytes())
+}
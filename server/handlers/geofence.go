@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+
+	"visit-tracker-api/config"
+	"visit-tracker-api/middleware"
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Geofence enforcement modes
+const (
+	GeofenceModeFlag   = config.GeofenceModeFlag   // accept out-of-range locations but record them as outside
+	GeofenceModeReject = config.GeofenceModeReject // refuse out-of-range locations unless the override is allowed
+)
+
+// geofenceViolationMessage explains a clock-in/out rejected outside the geofence
+const geofenceViolationMessage = "Location is outside the client's geofence; provide an override_reason of " +
+	"gps_inaccurate, client_off_site or address_changed to proceed"
+
+var geofenceConfig = config.Default().Geofence
+
+// SetGeofenceConfig replaces the geofence settings used by the visit handlers
+func SetGeofenceConfig(cfg config.GeofenceConfig) {
+	geofenceConfig = cfg
+}
+
+// evaluateGeofence compares a submitted location with the client's home and reports
+// whether the clock-in/out may proceed under the configured mode. An out-of-range location
+// given with an override reason is recorded as overridden; in reject mode only a known
+// reason code, or any reason from a coordinator or admin, overrides the geofence.
+func evaluateGeofence(clientLat, clientLng, lat, lng float64, overrideReason, role string) (models.GeofenceResult, bool) {
+	distance := utils.HaversineDistance(clientLat, clientLng, lat, lng)
+
+	result := models.GeofenceResult{
+		Status:         models.GeofenceInside,
+		DistanceMeters: math.Round(distance*10) / 10,
+		RadiusMeters:   geofenceConfig.RadiusMeters,
+	}
+
+	if distance <= geofenceConfig.RadiusMeters {
+		return result, true
+	}
+
+	if overrideReason != "" && (geofenceConfig.Mode != GeofenceModeReject || canOverrideGeofence(role, overrideReason)) {
+		result.Status = models.GeofenceOverridden
+		return result, true
+	}
+
+	result.Status = models.GeofenceOutside
+	return result, geofenceConfig.Mode != GeofenceModeReject
+}
+
+// canOverrideGeofence reports whether a user with the role may clock in or out outside a
+// rejecting geofence for the reason
+func canOverrideGeofence(role, reason string) bool {
+	return role == models.RoleCoordinator || role == models.RoleAdmin || models.IsGeofenceOverrideReason(reason)
+}
+
+// handleGeofenceViolation reports a rejected clock-in/out that was outside the geofence
+func handleGeofenceViolation(c *gin.Context, scheduleID int, result models.GeofenceResult) {
+	utils.LogWarn("Location outside geofence", logrus.Fields{
+		"request_id":      c.GetString("request_id"),
+		"schedule_id":     scheduleID,
+		"distance_meters": result.DistanceMeters,
+		"radius_meters":   result.RadiusMeters,
+	})
+
+	c.Error(&middleware.APIError{
+		Code:       "GEOFENCE_VIOLATION",
+		Message:    geofenceViolationMessage,
+		Details:    result,
+		StatusCode: http.StatusUnprocessableEntity,
+	})
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"testing"
+
+	"visit-tracker-api/config"
+	"visit-tracker-api/models"
+)
+
+func TestEvaluateGeofence(t *testing.T) {
+	// About 1.1 km north of the client's home, beyond the 150 m radius
+	farLatitude := homeLatitude + 0.01
+
+	tests := []struct {
+		name        string
+		mode        string
+		latitude    float64
+		reason      string
+		role        string
+		wantStatus  string
+		wantAllowed bool
+	}{
+		{"inside in reject mode", GeofenceModeReject, homeLatitude, "", models.RoleCaregiver, models.GeofenceInside, true},
+		{"outside in reject mode", GeofenceModeReject, farLatitude, "", models.RoleCaregiver, models.GeofenceOutside, false},
+		{"caregiver with a reason code", GeofenceModeReject, farLatitude, models.GeofenceOverrideGPSInaccurate, models.RoleCaregiver, models.GeofenceOverridden, true},
+		{"caregiver with a free-text reason", GeofenceModeReject, farLatitude, "Client asked me to", models.RoleCaregiver, models.GeofenceOutside, false},
+		{"coordinator with a free-text reason", GeofenceModeReject, farLatitude, "Visit at the day centre", models.RoleCoordinator, models.GeofenceOverridden, true},
+		{"admin with a free-text reason", GeofenceModeReject, farLatitude, "Visit at the day centre", models.RoleAdmin, models.GeofenceOverridden, true},
+		{"outside in flag mode", GeofenceModeFlag, farLatitude, "", models.RoleCaregiver, models.GeofenceOutside, true},
+		{"free-text reason in flag mode", GeofenceModeFlag, farLatitude, "Client asked me to", models.RoleCaregiver, models.GeofenceOverridden, true},
+	}
+
+	defer SetGeofenceConfig(config.Default().Geofence)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetGeofenceConfig(config.GeofenceConfig{RadiusMeters: 150, Mode: tt.mode})
+
+			result, allowed := evaluateGeofence(homeLatitude, homeLongitude, tt.latitude, homeLongitude, tt.reason, tt.role)
+			if result.Status != tt.wantStatus || allowed != tt.wantAllowed {
+				t.Errorf("evaluateGeofence() = %s, %v; want %s, %v", result.Status, allowed, tt.wantStatus, tt.wantAllowed)
+			}
+			if result.RadiusMeters != 150 {
+				t.Errorf("radius = %g, want 150", result.RadiusMeters)
+			}
+		})
+	}
+}
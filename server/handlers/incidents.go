@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+	"visit-tracker-api/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// IncidentHandler serves the incident reporting endpoints
+type IncidentHandler struct {
+	Schedules store.ScheduleStore
+	Incidents store.IncidentStore
+}
+
+// NewIncidentHandler returns an incident handler backed by the given stores
+func NewIncidentHandler(schedules store.ScheduleStore, incidents store.IncidentStore) *IncidentHandler {
+	return &IncidentHandler{Schedules: schedules, Incidents: incidents}
+}
+
+// unknownValue reports a value that is not one of the allowed ones
+func unknownValue(field, value string, allowed []string) *ValidationError {
+	return &ValidationError{
+		Field:   field,
+		Message: fmt.Sprintf("Unknown %s %q, expected %s", field, value, strings.Join(allowed, ", ")),
+	}
+}
+
+// validateIncident checks the fields shared by new and updated incidents
+func validateIncident(category, severity string, injury bool, injuryDetails string) *ValidationError {
+	if !models.IsIncidentCategory(category) {
+		return unknownValue("category", category, models.IncidentCategories)
+	}
+	if !models.IsIncidentSeverity(severity) {
+		return unknownValue("severity", severity, models.IncidentSeverities)
+	}
+	if injury && strings.TrimSpace(injuryDetails) == "" {
+		return &ValidationError{Field: "injury_details", Message: "injury_details is required when injury is true"}
+	}
+	return nil
+}
+
+// validateIncidentUpdate checks the fields an incident update sets and trims its text.
+// Checks that depend on the incident's current state are made by the store.
+func validateIncidentUpdate(req *models.IncidentUpdateRequest) *ValidationError {
+	if req.Category != nil && !models.IsIncidentCategory(*req.Category) {
+		return unknownValue("category", *req.Category, models.IncidentCategories)
+	}
+	if req.Severity != nil && !models.IsIncidentSeverity(*req.Severity) {
+		return unknownValue("severity", *req.Severity, models.IncidentSeverities)
+	}
+	if req.Status != nil && !models.IsIncidentStatus(*req.Status) {
+		return unknownValue("status", *req.Status, models.IncidentStatuses)
+	}
+	if req.InjuryDetails != nil {
+		details := strings.TrimSpace(*req.InjuryDetails)
+		req.InjuryDetails = &details
+	}
+	if req.FollowUpNotes != nil {
+		notes := strings.TrimSpace(*req.FollowUpNotes)
+		req.FollowUpNotes = &notes
+	}
+	return nil
+}
+
+// parseIncidentFilter reads the coordinator queue's filters. Without a status filter
+// the queue lists the incidents still open or in review.
+func parseIncidentFilter(c *gin.Context) (store.IncidentFilter, *ValidationError) {
+	filter := store.IncidentFilter{Statuses: models.OpenIncidentStatuses}
+
+	if value := c.Query("client_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, &ValidationError{Field: "client_id", Message: "Invalid client ID"}
+		}
+		filter.ClientID = &id
+	}
+
+	if value := c.Query("status"); value != "" {
+		filter.Statuses = nil
+		for _, status := range strings.Split(value, ",") {
+			status = strings.TrimSpace(status)
+			if !models.IsIncidentStatus(status) {
+				return filter, unknownValue("status", status, models.IncidentStatuses)
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	if value := c.Query("severity"); value != "" {
+		for _, severity := range strings.Split(value, ",") {
+			severity = strings.TrimSpace(severity)
+			if !models.IsIncidentSeverity(severity) {
+				return filter, unknownValue("severity", severity, models.IncidentSeverities)
+			}
+			filter.Severities = append(filter.Severities, severity)
+		}
+	}
+	return filter, nil
+}
+
+// GetIncidentQueue godoc
+// @Summary List open incidents
+// @Description List incidents for coordinator follow-up, most severe first and, within a severity, the longest waiting first. Without a status filter only open and in_review incidents are listed.
+// @Tags incidents
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Comma-separated statuses: open, in_review, resolved"
+// @Param severity query string false "Comma-separated severities: low, medium, high, critical"
+// @Param client_id query int false "Client ID"
+// @Success 200 {array} models.Incident
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /incidents [get]
+func (h *IncidentHandler) GetIncidentQueue(c *gin.Context) {
+	filter, verr := parseIncidentFilter(c)
+	if verr != nil {
+		utils.HandleValidationError(c, verr, verr.Field)
+		return
+	}
+
+	incidents, err := h.Incidents.ListIncidents(filter)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "list_incidents")
+		return
+	}
+
+	utils.JSONSuccess(c, incidents)
+}
+
+// GetScheduleIncidents godoc
+// @Summary List a schedule's incidents
+// @Description Get every incident filed against a schedule, most severe first
+// @Tags incidents
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Success 200 {array} models.Incident
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 403 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /schedules/{id}/incidents [get]
+func (h *IncidentHandler) GetScheduleIncidents(c *gin.Context) {
+	scheduleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "schedule_id")
+		return
+	}
+
+	if !authorizeScheduleAccess(c, h.Schedules, scheduleID) {
+		return
+	}
+	if _, err := h.Schedules.GetSchedule(scheduleID); err != nil {
+		utils.HandleDatabaseError(c, err, "get_schedule")
+		return
+	}
+
+	incidents, err := h.Incidents.ListIncidents(store.IncidentFilter{ScheduleID: &scheduleID})
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "list_incidents")
+		return
+	}
+
+	utils.JSONSuccess(c, incidents)
+}
+
+// GetIncident godoc
+// @Summary Get an incident
+// @Description Get a single incident with its follow-up
+// @Tags incidents
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Incident ID"
+// @Success 200 {object} models.Incident
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 403 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /incidents/{id} [get]
+func (h *IncidentHandler) GetIncident(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "incident_id")
+		return
+	}
+
+	incident, err := h.Incidents.GetIncident(id)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "get_incident")
+		return
+	}
+	if !authorizeScheduleAccess(c, h.Schedules, incident.ScheduleID) {
+		return
+	}
+
+	utils.JSONSuccess(c, incident)
+}
+
+// CreateIncident godoc
+// @Summary File an incident
+// @Description File an incident such as a fall, medication error or injury against a schedule whose visit has started. It is queued as open for coordinator follow-up.
+// @Tags incidents
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Param incident body models.IncidentRequest true "Incident"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 201 {object} models.Incident
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 403 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /schedules/{id}/incidents [post]
+func (h *IncidentHandler) CreateIncident(c *gin.Context) {
+	scheduleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "schedule_id")
+		return
+	}
+
+	var req models.IncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err, "request_body")
+		return
+	}
+
+	input := store.IncidentInput{
+		Category:      req.Category,
+		Severity:      req.Severity,
+		Description:   strings.TrimSpace(req.Description),
+		OccurredAt:    time.Now(),
+		Injury:        req.Injury,
+		InjuryDetails: strings.TrimSpace(req.InjuryDetails),
+	}
+	if verr := validateIncident(input.Category, input.Severity, input.Injury, input.InjuryDetails); verr != nil {
+		utils.HandleValidationError(c, verr, verr.Field)
+		return
+	}
+	if input.Description == "" {
+		utils.HandleValidationError(c, &ValidationError{Field: "description", Message: "description is required"}, "description")
+		return
+	}
+	if req.OccurredAt != nil {
+		if req.OccurredAt.After(time.Now().Add(maxSyncClockSkew)) {
+			utils.HandleValidationError(c, &ValidationError{Field: "occurred_at", Message: "occurred_at cannot be in the future"}, "occurred_at")
+			return
+		}
+		input.OccurredAt = *req.OccurredAt
+	}
+
+	if !authorizeScheduleAccess(c, h.Schedules, scheduleID) {
+		return
+	}
+
+	incident, err := h.Incidents.CreateIncident(scheduleID, input, actorFromContext(c))
+	if err != nil {
+		if errors.Is(err, store.ErrIncidentBeforeVisit) {
+			utils.HandleValidationError(c,
+				&ValidationError{Field: "status", Message: err.Error()},
+				"visit_status")
+			return
+		}
+		utils.HandleDatabaseError(c, err, "create_incident")
+		return
+	}
+
+	if incident.Severity == models.SeverityHigh || incident.Severity == models.SeverityCritical {
+		utils.LogWarn("Incident needs escalation", logrus.Fields{
+			"request_id":  c.GetString("request_id"),
+			"incident_id": incident.ID,
+			"schedule_id": scheduleID,
+			"category":    incident.Category,
+			"severity":    incident.Severity,
+		})
+	}
+
+	utils.JSONCreated(c, incident)
+}
+
+// UpdateIncident godoc
+// @Summary Update an incident
+// @Description Reclassify an incident or record its follow-up. Omitted fields are left as they are; resolving requires follow_up_notes and records who resolved it and when.
+// @Tags incidents
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Incident ID"
+// @Param incident body models.IncidentUpdateRequest true "Changes"
+// @Success 200 {object} models.Incident
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /incidents/{id} [put]
+func (h *IncidentHandler) UpdateIncident(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "incident_id")
+		return
+	}
+
+	var req models.IncidentUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err, "request_body")
+		return
+	}
+
+	if verr := validateIncidentUpdate(&req); verr != nil {
+		utils.HandleValidationError(c, verr, verr.Field)
+		return
+	}
+
+	// The changes are merged with the stored incident inside the store's transaction
+	incident, err := h.Incidents.UpdateIncident(id, store.IncidentUpdate{
+		Category:      req.Category,
+		Severity:      req.Severity,
+		Status:        req.Status,
+		Injury:        req.Injury,
+		InjuryDetails: req.InjuryDetails,
+		FollowUpNotes: req.FollowUpNotes,
+	}, actorFromContext(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrInjuryDetailsRequired):
+			utils.HandleValidationError(c, &ValidationError{Field: "injury_details", Message: err.Error()}, "injury_details")
+		case errors.Is(err, store.ErrFollowUpRequired):
+			utils.HandleValidationError(c, &ValidationError{Field: "follow_up_notes", Message: err.Error()}, "follow_up_notes")
+		default:
+			utils.HandleDatabaseError(c, err, "update_incident")
+		}
+		return
+	}
+
+	utils.JSONSuccess(c, incident)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+)
+
+func TestUpdateIncident(t *testing.T) {
+	shiftStart := time.Now().Add(-time.Hour).Truncate(time.Second)
+	f := newSyncFixture(t, shiftStart)
+	checkpoint := store.VisitCheckpoint{Time: shiftStart, Latitude: homeLatitude, Longitude: homeLongitude}
+	if err := f.store.StartVisit(f.scheduleID, checkpoint, f.actor); err != nil {
+		t.Fatal(err)
+	}
+	incident, err := f.store.CreateIncident(f.scheduleID, store.IncidentInput{
+		Category:    models.IncidentFall,
+		Severity:    models.SeverityHigh,
+		Description: "Client slipped in the bathroom",
+		OccurredAt:  shiftStart,
+	}, f.actor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewIncidentHandler(f.store, f.store).UpdateIncident
+	path := "/incidents/" + strconv.Itoa(incident.ID)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantField  string
+	}{
+		{name: "unknown severity", body: `{"severity": "urgent"}`, wantStatus: http.StatusBadRequest, wantField: "severity"},
+		{name: "unknown status", body: `{"status": "closed"}`, wantStatus: http.StatusBadRequest, wantField: "status"},
+		{name: "resolve without follow-up notes", body: `{"status": "resolved"}`, wantStatus: http.StatusBadRequest, wantField: "follow_up_notes"},
+		{name: "resolve with blank follow-up notes", body: `{"status": "resolved", "follow_up_notes": "  "}`, wantStatus: http.StatusBadRequest, wantField: "follow_up_notes"},
+		{name: "injury without details", body: `{"injury": true}`, wantStatus: http.StatusBadRequest, wantField: "injury_details"},
+		{name: "follow-up notes only", body: `{"follow_up_notes": " Called the family "}`, wantStatus: http.StatusOK},
+		{name: "resolve with stored follow-up notes", body: `{"status": "resolved"}`, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := f.serve(t, "/incidents/:id", handler, http.MethodPut, path, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantField != "" {
+				var response struct {
+					Error struct {
+						Details map[string]string `json:"details"`
+					} `json:"error"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+					t.Fatal(err)
+				}
+				if response.Error.Details["field"] != tt.wantField {
+					t.Errorf("details = %v, want field %s", response.Error.Details, tt.wantField)
+				}
+			}
+		})
+	}
+
+	updated, err := f.store.GetIncident(incident.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Status != models.IncidentResolved || updated.FollowUpNotes != "Called the family" {
+		t.Errorf("status, follow_up_notes = %s, %q; want resolved with the trimmed notes", updated.Status, updated.FollowUpNotes)
+	}
+	if updated.Severity != models.SeverityHigh || updated.Injury {
+		t.Errorf("severity, injury = %s, %v; want the rejected changes not saved", updated.Severity, updated.Injury)
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"visit-tracker-api/config"
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+	"visit-tracker-api/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+var missedVisitConfig = config.Default().MissedVisit
+
+// missedVisitJob names the detector's lock, held by one API instance at a time
+const missedVisitJob = "missed_visit_detector"
+
+// SetMissedVisitConfig replaces the missed-visit settings
+func SetMissedVisitConfig(cfg config.MissedVisitConfig) {
+	missedVisitConfig = cfg
+}
+
+// StartMissedVisitDetector marks overdue schedules as late or missed now and then on every
+// interval. When several instances share the database, a run is skipped while another
+// instance's run is still going.
+func StartMissedVisitDetector(cfg config.MissedVisitConfig, schedules store.ScheduleStore, jobs store.JobStore) {
+	go func() {
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			_, err := jobs.RunExclusive(missedVisitJob, func() error {
+				_, err := MarkMissedVisits(schedules, time.Now())
+				return err
+			})
+			if err != nil {
+				utils.LogError(err, "Missed visit detection failed", nil)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// MarkMissedVisits moves schedules that have not been started through the state machine:
+// upcoming schedules past shift_start become late, and upcoming or late schedules more than
+// the grace period past shift_start become missed. It returns how many schedules changed.
+func MarkMissedVisits(schedules store.ScheduleStore, now time.Time) (int, error) {
+	graceMinutes := int(missedVisitConfig.GracePeriod.Minutes())
+
+	missed, err := transitionOverdueSchedules(
+		schedules,
+		now.Add(-missedVisitConfig.GracePeriod),
+		[]string{models.StatusUpcoming, models.StatusLate},
+		store.StatusChange{
+			To:     models.StatusMissed,
+			Reason: fmt.Sprintf("Not started within %d minutes of shift start", graceMinutes),
+		},
+	)
+	if err != nil {
+		return missed, err
+	}
+
+	late, err := transitionOverdueSchedules(
+		schedules,
+		now,
+		[]string{models.StatusUpcoming},
+		store.StatusChange{To: models.StatusLate, Reason: "Not started by shift start"},
+	)
+	return missed + late, err
+}
+
+// transitionOverdueSchedules moves schedules in one of the given statuses whose shift
+// started at or before cutoff, logging each transition. Schedules started in the meantime
+// are left alone.
+func transitionOverdueSchedules(schedules store.ScheduleStore, cutoff time.Time, statuses []string, change store.StatusChange) (int, error) {
+	overdue, err := schedules.ListOverdueSchedules(cutoff, statuses)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, schedule := range overdue {
+		from, err := schedules.TransitionSchedule(schedule.ID, change, store.SystemActor)
+		if err != nil {
+			var transitionErr *models.TransitionError
+			if errors.As(err, &transitionErr) {
+				continue
+			}
+			return changed, err
+		}
+		changed++
+
+		fields := logrus.Fields{
+			"schedule_id": schedule.ID,
+			"client_id":   schedule.ClientID,
+			"shift_start": schedule.ShiftStart.Format(utils.DateTimeLayout),
+			"from_status": from,
+			"to_status":   change.To,
+		}
+		if schedule.CaregiverID != nil {
+			fields["caregiver_id"] = *schedule.CaregiverID
+		}
+		if change.To == models.StatusMissed {
+			fields["grace_minutes"] = int(missedVisitConfig.GracePeriod.Minutes())
+		}
+		utils.LogInfo("Schedule marked as "+change.To, fields)
+	}
+	return changed, nil
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+	"visit-tracker-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxNoteLength is the longest note body accepted, in characters
+const maxNoteLength = 4000
+
+// NoteHandler serves the visit note endpoints
+type NoteHandler struct {
+	Schedules store.ScheduleStore
+	Notes     store.NoteStore
+}
+
+// NewNoteHandler returns a visit note handler backed by the given stores
+func NewNoteHandler(schedules store.ScheduleStore, notes store.NoteStore) *NoteHandler {
+	return &NoteHandler{Schedules: schedules, Notes: notes}
+}
+
+// validateNoteRequest checks a visit note payload and fills in the default category
+func validateNoteRequest(req *models.VisitNoteRequest) *ValidationError {
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Body == "" {
+		return &ValidationError{Field: "body", Message: "body is required"}
+	}
+	if len([]rune(req.Body)) > maxNoteLength {
+		return &ValidationError{Field: "body", Message: "body must be at most " + strconv.Itoa(maxNoteLength) + " characters"}
+	}
+	if req.Category == "" {
+		req.Category = models.NoteCategoryGeneral
+	}
+	if !models.IsNoteCategory(req.Category) {
+		return &ValidationError{
+			Field:   "category",
+			Message: "category must be one of " + strings.Join(models.NoteCategories, ", "),
+		}
+	}
+	return nil
+}
+
+// GetVisitNotes godoc
+// @Summary List a visit's notes
+// @Description Get the narrative notes written about a schedule's visit, oldest first
+// @Tags notes
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Success 200 {array} models.VisitNote
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 403 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /schedules/{id}/notes [get]
+func (h *NoteHandler) GetVisitNotes(c *gin.Context) {
+	scheduleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "schedule_id")
+		return
+	}
+
+	if !authorizeScheduleAccess(c, h.Schedules, scheduleID) {
+		return
+	}
+	if _, err := h.Schedules.GetSchedule(scheduleID); err != nil {
+		utils.HandleDatabaseError(c, err, "get_schedule")
+		return
+	}
+
+	notes, err := h.Notes.ListVisitNotes(scheduleID)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "list_visit_notes")
+		return
+	}
+
+	utils.JSONSuccess(c, notes)
+}
+
+// CreateVisitNote godoc
+// @Summary Add a note to a visit
+// @Description Append a timestamped note to a schedule's visit, recorded with its author. Notes can be added once the visit has started and cannot be changed afterwards.
+// @Tags notes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Param note body models.VisitNoteRequest true "Note"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 201 {object} models.VisitNote
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 403 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /schedules/{id}/notes [post]
+func (h *NoteHandler) CreateVisitNote(c *gin.Context) {
+	scheduleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "schedule_id")
+		return
+	}
+
+	var req models.VisitNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err, "request_body")
+		return
+	}
+	if verr := validateNoteRequest(&req); verr != nil {
+		utils.HandleValidationError(c, verr, verr.Field)
+		return
+	}
+
+	if !authorizeScheduleAccess(c, h.Schedules, scheduleID) {
+		return
+	}
+
+	note, err := h.Notes.AddVisitNote(scheduleID, req.Category, req.Body, actorFromContext(c))
+	if err != nil {
+		if errors.Is(err, store.ErrVisitNotStarted) {
+			utils.HandleValidationError(c,
+				&ValidationError{Field: "status", Message: err.Error()},
+				"visit_status")
+			return
+		}
+		utils.HandleDatabaseError(c, err, "add_visit_note")
+		return
+	}
+
+	utils.JSONCreated(c, note)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+)
+
+func TestValidateNoteRequest(t *testing.T) {
+	tests := []struct {
+		name         string
+		category     string
+		body         string
+		wantField    string
+		wantCategory string
+	}{
+		{name: "default category", body: "Client seemed tired", wantCategory: models.NoteCategoryGeneral},
+		{name: "known category", category: models.NoteCategoryIncident, body: "Client slipped", wantCategory: models.NoteCategoryIncident},
+		{name: "unknown category", category: "medical", body: "Client seemed tired", wantField: "category"},
+		{name: "blank body", body: "  \n ", wantField: "body"},
+		{name: "body at the limit in multi-byte characters", body: strings.Repeat("é", maxNoteLength), wantCategory: models.NoteCategoryGeneral},
+		{name: "body over the limit", body: strings.Repeat("a", maxNoteLength+1), wantField: "body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := models.VisitNoteRequest{Category: tt.category, Body: tt.body}
+			verr := validateNoteRequest(&req)
+			if tt.wantField != "" {
+				if verr == nil || verr.Field != tt.wantField {
+					t.Fatalf("validateNoteRequest() = %v, want an error on %s", verr, tt.wantField)
+				}
+				return
+			}
+			if verr != nil {
+				t.Fatalf("validateNoteRequest() = %v, want nil", verr)
+			}
+			if req.Category != tt.wantCategory {
+				t.Errorf("category = %q, want %q", req.Category, tt.wantCategory)
+			}
+		})
+	}
+}
+
+func TestVisitNotesInScheduleAndClockOut(t *testing.T) {
+	shiftStart := time.Now().Add(-time.Hour).Truncate(time.Second)
+	f := newSyncFixture(t, shiftStart)
+	path := "/schedules/" + strconv.Itoa(f.scheduleID)
+
+	notes := NewNoteHandler(f.store, f.store)
+	rec := f.serve(t, "/schedules/:id/notes", notes.CreateVisitNote, http.MethodPost, path+"/notes", `{"body": "Arrived early"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("note before clock-in: status = %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body.String())
+	}
+
+	checkpoint := store.VisitCheckpoint{Time: shiftStart, Latitude: homeLatitude, Longitude: homeLongitude}
+	if err := f.store.StartVisit(f.scheduleID, checkpoint, f.actor); err != nil {
+		t.Fatal(err)
+	}
+	rec = f.serve(t, "/schedules/:id/notes", notes.CreateVisitNote, http.MethodPost, path+"/notes", `{"body": " Client seemed tired "}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("note during the visit: status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+
+	schedules := NewScheduleHandler(f.store, f.store, f.store, f.store, f.store, f.store)
+	rec = f.serve(t, "/schedules/:id", schedules.GetScheduleByID, http.MethodGet, path, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get schedule: status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var details models.ScheduleWithTasks
+	if err := json.Unmarshal(rec.Body.Bytes(), &details); err != nil {
+		t.Fatal(err)
+	}
+	if len(details.Notes) != 1 || details.Notes[0].Body != "Client seemed tired" || details.Notes[0].Category != models.NoteCategoryGeneral {
+		t.Errorf("schedule notes = %+v, want the general note", details.Notes)
+	}
+
+	for _, task := range details.Tasks {
+		if _, err := f.store.UpdateTask(task.ID, "completed", "", f.actor); err != nil {
+			t.Fatal(err)
+		}
+	}
+	visits := NewVisitHandler(f.store, f.store, f.store)
+	body := `{"latitude": ` + strconv.FormatFloat(homeLatitude, 'f', -1, 64) + `, "longitude": ` + strconv.FormatFloat(homeLongitude, 'f', -1, 64) + `}`
+	rec = f.serve(t, "/schedules/:id/end", visits.EndVisit, http.MethodPost, path+"/end", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("end visit: status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var summary struct {
+		Notes []models.VisitNote `json:"notes"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Notes) != 1 || summary.Notes[0].Body != "Client seemed tired" {
+		t.Errorf("clock-out notes = %+v, want the note written during the visit", summary.Notes)
+	}
+}
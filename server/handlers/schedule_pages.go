@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+	"visit-tracker-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Default and largest number of schedules returned in one page
+const (
+	defaultSchedulePageSize = 50
+	maxSchedulePageSize     = 200
+)
+
+// scheduleSorts lists the columns GET /schedules can be sorted by
+var scheduleSorts = []string{
+	store.SortShiftStart, store.SortShiftEnd, store.SortCreatedAt,
+	store.SortUpdatedAt, store.SortClientName, store.SortStatus,
+}
+
+// scheduleCursor is the decoded form of a next_cursor. It carries the sort it was
+// issued for, so it cannot be replayed against a different order.
+type scheduleCursor struct {
+	Sort  string `json:"sort"`
+	Value string `json:"value"`
+	ID    int    `json:"id"`
+}
+
+// encodeScheduleCursor turns the position after a page into an opaque cursor
+func encodeScheduleCursor(sort string, position *store.ScheduleCursor) *string {
+	if position == nil {
+		return nil
+	}
+	encoded, _ := json.Marshal(scheduleCursor{Sort: sort, Value: position.Value, ID: position.ID})
+	cursor := base64.RawURLEncoding.EncodeToString(encoded)
+	return &cursor
+}
+
+// decodeScheduleCursor reads a cursor issued by encodeScheduleCursor for the same sort
+func decodeScheduleCursor(value, sort string) (*store.ScheduleCursor, *ValidationError) {
+	var cursor scheduleCursor
+	encoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err == nil {
+		err = json.Unmarshal(encoded, &cursor)
+	}
+	if err != nil || cursor.ID <= 0 {
+		return nil, &ValidationError{Field: "cursor", Message: "Invalid cursor; pass the next_cursor of the previous page"}
+	}
+	if cursor.Sort != sort {
+		return nil, &ValidationError{Field: "cursor", Message: "The cursor was issued for a different sort"}
+	}
+	return &store.ScheduleCursor{Value: cursor.Value, ID: cursor.ID}, nil
+}
+
+// parseScheduleFilter reads the caregiver, client and status filters of a schedule
+// listing from the query string. Caregivers only ever see their own shifts.
+func parseScheduleFilter(c *gin.Context) (store.ScheduleFilter, *ValidationError) {
+	var filter store.ScheduleFilter
+
+	caregiverID, err := requestedCaregiverID(c)
+	if err != nil {
+		return filter, err.(*ValidationError)
+	}
+	filter.CaregiverID = caregiverID
+
+	if value := c.Query("client_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, &ValidationError{Field: "client_id", Message: "Invalid client ID"}
+		}
+		filter.ClientID = &id
+	}
+
+	if value := c.Query("status"); value != "" {
+		for _, status := range strings.Split(value, ",") {
+			status = strings.TrimSpace(status)
+			if !models.IsScheduleStatus(status) {
+				return filter, &ValidationError{
+					Field:   "status",
+					Message: fmt.Sprintf("Unknown status %q, expected %s", status, strings.Join(models.ScheduleStatuses, ", ")),
+				}
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+	return filter, nil
+}
+
+// parseShiftRange reads the from and to query parameters limiting when shifts start.
+// Each is an RFC 3339 time or a YYYY-MM-DD date in the agency's time zone; to is
+// exclusive as a time and inclusive as a date.
+func parseShiftRange(c *gin.Context, filter *store.ScheduleFilter) *ValidationError {
+	for _, name := range []string{"from", "to"} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+
+		t, err := time.ParseInLocation("2006-01-02", value, utils.AgencyLocation())
+		if err == nil && name == "to" {
+			t = t.AddDate(0, 0, 1)
+		}
+		if err != nil {
+			if t, err = time.Parse(time.RFC3339, value); err != nil {
+				return &ValidationError{
+					Field:   name,
+					Message: name + " must be a YYYY-MM-DD date or an RFC 3339 time, e.g. 2024-01-15T09:00:00Z",
+				}
+			}
+		}
+
+		if name == "from" {
+			filter.From = t
+		} else {
+			filter.To = t
+		}
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return &ValidationError{Field: "to", Message: "to must be after from"}
+	}
+	return nil
+}
+
+// parseSchedulePage reads the sort, cursor and page size of a schedule listing. sort
+// names a column, prefixed with - for descending order.
+func parseSchedulePage(c *gin.Context) (store.SchedulePage, string, *ValidationError) {
+	page := store.SchedulePage{Sort: store.SortShiftStart, Limit: defaultSchedulePageSize}
+
+	sort := c.DefaultQuery("sort", store.SortShiftStart)
+	page.Sort = strings.TrimPrefix(sort, "-")
+	page.Desc = page.Sort != sort
+	known := false
+	for _, column := range scheduleSorts {
+		known = known || column == page.Sort
+	}
+	if !known {
+		return page, sort, &ValidationError{
+			Field:   "sort",
+			Message: fmt.Sprintf("Unknown sort %q, expected one of %s, optionally prefixed with -", sort, strings.Join(scheduleSorts, ", ")),
+		}
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxSchedulePageSize {
+			return page, sort, &ValidationError{
+				Field:   "limit",
+				Message: fmt.Sprintf("limit must be between 1 and %d", maxSchedulePageSize),
+			}
+		}
+		page.Limit = limit
+	}
+
+	if value := c.Query("cursor"); value != "" {
+		after, verr := decodeScheduleCursor(value, sort)
+		if verr != nil {
+			return page, sort, verr
+		}
+		page.After = after
+	}
+	return page, sort, nil
+}
+
+// GetAllSchedules godoc
+// @Summary List schedules
+// @Description Get a page of caregiver schedules matching the filters. Follow pagination.next_cursor, keeping the same filters and sort, until it is null. Caregivers only see their own shifts.
+// @Tags schedules
+// @Produce json
+// @Security BearerAuth
+// @Param caregiver_id query int false "Only return shifts assigned to this caregiver"
+// @Param client_id query int false "Only return shifts for this client"
+// @Param status query string false "Only return shifts in these statuses, comma-separated, e.g. upcoming,late"
+// @Param from query string false "Only shifts starting at or after this RFC 3339 time, or on or after this YYYY-MM-DD date in the agency's time zone"
+// @Param to query string false "Only shifts starting before this RFC 3339 time, or on or before this YYYY-MM-DD date in the agency's time zone"
+// @Param sort query string false "shift_start, shift_end, created_at, updated_at, client_name or status; prefix with - for descending order (default shift_start)"
+// @Param limit query int false "Schedules per page (default 50, max 200)"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} models.SchedulePage
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 401 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /schedules [get]
+func (h *ScheduleHandler) GetAllSchedules(c *gin.Context) {
+	filter, verr := parseScheduleFilter(c)
+	if verr == nil {
+		verr = parseShiftRange(c, &filter)
+	}
+	if verr != nil {
+		utils.HandleValidationError(c, verr, verr.Field)
+		return
+	}
+	page, sort, verr := parseSchedulePage(c)
+	if verr != nil {
+		utils.HandleValidationError(c, verr, verr.Field)
+		return
+	}
+
+	schedules, total, next, err := h.Schedules.ListSchedulePage(filter, page)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "list_schedules")
+		return
+	}
+
+	utils.JSONPage(c, schedules, models.Pagination{
+		Limit:      page.Limit,
+		Total:      total,
+		NextCursor: encodeScheduleCursor(sort, next),
+	})
+}
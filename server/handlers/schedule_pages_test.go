@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"visit-tracker-api/store"
+)
+
+func TestDecodeScheduleCursor(t *testing.T) {
+	position := &store.ScheduleCursor{Value: "2026-03-02 09:00:00", ID: 7}
+	issued := *encodeScheduleCursor("-shift_start", position)
+
+	if encodeScheduleCursor("shift_start", nil) != nil {
+		t.Error("the last page has a next cursor")
+	}
+
+	tests := []struct {
+		name    string
+		cursor  string
+		sort    string
+		wantErr string
+	}{
+		{name: "issued for the sort", cursor: issued, sort: "-shift_start"},
+		{name: "issued for another direction", cursor: issued, sort: "shift_start", wantErr: "The cursor was issued for a different sort"},
+		{name: "not base64", cursor: "not a cursor!", sort: "-shift_start", wantErr: "Invalid cursor; pass the next_cursor of the previous page"},
+		{name: "not JSON", cursor: base64.RawURLEncoding.EncodeToString([]byte("7")), sort: "-shift_start", wantErr: "Invalid cursor; pass the next_cursor of the previous page"},
+		{name: "without an ID", cursor: base64.RawURLEncoding.EncodeToString([]byte(`{"sort":"-shift_start","value":"x"}`)), sort: "-shift_start", wantErr: "Invalid cursor; pass the next_cursor of the previous page"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, verr := decodeScheduleCursor(tt.cursor, tt.sort)
+			if tt.wantErr != "" {
+				if verr == nil || verr.Message != tt.wantErr {
+					t.Fatalf("decodeScheduleCursor() error = %v, want %q", verr, tt.wantErr)
+				}
+				return
+			}
+			if verr != nil {
+				t.Fatalf("decodeScheduleCursor() error = %v", verr)
+			}
+			if *got != *position {
+				t.Errorf("cursor = %+v, want %+v", *got, *position)
+			}
+		})
+	}
+}
@@ -2,82 +2,34 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
 	"time"
 
-	"visit-tracker-api/database"
 	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+	"visit-tracker-api/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
-// parseTime safely parses time strings using standard Go time formats
-func parseTime(timeStr string) time.Time {
-	layouts := []string{
-		time.DateTime,    // "2006-01-02 15:04:05"
-		time.RFC3339,     // "2006-01-02T15:04:05Z07:00"
-		time.RFC3339Nano, // "2006-01-02T15:04:05.999999999Z07:00"
-		time.DateOnly,    // "2006-01-02"
-	}
-	
-	for _, layout := range layouts {
-		if t, err := time.Parse(layout, timeStr); err == nil {
-			return t
-		}
-	}
-	
-	return time.Time{}
+// ScheduleHandler serves the schedule endpoints
+type ScheduleHandler struct {
+	Schedules  store.ScheduleStore
+	Tasks      store.TaskStore
+	Visits     store.VisitStore
+	Notes      store.NoteStore
+	Clients    store.ClientStore
+	Caregivers store.CaregiverStore
 }
 
-// GetAllSchedules godoc
-// @Summary Get all schedules
-// @Description Get a list of all caregiver schedules
-// @Tags schedules
-// @Accept json
-// @Produce json
-// @Success 200 {array} models.Schedule
-// @Failure 500 {object} map[string]string
-// @Router /schedules [get]
-func GetAllSchedules(c *gin.Context) {
-	query := `
-		SELECT s.id, s.client_name, s.shift_start, s.shift_end, s.latitude, s.longitude, s.status, s.created_at, s.updated_at
-		FROM schedules s
-		ORDER BY s.shift_start ASC`
-
-	rows, err := database.DB.Query(query)
-	if err != nil {
-		log.Printf("Database query error in GetAllSchedules: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedules"})
-		return
-	}
-	defer rows.Close()
-
-	var schedules []models.Schedule
-	for rows.Next() {
-		var schedule models.Schedule
-		var shiftStart, shiftEnd, createdAt, updatedAt string
-
-		err := rows.Scan(
-			&schedule.ID, &schedule.ClientName, &shiftStart, &shiftEnd,
-			&schedule.Latitude, &schedule.Longitude, &schedule.Status, &createdAt, &updatedAt,
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse schedule data"})
-			return
-		}
-
-		// Parse time strings using flexible parsing
-		schedule.ShiftStart = parseTime(shiftStart)
-		schedule.ShiftEnd = parseTime(shiftEnd)
-		schedule.CreatedAt = parseTime(createdAt)
-		schedule.UpdatedAt = parseTime(updatedAt)
-
-		schedules = append(schedules, schedule)
-	}
-
-	c.JSON(http.StatusOK, schedules)
+// NewScheduleHandler returns a schedule handler backed by the given stores
+func NewScheduleHandler(schedules store.ScheduleStore, tasks store.TaskStore, visits store.VisitStore, notes store.NoteStore, clients store.ClientStore, caregivers store.CaregiverStore) *ScheduleHandler {
+	return &ScheduleHandler{Schedules: schedules, Tasks: tasks, Visits: visits, Notes: notes, Clients: clients, Caregivers: caregivers}
 }
 
 // GetTodaySchedules godoc
@@ -86,65 +38,89 @@ func GetAllSchedules(c *gin.Context) {
 // @Tags schedules
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Param caregiver_id query int false "Only return shifts assigned to this caregiver"
 // @Success 200 {array} models.Schedule
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /schedules/today [get]
-func GetTodaySchedules(c *gin.Context) {
-	today := time.Now().Format("2006-01-02")
-	
-	query := `
-		SELECT s.id, s.client_name, s.shift_start, s.shift_end, s.latitude, s.longitude, s.status, s.created_at, s.updated_at
-		FROM schedules s
-		WHERE DATE(s.shift_start) = ?
-		ORDER BY s.shift_start ASC`
-
-	rows, err := database.DB.Query(query, today)
+func (h *ScheduleHandler) GetTodaySchedules(c *gin.Context) {
+	caregiverID, err := requestedCaregiverID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedules, err := h.Schedules.ListSchedules(store.ScheduleFilter{
+		CaregiverID: caregiverID,
+		Day:         time.Now(),
+	})
 	if err != nil {
 		log.Printf("Database query error in GetTodaySchedules: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch today's schedules"})
 		return
 	}
-	defer rows.Close()
 
-	var schedules []models.Schedule
-	for rows.Next() {
-		var schedule models.Schedule
-		var shiftStart, shiftEnd, createdAt, updatedAt string
+	c.JSON(http.StatusOK, schedules)
+}
 
-		err := rows.Scan(
-			&schedule.ID, &schedule.ClientName, &shiftStart, &shiftEnd,
-			&schedule.Latitude, &schedule.Longitude, &schedule.Status, &createdAt, &updatedAt,
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse schedule data"})
-			return
-		}
+// getScheduleDetails loads a schedule with its client profile, tasks, visit record and
+// the adjustments made to the visit
+func (h *ScheduleHandler) getScheduleDetails(id int) (models.ScheduleWithTasks, error) {
+	var details models.ScheduleWithTasks
+
+	schedule, err := h.Schedules.GetSchedule(id)
+	if err != nil {
+		return details, err
+	}
+	details.Schedule = schedule
 
-		// Parse time strings using flexible parsing
-		schedule.ShiftStart = parseTime(shiftStart)
-		schedule.ShiftEnd = parseTime(shiftEnd)
-		schedule.CreatedAt = parseTime(createdAt)
-		schedule.UpdatedAt = parseTime(updatedAt)
+	client, err := h.Clients.GetClient(schedule.ClientID)
+	if err != nil {
+		return details, fmt.Errorf("fetch client: %w", err)
+	}
+	details.Client = &client
 
-		schedules = append(schedules, schedule)
+	details.Tasks, err = h.Tasks.ListTasks(id)
+	if err != nil {
+		return details, fmt.Errorf("fetch tasks: %w", err)
 	}
 
-	c.JSON(http.StatusOK, schedules)
+	visit, err := h.Visits.GetVisit(id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return details, fmt.Errorf("fetch visit: %w", err)
+	}
+	if err == nil {
+		details.Visit = &visit
+	}
+
+	details.Adjustments, err = h.Visits.ListVisitAdjustments(id)
+	if err != nil {
+		return details, fmt.Errorf("fetch visit adjustments: %w", err)
+	}
+
+	details.Notes, err = h.Notes.ListVisitNotes(id)
+	if err != nil {
+		return details, fmt.Errorf("fetch visit notes: %w", err)
+	}
+
+	return details, nil
 }
 
 // GetScheduleByID godoc
 // @Summary Get schedule by ID
-// @Description Get a specific schedule with its tasks and visit information
+// @Description Get a specific schedule with its client profile, tasks, visit information, the trail of adjustments to the visit's times and the visit's notes
 // @Tags schedules
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Schedule ID"
 // @Success 200 {object} models.ScheduleWithTasks
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /schedules/{id} [get]
-func GetScheduleByID(c *gin.Context) {
+func (h *ScheduleHandler) GetScheduleByID(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
@@ -152,19 +128,11 @@ func GetScheduleByID(c *gin.Context) {
 		return
 	}
 
-	// Get schedule
-	var scheduleWithTasks models.ScheduleWithTasks
-	var shiftStart, shiftEnd, createdAt, updatedAt string
-
-	scheduleQuery := `
-		SELECT id, client_name, shift_start, shift_end, latitude, longitude, status, created_at, updated_at
-		FROM schedules
-		WHERE id = ?`
+	if !authorizeScheduleAccess(c, h.Schedules, id) {
+		return
+	}
 
-	err = database.DB.QueryRow(scheduleQuery, id).Scan(
-		&scheduleWithTasks.ID, &scheduleWithTasks.ClientName, &shiftStart, &shiftEnd,
-		&scheduleWithTasks.Latitude, &scheduleWithTasks.Longitude, &scheduleWithTasks.Status, &createdAt, &updatedAt,
-	)
+	scheduleWithTasks, err := h.getScheduleDetails(id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
@@ -175,150 +143,265 @@ func GetScheduleByID(c *gin.Context) {
 		return
 	}
 
-	// Parse time strings using flexible parsing
-	scheduleWithTasks.ShiftStart = parseTime(shiftStart)
-	scheduleWithTasks.ShiftEnd = parseTime(shiftEnd)
-	scheduleWithTasks.CreatedAt = parseTime(createdAt)
-	scheduleWithTasks.UpdatedAt = parseTime(updatedAt)
-
-	// Get tasks
-	tasksQuery := `
-		SELECT id, description, status, reason, created_at, updated_at
-		FROM tasks
-		WHERE schedule_id = ?
-		ORDER BY id ASC`
+	c.JSON(http.StatusOK, scheduleWithTasks)
+}
 
-	rows, err := database.DB.Query(tasksQuery, id)
+// GetStats godoc
+// @Summary Get dashboard statistics
+// @Description Get statistics for the dashboard including total, missed, upcoming, and completed schedules
+// @Tags stats
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.StatsResponse
+// @Failure 500 {object} map[string]string
+// @Router /stats [get]
+func (h *ScheduleHandler) GetStats(c *gin.Context) {
+	stats, err := h.Schedules.GetStats(time.Now())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks"})
+		log.Printf("Database query error in GetStats: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch statistics"})
 		return
 	}
-	defer rows.Close()
 
-	var tasks []models.Task
-	for rows.Next() {
-		var task models.Task
-		var reason sql.NullString
-		var taskCreatedAt, taskUpdatedAt string
+	c.JSON(http.StatusOK, stats)
+}
 
-		err := rows.Scan(
-			&task.ID, &task.Description, &task.Status, &reason,
-			&taskCreatedAt, &taskUpdatedAt,
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse task data"})
-			return
+// validateScheduleRequest checks the shift window and that the client and caregiver
+// can take on the shift. It reports the error and returns false when the request is invalid.
+func (h *ScheduleHandler) validateScheduleRequest(c *gin.Context, req models.ScheduleRequest) bool {
+	if !req.ShiftStart.Before(req.ShiftEnd) {
+		utils.HandleValidationError(c,
+			&ValidationError{Field: "shift_end", Message: "shift_start must be before shift_end"},
+			"shift_end")
+		return false
+	}
+
+	return validateScheduleAssignment(c, h.Clients, h.Caregivers, req.ClientID, req.CaregiverID)
+}
+
+// validateScheduleAssignment checks that the client and caregiver exist and are active.
+// It reports the error and returns false when either cannot take on shifts.
+func validateScheduleAssignment(c *gin.Context, clients store.ClientStore, caregivers store.CaregiverStore, clientID int, caregiverID *int) bool {
+	client, err := clients.GetClient(clientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.HandleValidationError(c,
+				&ValidationError{Field: "client_id", Message: "Client not found"},
+				"client_id")
+			return false
 		}
+		utils.HandleDatabaseError(c, err, "get_client")
+		return false
+	}
+	if !client.Active {
+		utils.HandleValidationError(c,
+			&ValidationError{Field: "client_id", Message: "Client is inactive"},
+			"client_id")
+		return false
+	}
 
-		task.ScheduleID = id
-		if reason.Valid {
-			task.Reason = reason.String
+	if caregiverID == nil {
+		return true
+	}
+
+	caregiver, err := caregivers.GetCaregiver(*caregiverID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.HandleValidationError(c,
+				&ValidationError{Field: "caregiver_id", Message: "Caregiver not found"},
+				"caregiver_id")
+			return false
 		}
-		task.CreatedAt = parseTime(taskCreatedAt)
-		task.UpdatedAt = parseTime(taskUpdatedAt)
-
-		tasks = append(tasks, task)
-	}
-	scheduleWithTasks.Tasks = tasks
-
-	// Get visit information
-	visitQuery := `
-		SELECT id, start_time, end_time, start_lat, start_lng, end_lat, end_lng, created_at, updated_at
-		FROM visits
-		WHERE schedule_id = ?`
-
-	var visit models.Visit
-	var startTime, endTime sql.NullString
-	var startLat, startLng, endLat, endLng sql.NullFloat64
-	var visitCreatedAt, visitUpdatedAt string
-
-	err = database.DB.QueryRow(visitQuery, id).Scan(
-		&visit.ID, &startTime, &endTime, &startLat, &startLng, &endLat, &endLng,
-		&visitCreatedAt, &visitUpdatedAt,
-	)
-	if err != nil && err != sql.ErrNoRows {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch visit data"})
+		utils.HandleDatabaseError(c, err, "get_caregiver")
+		return false
+	}
+	if !caregiver.Active {
+		utils.HandleValidationError(c,
+			&ValidationError{Field: "caregiver_id", Message: "Caregiver is inactive"},
+			"caregiver_id")
+		return false
+	}
+	return true
+}
+
+// handleScheduleWriteError reports why a schedule could not be created, edited or cancelled
+func handleScheduleWriteError(c *gin.Context, err error, operation string) {
+	var overlapErr *store.OverlapError
+	switch {
+	case errors.As(err, &overlapErr):
+		utils.HandleConflictError(c, overlapErr.Error(), gin.H{
+			"caregiver_id":         overlapErr.CaregiverID,
+			"conflicting_schedule": overlapErr.ScheduleID,
+		})
+	case errors.Is(err, store.ErrScheduleNotEditable):
+		utils.HandleValidationError(c,
+			&ValidationError{Field: "status", Message: err.Error()},
+			"schedule_status")
+	default:
+		handleTransitionError(c, err, operation)
+	}
+}
+
+// scheduleInput converts a schedule request to the fields the store persists
+func scheduleInput(req models.ScheduleRequest) store.ScheduleInput {
+	return store.ScheduleInput{
+		ClientID:    req.ClientID,
+		CaregiverID: req.CaregiverID,
+		ShiftStart:  req.ShiftStart,
+		ShiftEnd:    req.ShiftEnd,
+		ServiceCode: req.ServiceCode,
+		Tasks:       req.Tasks,
+	}
+}
+
+// CreateSchedule godoc
+// @Summary Create a schedule
+// @Description Schedule a shift for a client, optionally assigned to a caregiver, with its visit record and tasks
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param schedule body models.ScheduleRequest true "Schedule data"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 201 {object} models.ScheduleWithTasks
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 409 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /schedules [post]
+func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
+	var req models.ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err, "request_body")
+		return
+	}
+	if !h.validateScheduleRequest(c, req) {
 		return
 	}
 
-	if err != sql.ErrNoRows {
-		visit.ScheduleID = id
-		if startTime.Valid {
-			if t := parseTime(startTime.String); !t.IsZero() {
-				visit.StartTime = &t
-			}
-		}
-		if endTime.Valid {
-			if t := parseTime(endTime.String); !t.IsZero() {
-				visit.EndTime = &t
-			}
-		}
-		if startLat.Valid {
-			visit.StartLat = &startLat.Float64
-		}
-		if startLng.Valid {
-			visit.StartLng = &startLng.Float64
-		}
-		if endLat.Valid {
-			visit.EndLat = &endLat.Float64
-		}
-		if endLng.Valid {
-			visit.EndLng = &endLng.Float64
-		}
-		visit.CreatedAt = parseTime(visitCreatedAt)
-		visit.UpdatedAt = parseTime(visitUpdatedAt)
+	scheduleID, err := h.Schedules.CreateSchedule(scheduleInput(req), actorFromContext(c))
+	if err != nil {
+		handleScheduleWriteError(c, err, "create_schedule")
+		return
+	}
 
-		scheduleWithTasks.Visit = &visit
+	schedule, err := h.getScheduleDetails(scheduleID)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "get_schedule")
+		return
 	}
 
-	c.JSON(http.StatusOK, scheduleWithTasks)
+	utils.LogInfo("Schedule created", logrus.Fields{
+		"request_id":   c.GetString("request_id"),
+		"schedule_id":  schedule.ID,
+		"client_id":    schedule.ClientID,
+		"caregiver_id": schedule.CaregiverID,
+	})
+
+	utils.JSONCreated(c, schedule)
 }
 
-// GetStats godoc
-// @Summary Get dashboard statistics
-// @Description Get statistics for the dashboard including total, missed, upcoming, and completed schedules
-// @Tags stats
+// UpdateSchedule godoc
+// @Summary Update a schedule
+// @Description Reschedule or reassign an upcoming shift; a tasks list replaces the shift's tasks
+// @Tags schedules
 // @Accept json
 // @Produce json
-// @Success 200 {object} models.StatsResponse
-// @Failure 500 {object} map[string]string
-// @Router /stats [get]
-func GetStats(c *gin.Context) {
-	today := time.Now().Format("2006-01-02")
-	
-	var stats models.StatsResponse
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Param schedule body models.ScheduleRequest true "Schedule data"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 200 {object} models.ScheduleWithTasks
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 409 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /schedules/{id} [put]
+func (h *ScheduleHandler) UpdateSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "schedule_id")
+		return
+	}
 
-	// Total schedules
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM schedules").Scan(&stats.TotalSchedules)
+	var req models.ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err, "request_body")
+		return
+	}
+
+	current, err := h.Schedules.GetSchedule(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch total schedules"})
+		utils.HandleDatabaseError(c, err, "get_schedule_status")
+		return
+	}
+	if current.Status != models.StatusUpcoming {
+		handleScheduleWriteError(c, store.ErrScheduleNotEditable, "update_schedule")
+		return
+	}
+
+	if !h.validateScheduleRequest(c, req) {
+		return
+	}
+
+	if err := h.Schedules.UpdateSchedule(id, scheduleInput(req), actorFromContext(c)); err != nil {
+		handleScheduleWriteError(c, err, "update_schedule")
 		return
 	}
 
-	// Missed schedules
-	err = database.DB.QueryRow("SELECT COUNT(*) FROM schedules WHERE status = 'missed'").Scan(&stats.MissedSchedules)
+	schedule, err := h.getScheduleDetails(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch missed schedules"})
+		utils.HandleDatabaseError(c, err, "get_schedule")
 		return
 	}
 
-	// Upcoming today
-	err = database.DB.QueryRow(
-		"SELECT COUNT(*) FROM schedules WHERE DATE(shift_start) = ? AND status = 'upcoming'",
-		today).Scan(&stats.UpcomingToday)
+	utils.JSONSuccess(c, schedule)
+}
+
+// CancelSchedule godoc
+// @Summary Cancel a schedule
+// @Description Cancel an upcoming shift with a reason; the shift is kept for reporting
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Param request body models.CancelScheduleRequest true "Cancellation reason"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 200 {object} models.ScheduleWithTasks
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /schedules/{id}/cancel [post]
+func (h *ScheduleHandler) CancelSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch upcoming schedules"})
+		utils.HandleValidationError(c, err, "schedule_id")
 		return
 	}
 
-	// Completed today
-	err = database.DB.QueryRow(
-		"SELECT COUNT(*) FROM schedules WHERE DATE(shift_start) = ? AND status = 'completed'",
-		today).Scan(&stats.CompletedToday)
+	var req models.CancelScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err, "request_body")
+		return
+	}
+
+	if err := h.Schedules.CancelSchedule(id, req.Reason, actorFromContext(c)); err != nil {
+		handleScheduleWriteError(c, err, "cancel_schedule")
+		return
+	}
+
+	schedule, err := h.getScheduleDetails(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch completed schedules"})
+		utils.HandleDatabaseError(c, err, "get_schedule")
 		return
 	}
 
-	c.JSON(http.StatusOK, stats)
-} 
\ No newline at end of file
+	utils.LogInfo("Schedule cancelled", logrus.Fields{
+		"request_id":  c.GetString("request_id"),
+		"schedule_id": id,
+		"reason":      req.Reason,
+	})
+
+	utils.JSONSuccess(c, schedule)
+}
\ No newline at end of file
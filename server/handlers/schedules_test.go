@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"visit-tracker-api/middleware"
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeScheduleStore keeps schedules in memory; the methods a test does not need panic
+type fakeScheduleStore struct {
+	store.ScheduleStore
+	schedules map[int]models.Schedule
+}
+
+func (f *fakeScheduleStore) GetSchedule(id int) (models.Schedule, error) {
+	schedule, ok := f.schedules[id]
+	if !ok {
+		return schedule, sql.ErrNoRows
+	}
+	return schedule, nil
+}
+
+func (f *fakeScheduleStore) CreateSchedule(input store.ScheduleInput, actor store.StatusActor) (int, error) {
+	id := len(f.schedules) + 1
+	f.schedules[id] = models.Schedule{
+		ID:          id,
+		ClientID:    input.ClientID,
+		CaregiverID: input.CaregiverID,
+		ShiftStart:  input.ShiftStart,
+		ShiftEnd:    input.ShiftEnd,
+		Status:      models.StatusUpcoming,
+	}
+	return id, nil
+}
+
+// fakeVisitRecords is a schedule's tasks, visit and notes before the visit has started
+type fakeVisitRecords struct {
+	store.TaskStore
+	store.VisitStore
+	store.NoteStore
+}
+
+func (fakeVisitRecords) ListTasks(scheduleID int) ([]models.Task, error) {
+	return []models.Task{}, nil
+}
+
+func (fakeVisitRecords) GetVisit(scheduleID int) (models.Visit, error) {
+	return models.Visit{}, sql.ErrNoRows
+}
+
+func (fakeVisitRecords) ListVisitAdjustments(scheduleID int) ([]models.VisitAdjustment, error) {
+	return []models.VisitAdjustment{}, nil
+}
+
+func (fakeVisitRecords) ListVisitNotes(scheduleID int) ([]models.VisitNote, error) {
+	return []models.VisitNote{}, nil
+}
+
+// fakeClientStore keeps clients in memory and fails every read with err when set
+type fakeClientStore struct {
+	store.ClientStore
+	clients map[int]models.Client
+	err     error
+}
+
+func (f *fakeClientStore) GetClient(id int) (models.Client, error) {
+	client, ok := f.clients[id]
+	if f.err != nil {
+		return client, f.err
+	}
+	if !ok {
+		return client, sql.ErrNoRows
+	}
+	return client, nil
+}
+
+// fakeCaregiverStore keeps caregivers in memory
+type fakeCaregiverStore struct {
+	store.CaregiverStore
+	caregivers map[int]models.Caregiver
+}
+
+func (f *fakeCaregiverStore) GetCaregiver(id int) (models.Caregiver, error) {
+	caregiver, ok := f.caregivers[id]
+	if !ok {
+		return caregiver, sql.ErrNoRows
+	}
+	return caregiver, nil
+}
+
+// coordinatorLookup signs every token in as an active coordinator
+type coordinatorLookup struct{}
+
+func (coordinatorLookup) GetUser(id int) (models.User, error) {
+	return models.User{ID: id, Role: models.RoleCoordinator, Active: true}, nil
+}
+
+// scheduleFixture serves the schedule endpoints over fake stores holding an active and an
+// inactive client and caregiver, and schedule 1 for the active client
+type scheduleFixture struct {
+	router    *gin.Engine
+	token     string
+	schedules *fakeScheduleStore
+	clients   *fakeClientStore
+}
+
+func newScheduleFixture(t *testing.T) scheduleFixture {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	f := scheduleFixture{
+		schedules: &fakeScheduleStore{schedules: map[int]models.Schedule{
+			1: {ID: 1, ClientID: 1, Status: models.StatusUpcoming},
+		}},
+		clients: &fakeClientStore{clients: map[int]models.Client{
+			1: {ID: 1, Name: "Active Client", Active: true},
+			2: {ID: 2, Name: "Inactive Client"},
+		}},
+	}
+	caregivers := &fakeCaregiverStore{caregivers: map[int]models.Caregiver{
+		1: {ID: 1, Name: "Active Carer", Active: true},
+		2: {ID: 2, Name: "Inactive Carer"},
+	}}
+	records := fakeVisitRecords{}
+	h := NewScheduleHandler(f.schedules, records, records, records, f.clients, caregivers)
+
+	auth := middleware.AuthConfig{Secret: []byte("test"), TokenTTL: time.Hour}
+	token, _, err := middleware.GenerateToken(auth, models.User{ID: 1, Role: models.RoleCoordinator})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.token = token
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	f.router = gin.New()
+	f.router.Use(middleware.ErrorHandlerMiddleware(logger))
+	f.router.Use(middleware.Auth(auth, coordinatorLookup{}))
+	f.router.GET("/schedules/:id", h.GetScheduleByID)
+	f.router.POST("/schedules", h.CreateSchedule)
+	return f
+}
+
+func (f scheduleFixture) serve(method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+f.token)
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCreateScheduleValidatesAssignment(t *testing.T) {
+	tests := []struct {
+		name        string
+		clientID    int
+		caregiverID string
+		wantStatus  int
+		wantError   string
+	}{
+		{name: "active client and caregiver", clientID: 1, caregiverID: "1", wantStatus: http.StatusCreated},
+		{name: "unassigned", clientID: 1, caregiverID: "null", wantStatus: http.StatusCreated},
+		{name: "unknown client", clientID: 9, caregiverID: "1", wantStatus: http.StatusBadRequest, wantError: "Client not found"},
+		{name: "inactive client", clientID: 2, caregiverID: "1", wantStatus: http.StatusBadRequest, wantError: "Client is inactive"},
+		{name: "unknown caregiver", clientID: 1, caregiverID: "9", wantStatus: http.StatusBadRequest, wantError: "Caregiver not found"},
+		{name: "inactive caregiver", clientID: 1, caregiverID: "2", wantStatus: http.StatusBadRequest, wantError: "Caregiver is inactive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newScheduleFixture(t)
+			body := `{"client_id": ` + strconv.Itoa(tt.clientID) + `, "caregiver_id": ` + tt.caregiverID + `,
+				"shift_start": "2026-03-02T09:00:00Z", "shift_end": "2026-03-02T11:00:00Z"}`
+
+			rec := f.serve(http.MethodPost, "/schedules", body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantError != "" && !strings.Contains(rec.Body.String(), tt.wantError) {
+				t.Errorf("body = %s, want %q", rec.Body.String(), tt.wantError)
+			}
+
+			wantSchedules := 1
+			if tt.wantStatus == http.StatusCreated {
+				wantSchedules = 2
+			}
+			if len(f.schedules.schedules) != wantSchedules {
+				t.Errorf("%d schedules stored, want %d", len(f.schedules.schedules), wantSchedules)
+			}
+		})
+	}
+}
+
+func TestGetScheduleByIDIncludesClient(t *testing.T) {
+	f := newScheduleFixture(t)
+
+	rec := f.serve(http.MethodGet, "/schedules/1", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var details models.ScheduleWithTasks
+	if err := json.Unmarshal(rec.Body.Bytes(), &details); err != nil {
+		t.Fatal(err)
+	}
+	if details.Client == nil || details.Client.Name != "Active Client" {
+		t.Errorf("client = %+v, want Active Client", details.Client)
+	}
+
+	if rec := f.serve(http.MethodGet, "/schedules/9", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown schedule: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	f.clients.err = errors.New("connection reset")
+	if rec := f.serve(http.MethodGet, "/schedules/1", ""); rec.Code != http.StatusInternalServerError {
+		t.Errorf("client store failing: status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
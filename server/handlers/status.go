@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"visit-tracker-api/middleware"
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+	"visit-tracker-api/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// actorFromContext builds the status actor for the authenticated request
+func actorFromContext(c *gin.Context) store.StatusActor {
+	actor := store.StatusActor{
+		IPAddress: c.ClientIP(),
+		RequestID: c.GetString("request_id"),
+	}
+	if claims, ok := middleware.CurrentClaims(c); ok {
+		userID := claims.UserID
+		actor.UserID = &userID
+		actor.Role = claims.Role
+	}
+	return actor
+}
+
+// handleTransitionError reports a failed status transition
+func handleTransitionError(c *gin.Context, err error, operation string) {
+	var transitionErr *models.TransitionError
+	if errors.As(err, &transitionErr) {
+		utils.HandleValidationError(c, transitionErr, "visit_status")
+		return
+	}
+	utils.HandleDatabaseError(c, err, operation)
+}
+
+// GetScheduleStatusHistory godoc
+// @Summary Get schedule status history
+// @Description Get every status change of a schedule with who made it, when and from where
+// @Tags schedules
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Schedule ID"
+// @Success 200 {array} models.ScheduleStatusChange
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 403 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /schedules/{id}/history [get]
+func (h *ScheduleHandler) GetScheduleStatusHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "schedule_id")
+		return
+	}
+
+	if !authorizeScheduleAccess(c, h.Schedules, id) {
+		return
+	}
+
+	history, err := h.Schedules.GetStatusHistory(id)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "get_status_history")
+		return
+	}
+
+	utils.JSONSuccess(c, history)
+}
@@ -0,0 +1,500 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"visit-tracker-api/config"
+	"visit-tracker-api/middleware"
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+	"visit-tracker-api/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// maxSyncClockSkew is how far ahead of the server's clock a device timestamp may be
+const maxSyncClockSkew = 5 * time.Minute
+
+var syncConfig = config.Default().Sync
+
+// SetSyncConfig replaces the bounds on the times synced events may carry
+func SetSyncConfig(cfg config.SyncConfig) {
+	syncConfig = cfg
+}
+
+// SyncHandler applies the events caregivers' devices queue while offline
+type SyncHandler struct {
+	Schedules  store.ScheduleStore
+	Visits     store.VisitStore
+	Tasks      store.TaskStore
+	Activities store.ActivityStore
+	Sync       store.SyncStore
+}
+
+// NewSyncHandler returns a sync handler backed by the given stores
+func NewSyncHandler(schedules store.ScheduleStore, visits store.VisitStore, tasks store.TaskStore,
+	activities store.ActivityStore, sync store.SyncStore) *SyncHandler {
+	return &SyncHandler{Schedules: schedules, Visits: visits, Tasks: tasks, Activities: activities, Sync: sync}
+}
+
+// SyncEvents godoc
+// @Summary Sync offline events
+// @Description Apply clock-ins, clock-outs, task updates and activity updates queued on a device, using the times and locations the device captured. Events are applied in occurred_at order, and each carries an idempotency key so a retried batch is not applied twice. Every event gets its own result, in the order the events were sent.
+// @Tags sync
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param sync body models.SyncRequest true "Queued device events"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 200 {object} models.SyncResponse
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 401 {object} middleware.ErrorResponse
+// @Router /sync [post]
+func (h *SyncHandler) SyncEvents(c *gin.Context) {
+	claims, ok := middleware.CurrentClaims(c)
+	if !ok {
+		c.Error(middleware.ErrUnauthorized)
+		return
+	}
+
+	var req models.SyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err, "request_body")
+		return
+	}
+
+	// Apply events in the order they happened on the device, whatever order they were queued in
+	order := make([]int, len(req.Events))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return req.Events[order[a]].OccurredAt.Before(req.Events[order[b]].OccurredAt)
+	})
+
+	actor := actorFromContext(c)
+	response := models.SyncResponse{Results: make([]models.SyncEventResult, len(req.Events))}
+	for _, i := range order {
+		result := h.syncEvent(claims, actor, req.DeviceID, req.Events[i])
+		response.Results[i] = result
+
+		switch result.Status {
+		case models.SyncApplied:
+			response.Applied++
+		case models.SyncDuplicate:
+			response.Duplicates++
+		case models.SyncConflict:
+			response.Conflicts++
+		case models.SyncRejected:
+			response.Rejected++
+		default:
+			response.Failed++
+		}
+	}
+
+	utils.LogInfo("Offline events synced", logrus.Fields{
+		"request_id": c.GetString("request_id"),
+		"user_id":    claims.UserID,
+		"device_id":  req.DeviceID,
+		"events":     len(req.Events),
+		"applied":    response.Applied,
+		"duplicates": response.Duplicates,
+		"conflicts":  response.Conflicts,
+		"rejected":   response.Rejected,
+		"failed":     response.Failed,
+	})
+
+	utils.JSONSuccess(c, response)
+}
+
+// syncEvent applies one event unless its idempotency key has already been synced, and
+// records the outcome against the key
+func (h *SyncHandler) syncEvent(claims *middleware.Claims, actor store.StatusActor, deviceID string, event models.SyncEvent) models.SyncEventResult {
+	fields := logrus.Fields{
+		"request_id":      actor.RequestID,
+		"user_id":         claims.UserID,
+		"idempotency_key": event.IdempotencyKey,
+		"type":            event.Type,
+	}
+
+	var scheduleID *int
+	if event.ScheduleID > 0 {
+		scheduleID = &event.ScheduleID
+	}
+	record, claimed, err := h.Sync.ClaimSyncEvent(store.SyncEventRecord{
+		UserID:         claims.UserID,
+		IdempotencyKey: event.IdempotencyKey,
+		DeviceID:       deviceID,
+		EventType:      event.Type,
+		ScheduleID:     scheduleID,
+		OccurredAt:     event.OccurredAt,
+	})
+	if err != nil {
+		utils.LogError(err, "Failed to record sync event", fields)
+		return syncResult(event, models.SyncFailed, "INTERNAL_ERROR", "Event could not be recorded; sync it again")
+	}
+	if !claimed {
+		return replaySyncEvent(event, record)
+	}
+
+	result := h.applySyncEvent(claims, actor, event)
+	if result.Status == models.SyncFailed {
+		err = h.Sync.ReleaseSyncEvent(record.ID)
+	} else {
+		err = h.Sync.CompleteSyncEvent(record.ID, result)
+	}
+	if err != nil {
+		utils.LogError(err, "Failed to store sync event result", fields)
+	}
+	return result
+}
+
+// replaySyncEvent reports an event whose idempotency key has already been synced
+func replaySyncEvent(event models.SyncEvent, record store.SyncEventRecord) models.SyncEventResult {
+	if record.EventType != event.Type {
+		return syncResult(event, models.SyncRejected, "IDEMPOTENCY_KEY_REUSED",
+			"Idempotency key was already used for a "+record.EventType+" event")
+	}
+	if record.Result == nil {
+		result := syncResult(event, models.SyncDuplicate, "SYNC_IN_PROGRESS", "Event is still being processed")
+		result.OriginalStatus = record.Status
+		return result
+	}
+
+	result := *record.Result
+	result.OriginalStatus = result.Status
+	result.Status = models.SyncDuplicate
+	return result
+}
+
+// applySyncEvent applies an event that has not been synced before
+func (h *SyncHandler) applySyncEvent(claims *middleware.Claims, actor store.StatusActor, event models.SyncEvent) models.SyncEventResult {
+	if event.OccurredAt.After(time.Now().Add(maxSyncClockSkew)) {
+		return syncResult(event, models.SyncRejected, "INVALID_TIMESTAMP", "occurred_at is in the future")
+	}
+	if event.OccurredAt.Before(time.Now().Add(-syncConfig.MaxEventAge)) {
+		return syncResult(event, models.SyncRejected, "INVALID_TIMESTAMP",
+			fmt.Sprintf("occurred_at is more than %s ago, longer than a device may stay offline", syncConfig.MaxEventAge))
+	}
+
+	switch event.Type {
+	case models.SyncStartVisit:
+		return h.syncStartVisit(claims, actor, event)
+	case models.SyncEndVisit:
+		return h.syncEndVisit(claims, actor, event)
+	case models.SyncTaskUpdate:
+		return h.syncTaskUpdate(claims, actor, event)
+	default:
+		return h.syncActivityUpdate(claims, actor, event)
+	}
+}
+
+// syncStartVisit clocks in at the time and place the device recorded
+func (h *SyncHandler) syncStartVisit(claims *middleware.Claims, actor store.StatusActor, event models.SyncEvent) models.SyncEventResult {
+	schedule, failure := h.syncSchedule(claims, event, event.ScheduleID)
+	if failure != nil {
+		return *failure
+	}
+	if failure := checkSyncLocation(event); failure != nil {
+		return *failure
+	}
+	if failure := checkSyncShiftWindow(event, schedule); failure != nil {
+		return *failure
+	}
+
+	// A schedule marked missed while the device was offline can still be started by a
+	// clock-in recorded before it was marked; the store checks the times
+	if !models.IsStartable(schedule.Status) && !models.CanReconcile(schedule.Status, models.StatusInProgress) {
+		return syncStatusConflict(event, schedule, models.StatusInProgress)
+	}
+
+	geofence, allowed := evaluateGeofence(schedule.Latitude, schedule.Longitude, *event.Latitude, *event.Longitude, event.OverrideReason, actor.Role)
+	if !allowed {
+		result := syncResult(event, models.SyncRejected, "GEOFENCE_VIOLATION", geofenceViolationMessage)
+		result.Data = geofence
+		return result
+	}
+
+	err := h.Visits.StartVisit(schedule.ID, store.VisitCheckpoint{
+		Time:           event.OccurredAt,
+		Latitude:       *event.Latitude,
+		Longitude:      *event.Longitude,
+		Geofence:       geofence,
+		OverrideReason: event.OverrideReason,
+		Offline:        true,
+	}, actor)
+	if err != nil {
+		return syncStoreError(event, err)
+	}
+
+	result := syncResult(event, models.SyncApplied, "", "Visit started")
+	result.Data = gin.H{
+		"schedule_id": schedule.ID,
+		"start_time":  event.OccurredAt,
+		"geofence":    geofence,
+	}
+	return result
+}
+
+// syncEndVisit clocks out at the time and place the device recorded
+func (h *SyncHandler) syncEndVisit(claims *middleware.Claims, actor store.StatusActor, event models.SyncEvent) models.SyncEventResult {
+	schedule, failure := h.syncSchedule(claims, event, event.ScheduleID)
+	if failure != nil {
+		return *failure
+	}
+	if failure := checkSyncLocation(event); failure != nil {
+		return *failure
+	}
+	if failure := checkSyncShiftWindow(event, schedule); failure != nil {
+		return *failure
+	}
+
+	if !models.CanTransition(schedule.Status, models.StatusCompleted) {
+		return syncStatusConflict(event, schedule, models.StatusCompleted)
+	}
+
+	visit, err := h.Visits.GetVisit(schedule.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return syncStoreError(event, err)
+	}
+	if err != nil || visit.StartTime == nil {
+		return syncResult(event, models.SyncConflict, "STATUS_CONFLICT", "Visit not properly started")
+	}
+	if event.OccurredAt.Before(*visit.StartTime) {
+		result := syncResult(event, models.SyncConflict, "END_BEFORE_START", "occurred_at is before the visit started")
+		result.Data = gin.H{"start_time": visit.StartTime}
+		return result
+	}
+
+	geofence, allowed := evaluateGeofence(schedule.Latitude, schedule.Longitude, *event.Latitude, *event.Longitude, event.OverrideReason, actor.Role)
+	if !allowed {
+		result := syncResult(event, models.SyncRejected, "GEOFENCE_VIOLATION", geofenceViolationMessage)
+		result.Data = geofence
+		return result
+	}
+
+	closedTasks, err := h.Visits.EndVisit(schedule.ID, store.VisitCheckpoint{
+		Time:           event.OccurredAt,
+		Latitude:       *event.Latitude,
+		Longitude:      *event.Longitude,
+		Geofence:       geofence,
+		OverrideReason: event.OverrideReason,
+		CloseTasks:     closesPendingTasks(),
+	}, actor)
+	if err != nil {
+		var pendingErr *store.PendingTasksError
+		if errors.As(err, &pendingErr) {
+			result := syncResult(event, models.SyncRejected, "TASKS_PENDING", pendingErr.Error())
+			result.Data = pendingTasksDetails(pendingErr.Tasks)
+			return result
+		}
+		return syncStoreError(event, err)
+	}
+
+	result := syncResult(event, models.SyncApplied, "", "Visit ended")
+	result.Data = gin.H{
+		"schedule_id":      schedule.ID,
+		"start_time":       visit.StartTime,
+		"end_time":         event.OccurredAt,
+		"duration_minutes": int(event.OccurredAt.Sub(*visit.StartTime).Minutes()),
+		"geofence":         geofence,
+		"closed_tasks":     closedTasks,
+	}
+	return result
+}
+
+// syncTaskUpdate records a task's outcome. Tasks may be synced after the visit ended as
+// long as they were updated while it was in progress.
+func (h *SyncHandler) syncTaskUpdate(claims *middleware.Claims, actor store.StatusActor, event models.SyncEvent) models.SyncEventResult {
+	switch {
+	case event.TaskID <= 0:
+		return syncResult(event, models.SyncRejected, "VALIDATION_ERROR", "task_id is required")
+	case event.Status != "completed" && event.Status != "not_completed":
+		return syncResult(event, models.SyncRejected, "VALIDATION_ERROR", "status must be completed or not_completed")
+	case event.Status == "not_completed" && event.Reason == "":
+		return syncResult(event, models.SyncRejected, "VALIDATION_ERROR", "Reason is required when marking task as not completed")
+	}
+
+	task, err := h.Tasks.GetTask(event.TaskID)
+	if err != nil {
+		return syncLookupError(event, err, "Task not found")
+	}
+	schedule, failure := h.syncSchedule(claims, event, task.ScheduleID)
+	if failure != nil {
+		return *failure
+	}
+	if failure := h.checkDuringVisit(event, schedule); failure != nil {
+		return *failure
+	}
+
+	// A task closed automatically at clock-out still takes the outcome the device recorded
+	autoClosed := task.Status == "not_completed" && task.Reason == store.AutoClosedTaskReason
+	if task.Status != "pending" && !autoClosed && task.UpdatedAt.After(event.OccurredAt) {
+		result := syncResult(event, models.SyncConflict, "STALE_UPDATE", "Task was updated after this event happened")
+		result.Data = task
+		return result
+	}
+
+	updated, err := h.Tasks.UpdateTask(task.ID, event.Status, event.Reason, actor)
+	if err != nil {
+		return syncStoreError(event, err)
+	}
+	result := syncResult(event, models.SyncApplied, "", "Task updated")
+	result.Data = updated
+	return result
+}
+
+// syncActivityUpdate records whether an activity was resolved
+func (h *SyncHandler) syncActivityUpdate(claims *middleware.Claims, actor store.StatusActor, event models.SyncEvent) models.SyncEventResult {
+	switch {
+	case event.ActivityID <= 0:
+		return syncResult(event, models.SyncRejected, "VALIDATION_ERROR", "activity_id is required")
+	case event.IsResolved == nil:
+		return syncResult(event, models.SyncRejected, "VALIDATION_ERROR", "is_resolved is required")
+	case !*event.IsResolved && event.Reason == "":
+		return syncResult(event, models.SyncRejected, "VALIDATION_ERROR", "Reason is required when activity is not resolved")
+	}
+
+	activity, err := h.Activities.GetActivity(event.ActivityID)
+	if err != nil {
+		return syncLookupError(event, err, "Activity not found")
+	}
+	if _, failure := h.syncSchedule(claims, event, activity.ScheduleID); failure != nil {
+		return *failure
+	}
+
+	if activity.UpdatedAt.After(activity.CreatedAt) && activity.UpdatedAt.After(event.OccurredAt) {
+		result := syncResult(event, models.SyncConflict, "STALE_UPDATE", "Activity was updated after this event happened")
+		result.Data = activity
+		return result
+	}
+
+	updated, err := h.Activities.UpdateActivity(activity.ID, models.UpdateActivityRequest{
+		IsResolved: *event.IsResolved,
+		Reason:     event.Reason,
+	}, actor)
+	if err != nil {
+		return syncStoreError(event, err)
+	}
+	result := syncResult(event, models.SyncApplied, "", "Activity updated")
+	result.Data = updated
+	return result
+}
+
+// syncSchedule loads the schedule an event applies to and checks the user may work on it
+func (h *SyncHandler) syncSchedule(claims *middleware.Claims, event models.SyncEvent, scheduleID int) (models.Schedule, *models.SyncEventResult) {
+	if scheduleID <= 0 {
+		result := syncResult(event, models.SyncRejected, "VALIDATION_ERROR", "schedule_id is required")
+		return models.Schedule{}, &result
+	}
+
+	schedule, err := h.Schedules.GetSchedule(scheduleID)
+	if err != nil {
+		result := syncLookupError(event, err, "Schedule not found")
+		return schedule, &result
+	}
+	if !canAccessSchedule(claims, schedule) {
+		result := syncResult(event, models.SyncRejected, "FORBIDDEN", "Schedule is not assigned to you")
+		return schedule, &result
+	}
+	return schedule, nil
+}
+
+// checkDuringVisit checks that an event happened while the schedule's visit was in
+// progress: either it still is, or the event falls between clock-in and clock-out
+func (h *SyncHandler) checkDuringVisit(event models.SyncEvent, schedule models.Schedule) *models.SyncEventResult {
+	if schedule.Status == models.StatusInProgress {
+		return nil
+	}
+	if schedule.Status == models.StatusCompleted {
+		visit, err := h.Visits.GetVisit(schedule.ID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			result := syncStoreError(event, err)
+			return &result
+		}
+		if err == nil && visit.StartTime != nil && visit.EndTime != nil &&
+			!event.OccurredAt.Before(*visit.StartTime) && !event.OccurredAt.After(*visit.EndTime) {
+			return nil
+		}
+	}
+
+	result := syncResult(event, models.SyncConflict, "STATUS_CONFLICT", "Event did not happen during the visit")
+	result.Data = gin.H{"schedule_id": schedule.ID, "status": schedule.Status}
+	return &result
+}
+
+// checkSyncLocation validates the location a clock-in or clock-out was recorded at
+func checkSyncLocation(event models.SyncEvent) *models.SyncEventResult {
+	var message string
+	switch {
+	case event.Latitude == nil || event.Longitude == nil:
+		message = "latitude and longitude are required"
+	case *event.Latitude < -90 || *event.Latitude > 90 || *event.Longitude < -180 || *event.Longitude > 180:
+		message = "Invalid latitude or longitude"
+	default:
+		return nil
+	}
+	result := syncResult(event, models.SyncRejected, "VALIDATION_ERROR", message)
+	return &result
+}
+
+// checkSyncShiftWindow rejects a clock-in or clock-out recorded too long before the shift
+// starts or after it ends, so a backdated event cannot invent visit times
+func checkSyncShiftWindow(event models.SyncEvent, schedule models.Schedule) *models.SyncEventResult {
+	opens := schedule.ShiftStart.Add(-syncConfig.EarlyClockIn)
+	closes := schedule.ShiftEnd.Add(syncConfig.LateClockOut)
+	if !event.OccurredAt.Before(opens) && !event.OccurredAt.After(closes) {
+		return nil
+	}
+	result := syncResult(event, models.SyncRejected, "OUTSIDE_SHIFT_WINDOW",
+		"occurred_at is outside the shift's clock-in/out window")
+	result.Data = gin.H{"schedule_id": schedule.ID, "window_start": opens, "window_end": closes}
+	return &result
+}
+
+// syncStatusConflict reports an event the schedule's current status does not allow
+func syncStatusConflict(event models.SyncEvent, schedule models.Schedule, to string) models.SyncEventResult {
+	transitionErr := &models.TransitionError{From: schedule.Status, To: to}
+	result := syncResult(event, models.SyncConflict, "STATUS_CONFLICT", transitionErr.Error())
+	result.Data = gin.H{"schedule_id": schedule.ID, "status": schedule.Status}
+	return result
+}
+
+// syncLookupError reports a record an event refers to that could not be loaded
+func syncLookupError(event models.SyncEvent, err error, notFound string) models.SyncEventResult {
+	if errors.Is(err, sql.ErrNoRows) {
+		return syncResult(event, models.SyncRejected, "NOT_FOUND", notFound)
+	}
+	return syncStoreError(event, err)
+}
+
+// syncStoreError reports an event that failed to apply: a status change another request
+// made first is a conflict, anything else a server error worth retrying
+func syncStoreError(event models.SyncEvent, err error) models.SyncEventResult {
+	var transitionErr *models.TransitionError
+	if errors.As(err, &transitionErr) {
+		return syncResult(event, models.SyncConflict, "STATUS_CONFLICT", transitionErr.Error())
+	}
+
+	utils.LogError(err, "Failed to apply sync event", logrus.Fields{
+		"idempotency_key": event.IdempotencyKey,
+		"type":            event.Type,
+	})
+	return syncResult(event, models.SyncFailed, "INTERNAL_ERROR", "Event could not be applied; sync it again")
+}
+
+// syncResult builds the result reported for an event
+func syncResult(event models.SyncEvent, status, code, message string) models.SyncEventResult {
+	return models.SyncEventResult{
+		IdempotencyKey: event.IdempotencyKey,
+		Type:           event.Type,
+		Status:         status,
+		Code:           code,
+		Message:        message,
+	}
+}
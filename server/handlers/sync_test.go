@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"visit-tracker-api/config"
+	"visit-tracker-api/database"
+	"visit-tracker-api/middleware"
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// syncFixture is a caregiver with one schedule at a client's home, on a fresh database
+type syncFixture struct {
+	store      *store.SQLStore
+	handler    *SyncHandler
+	claims     *middleware.Claims
+	actor      store.StatusActor
+	scheduleID int
+}
+
+const (
+	homeLatitude  = 40.7282
+	homeLongitude = -73.9942
+)
+
+func newSyncFixture(t *testing.T, shiftStart time.Time) syncFixture {
+	t.Helper()
+
+	database.Open(config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "visits.db")})
+	db := database.DB
+	t.Cleanup(func() { db.Close() })
+	if _, err := database.MigrateUp(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	for _, fixture := range []string{
+		"INSERT INTO clients (id, name, latitude, longitude) VALUES (1, 'Client', 40.7282, -73.9942)",
+		"INSERT INTO caregivers (id, name, email) VALUES (1, 'Carer', 'carer@example.com')",
+		"INSERT INTO users (id, email, password_hash, role, caregiver_id) VALUES (1, 'carer@example.com', '-', 'caregiver', 1)",
+	} {
+		if _, err := db.Exec(fixture); err != nil {
+			t.Fatalf("%s: %v", fixture, err)
+		}
+	}
+
+	sqlStore := store.NewSQLStore(db)
+	caregiverID, userID := 1, 1
+	scheduleID, err := sqlStore.CreateSchedule(store.ScheduleInput{
+		ClientID:    1,
+		CaregiverID: &caregiverID,
+		ShiftStart:  shiftStart,
+		ShiftEnd:    shiftStart.Add(2 * time.Hour),
+		Tasks:       []string{"Medication"},
+	}, store.SystemActor)
+	if err != nil {
+		t.Fatalf("create schedule: %v", err)
+	}
+
+	return syncFixture{
+		store:      sqlStore,
+		handler:    NewSyncHandler(sqlStore, sqlStore, sqlStore, sqlStore, sqlStore),
+		claims:     &middleware.Claims{UserID: userID, Role: models.RoleCaregiver, CaregiverID: &caregiverID},
+		actor:      store.StatusActor{UserID: &userID, Role: models.RoleCaregiver},
+		scheduleID: scheduleID,
+	}
+}
+
+// serve sends a request as the fixture's caregiver to handler, registered on route behind
+// the auth and error middleware
+func (f syncFixture) serve(t *testing.T, route string, handler gin.HandlerFunc, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	auth := middleware.AuthConfig{Secret: []byte("test"), TokenTTL: time.Hour}
+	token, _, err := middleware.GenerateToken(auth, models.User{ID: f.claims.UserID, Role: f.claims.Role})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	router := gin.New()
+	router.Use(middleware.ErrorHandlerMiddleware(logger))
+	router.Use(middleware.Auth(auth, f.store))
+	router.Handle(method, route, handler)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// visitEvent is a clock-in or clock-out at the client's home
+func visitEvent(key, eventType string, scheduleID int, at time.Time) models.SyncEvent {
+	latitude, longitude := homeLatitude, homeLongitude
+	return models.SyncEvent{
+		IdempotencyKey: key,
+		Type:           eventType,
+		OccurredAt:     at,
+		ScheduleID:     scheduleID,
+		Latitude:       &latitude,
+		Longitude:      &longitude,
+	}
+}
+
+func TestSyncConflicts(t *testing.T) {
+	shiftStart := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name       string
+		setup      func(t *testing.T, f syncFixture) // changes made online before the batch syncs
+		events     func(f syncFixture) []models.SyncEvent
+		wantStatus string // of the last event's result
+		wantCode   string
+		wantOrigin string // original status of a duplicate
+		wantState  string // the schedule's status afterwards
+	}{
+		{
+			name: "clock-in on time",
+			events: func(f syncFixture) []models.SyncEvent {
+				return []models.SyncEvent{visitEvent("in", models.SyncStartVisit, f.scheduleID, shiftStart)}
+			},
+			wantStatus: models.SyncApplied,
+			wantState:  models.StatusInProgress,
+		},
+		{
+			name: "clock-in recorded before the schedule was marked missed",
+			setup: func(t *testing.T, f syncFixture) {
+				if _, err := MarkMissedVisits(f.store, time.Now()); err != nil {
+					t.Fatal(err)
+				}
+			},
+			events: func(f syncFixture) []models.SyncEvent {
+				return []models.SyncEvent{visitEvent("in", models.SyncStartVisit, f.scheduleID, shiftStart.Add(5*time.Minute))}
+			},
+			wantStatus: models.SyncApplied,
+			wantState:  models.StatusInProgress,
+		},
+		{
+			name: "clock-in recorded after the schedule was marked missed",
+			setup: func(t *testing.T, f syncFixture) {
+				if _, err := MarkMissedVisits(f.store, time.Now()); err != nil {
+					t.Fatal(err)
+				}
+			},
+			events: func(f syncFixture) []models.SyncEvent {
+				return []models.SyncEvent{visitEvent("in", models.SyncStartVisit, f.scheduleID, time.Now().Add(2*time.Minute))}
+			},
+			wantStatus: models.SyncConflict,
+			wantCode:   "STATUS_CONFLICT",
+			wantState:  models.StatusMissed,
+		},
+		{
+			name: "clock-in backdated before the shift against a missed schedule",
+			setup: func(t *testing.T, f syncFixture) {
+				if _, err := MarkMissedVisits(f.store, time.Now()); err != nil {
+					t.Fatal(err)
+				}
+			},
+			events: func(f syncFixture) []models.SyncEvent {
+				return []models.SyncEvent{visitEvent("in", models.SyncStartVisit, f.scheduleID, shiftStart.Add(-3*time.Hour))}
+			},
+			wantStatus: models.SyncRejected,
+			wantCode:   "OUTSIDE_SHIFT_WINDOW",
+			wantState:  models.StatusMissed,
+		},
+		{
+			name: "clock-in recorded long before the shift",
+			events: func(f syncFixture) []models.SyncEvent {
+				return []models.SyncEvent{visitEvent("in", models.SyncStartVisit, f.scheduleID, shiftStart.Add(-2*time.Hour))}
+			},
+			wantStatus: models.SyncRejected,
+			wantCode:   "OUTSIDE_SHIFT_WINDOW",
+			wantState:  models.StatusUpcoming,
+		},
+		{
+			name: "event held longer than a device may stay offline",
+			events: func(f syncFixture) []models.SyncEvent {
+				return []models.SyncEvent{visitEvent("in", models.SyncStartVisit, f.scheduleID, time.Now().Add(-73*time.Hour))}
+			},
+			wantStatus: models.SyncRejected,
+			wantCode:   "INVALID_TIMESTAMP",
+			wantState:  models.StatusUpcoming,
+		},
+		{
+			name: "clock-in on a cancelled schedule",
+			setup: func(t *testing.T, f syncFixture) {
+				if err := f.store.CancelSchedule(f.scheduleID, "Client in hospital", store.SystemActor); err != nil {
+					t.Fatal(err)
+				}
+			},
+			events: func(f syncFixture) []models.SyncEvent {
+				return []models.SyncEvent{visitEvent("in", models.SyncStartVisit, f.scheduleID, shiftStart)}
+			},
+			wantStatus: models.SyncConflict,
+			wantCode:   "STATUS_CONFLICT",
+			wantState:  models.StatusCancelled,
+		},
+		{
+			name: "clock-out before the clock-in",
+			setup: func(t *testing.T, f syncFixture) {
+				checkpoint := store.VisitCheckpoint{Time: shiftStart.Add(10 * time.Minute), Latitude: homeLatitude, Longitude: homeLongitude}
+				if err := f.store.StartVisit(f.scheduleID, checkpoint, f.actor); err != nil {
+					t.Fatal(err)
+				}
+			},
+			events: func(f syncFixture) []models.SyncEvent {
+				return []models.SyncEvent{visitEvent("out", models.SyncEndVisit, f.scheduleID, shiftStart)}
+			},
+			wantStatus: models.SyncConflict,
+			wantCode:   "END_BEFORE_START",
+			wantState:  models.StatusInProgress,
+		},
+		{
+			name: "task updated online after the device updated it",
+			setup: func(t *testing.T, f syncFixture) {
+				checkpoint := store.VisitCheckpoint{Time: shiftStart, Latitude: homeLatitude, Longitude: homeLongitude}
+				if err := f.store.StartVisit(f.scheduleID, checkpoint, f.actor); err != nil {
+					t.Fatal(err)
+				}
+				tasks, err := f.store.ListTasks(f.scheduleID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := f.store.UpdateTask(tasks[0].ID, "completed", "", f.actor); err != nil {
+					t.Fatal(err)
+				}
+			},
+			events: func(f syncFixture) []models.SyncEvent {
+				tasks, _ := f.store.ListTasks(f.scheduleID)
+				return []models.SyncEvent{{
+					IdempotencyKey: "task",
+					Type:           models.SyncTaskUpdate,
+					OccurredAt:     shiftStart.Add(time.Minute),
+					TaskID:         tasks[0].ID,
+					Status:         "not_completed",
+					Reason:         "Client declined",
+				}}
+			},
+			wantStatus: models.SyncConflict,
+			wantCode:   "STALE_UPDATE",
+			wantState:  models.StatusInProgress,
+		},
+		{
+			name: "retried event",
+			events: func(f syncFixture) []models.SyncEvent {
+				in := visitEvent("in", models.SyncStartVisit, f.scheduleID, shiftStart)
+				return []models.SyncEvent{in, in}
+			},
+			wantStatus: models.SyncDuplicate,
+			wantOrigin: models.SyncApplied,
+			wantState:  models.StatusInProgress,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newSyncFixture(t, shiftStart)
+			if tt.setup != nil {
+				tt.setup(t, f)
+			}
+
+			var result models.SyncEventResult
+			for _, event := range tt.events(f) {
+				result = f.handler.syncEvent(f.claims, f.actor, "device-1", event)
+			}
+
+			if result.Status != tt.wantStatus || result.Code != tt.wantCode {
+				t.Errorf("result = %s %s (%s), want %s %s", result.Status, result.Code, result.Message, tt.wantStatus, tt.wantCode)
+			}
+			if result.OriginalStatus != tt.wantOrigin {
+				t.Errorf("original status = %q, want %q", result.OriginalStatus, tt.wantOrigin)
+			}
+			schedule, err := f.store.GetSchedule(f.scheduleID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if schedule.Status != tt.wantState {
+				t.Errorf("schedule status = %q, want %q", schedule.Status, tt.wantState)
+			}
+		})
+	}
+}
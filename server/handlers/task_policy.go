@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+
+	"visit-tracker-api/config"
+	"visit-tracker-api/middleware"
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+	"visit-tracker-api/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Task completion policies for ending a visit while tasks are still pending
+const (
+	TaskPolicyReject    = config.TaskPolicyReject    // refuse to end the visit until every task is resolved
+	TaskPolicyAutoClose = config.TaskPolicyAutoClose // end the visit and mark the pending tasks not_completed
+)
+
+var taskCompletionPolicy = config.Default().Tasks.CompletionPolicy
+
+// SetTaskCompletionPolicy replaces the policy applied when a visit is ended
+func SetTaskCompletionPolicy(policy string) {
+	taskCompletionPolicy = policy
+}
+
+// closesPendingTasks reports whether ending a visit marks pending tasks not_completed
+// rather than refusing
+func closesPendingTasks() bool {
+	return taskCompletionPolicy == TaskPolicyAutoClose
+}
+
+// pendingTasksDetails lists the tasks that kept a visit from ending
+func pendingTasksDetails(tasks []models.Task) gin.H {
+	return gin.H{"pending_tasks": tasks}
+}
+
+// handlePendingTasks reports a clock-out refused because tasks are pending
+func handlePendingTasks(c *gin.Context, scheduleID int, pending *store.PendingTasksError) {
+	utils.LogWarn("Visit ended with pending tasks", logrus.Fields{
+		"request_id":    c.GetString("request_id"),
+		"schedule_id":   scheduleID,
+		"pending_tasks": len(pending.Tasks),
+	})
+
+	c.Error(&middleware.APIError{
+		Code:       "TASKS_PENDING",
+		Message:    pending.Error(),
+		Details:    pendingTasksDetails(pending.Tasks),
+		StatusCode: http.StatusUnprocessableEntity,
+	})
+}
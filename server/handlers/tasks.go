@@ -5,14 +5,25 @@ import (
 	"net/http"
 	"strconv"
 
-	"visit-tracker-api/database"
 	"visit-tracker-api/models"
+	"visit-tracker-api/store"
 
 	"github.com/gin-gonic/gin"
 )
 
+// TaskHandler serves the care task endpoints
+type TaskHandler struct {
+	Schedules store.ScheduleStore
+	Tasks     store.TaskStore
+}
+
+// NewTaskHandler returns a task handler backed by the given stores
+func NewTaskHandler(schedules store.ScheduleStore, tasks store.TaskStore) *TaskHandler {
+	return &TaskHandler{Schedules: schedules, Tasks: tasks}
+}
+
 // UpdateTask updates the status of a specific task
-func UpdateTask(c *gin.Context) {
+func (h *TaskHandler) UpdateTask(c *gin.Context) {
 	idParam := c.Param("taskId")
 	taskID, err := strconv.Atoi(idParam)
 	if err != nil {
@@ -33,9 +44,7 @@ func UpdateTask(c *gin.Context) {
 	}
 
 	// Check if task exists
-	var existingStatus string
-	var scheduleID int
-	err = database.DB.QueryRow("SELECT status, schedule_id FROM tasks WHERE id = ?", taskID).Scan(&existingStatus, &scheduleID)
+	task, err := h.Tasks.GetTask(taskID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
@@ -45,49 +54,28 @@ func UpdateTask(c *gin.Context) {
 		return
 	}
 
+	if !authorizeScheduleAccess(c, h.Schedules, task.ScheduleID) {
+		return
+	}
+
 	// Check if the associated schedule is in progress (visit started)
-	var scheduleStatus string
-	err = database.DB.QueryRow("SELECT status FROM schedules WHERE id = ?", scheduleID).Scan(&scheduleStatus)
+	schedule, err := h.Schedules.GetSchedule(task.ScheduleID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedule status"})
 		return
 	}
 
-	if scheduleStatus != "in_progress" {
+	if schedule.Status != models.StatusInProgress {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot update tasks before starting the visit"})
 		return
 	}
 
-	// Update task
-	_, err = database.DB.Exec(`
-		UPDATE tasks 
-		SET status = ?, reason = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?`,
-		req.Status, req.Reason, taskID)
+	updatedTask, err := h.Tasks.UpdateTask(taskID, req.Status, req.Reason, actorFromContext(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
 		return
 	}
 
-	// Return updated task
-	var updatedTask models.Task
-	var reason sql.NullString
-	var createdAt, updatedAt string
-
-	err = database.DB.QueryRow(`
-		SELECT id, schedule_id, description, status, reason, created_at, updated_at
-		FROM tasks WHERE id = ?`, taskID).Scan(
-		&updatedTask.ID, &updatedTask.ScheduleID, &updatedTask.Description,
-		&updatedTask.Status, &reason, &createdAt, &updatedAt)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated task"})
-		return
-	}
-
-	if reason.Valid {
-		updatedTask.Reason = reason.String
-	}
-
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Task updated successfully",
 		"task": updatedTask,
@@ -95,7 +83,7 @@ func UpdateTask(c *gin.Context) {
 }
 
 // GetTasksBySchedule returns all tasks for a specific schedule
-func GetTasksBySchedule(c *gin.Context) {
+func (h *TaskHandler) GetTasksBySchedule(c *gin.Context) {
 	idParam := c.Param("id")
 	scheduleID, err := strconv.Atoi(idParam)
 	if err != nil {
@@ -103,51 +91,25 @@ func GetTasksBySchedule(c *gin.Context) {
 		return
 	}
 
-	// Check if schedule exists
-	var count int
-	err = database.DB.QueryRow("SELECT COUNT(*) FROM schedules WHERE id = ?", scheduleID).Scan(&count)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify schedule"})
+	if !authorizeScheduleAccess(c, h.Schedules, scheduleID) {
 		return
 	}
 
-	if count == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+	// Check if schedule exists
+	if _, err := h.Schedules.GetSchedule(scheduleID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify schedule"})
 		return
 	}
 
-	// Get tasks
-	rows, err := database.DB.Query(`
-		SELECT id, schedule_id, description, status, reason, created_at, updated_at
-		FROM tasks
-		WHERE schedule_id = ?
-		ORDER BY id ASC`, scheduleID)
+	tasks, err := h.Tasks.ListTasks(scheduleID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks"})
 		return
 	}
-	defer rows.Close()
-
-	var tasks []models.Task
-	for rows.Next() {
-		var task models.Task
-		var reason sql.NullString
-		var createdAt, updatedAt string
-
-		err := rows.Scan(
-			&task.ID, &task.ScheduleID, &task.Description, &task.Status,
-			&reason, &createdAt, &updatedAt)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse task data"})
-			return
-		}
-
-		if reason.Valid {
-			task.Reason = reason.String
-		}
-
-		tasks = append(tasks, task)
-	}
 
 	c.JSON(http.StatusOK, tasks)
-} 
\ No newline at end of file
+}
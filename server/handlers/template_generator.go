@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"visit-tracker-api/config"
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+	"visit-tracker-api/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/teambition/rrule-go"
+)
+
+var templateConfig = config.Default().Templates
+
+// templateGeneratorJob names the generator's lock, held by one API instance at a time
+const templateGeneratorJob = "template_generator"
+
+// SetTemplateConfig replaces the template generation settings
+func SetTemplateConfig(cfg config.TemplateConfig) {
+	templateConfig = cfg
+}
+
+// StartTemplateGenerator materialises schedules from templates now and then on every
+// interval. When several instances share the database, a run is skipped while another
+// instance's run is still going.
+func StartTemplateGenerator(cfg config.TemplateConfig, templates store.TemplateStore, schedules store.ScheduleStore, jobs store.JobStore) {
+	go func() {
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			_, err := jobs.RunExclusive(templateGeneratorJob, func() error {
+				_, err := GenerateTemplateSchedules(templates, schedules, time.Now())
+				return err
+			})
+			if err != nil {
+				utils.LogError(err, "Schedule template generation failed", nil)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// shiftWindow is a single occurrence of a template
+type shiftWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// parseRRule parses the RRULE of a template, with or without the "RRULE:" prefix
+func parseRRule(rule string) (*rrule.ROption, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	return rrule.StrToROption(rule)
+}
+
+// templateOccurrences expands a template into the shifts that start within [from, to)
+func templateOccurrences(template models.ScheduleTemplate, from, to time.Time) ([]shiftWindow, error) {
+	options, err := parseRRule(template.RRule)
+	if err != nil {
+		return nil, err
+	}
+
+	// Shifts keep their local start time across daylight saving changes in the client's zone
+	loc := utils.Location(template.Timezone)
+	dtstart, err := time.ParseInLocation("2006-01-02 15:04", template.StartsOn+" "+template.StartTime, loc)
+	if err != nil {
+		return nil, err
+	}
+	startClock, _ := time.Parse("15:04", template.StartTime)
+	endClock, _ := time.Parse("15:04", template.EndTime)
+	duration := endClock.Sub(startClock)
+	if duration <= 0 {
+		duration += 24 * time.Hour
+	}
+
+	options.Dtstart = dtstart
+	if template.EndsOn != nil {
+		endsOn, err := time.ParseInLocation("2006-01-02", *template.EndsOn, loc)
+		if err != nil {
+			return nil, err
+		}
+		until := endsOn.AddDate(0, 0, 1).Add(-time.Second)
+		if options.Until.IsZero() || until.Before(options.Until) {
+			options.Until = until
+		}
+	}
+
+	rule, err := rrule.NewRRule(*options)
+	if err != nil {
+		return nil, err
+	}
+
+	var windows []shiftWindow
+	for _, start := range rule.Between(from, to, true) {
+		if !start.Before(to) {
+			continue
+		}
+		windows = append(windows, shiftWindow{Start: start, End: start.Add(duration)})
+	}
+	return windows, nil
+}
+
+// GenerateTemplateSchedules materialises schedules from every active template for the
+// configured horizon starting at now. It returns how many schedules were created.
+func GenerateTemplateSchedules(templates store.TemplateStore, schedules store.ScheduleStore, now time.Time) (int, error) {
+	active, err := templates.ListScheduleTemplates(true)
+	if err != nil {
+		return 0, err
+	}
+
+	horizonEnd := now.AddDate(0, 0, templateConfig.HorizonDays)
+	created := 0
+	for _, template := range active {
+		count, err := generateTemplateSchedules(schedules, template, now, horizonEnd)
+		if err != nil {
+			return created, fmt.Errorf("template %d: %w", template.ID, err)
+		}
+		created += count
+	}
+
+	if created > 0 {
+		utils.LogInfo("Schedules generated from templates", logrus.Fields{
+			"created":     created,
+			"templates":   len(active),
+			"horizon_end": horizonEnd.Format(utils.DateTimeLayout),
+		})
+	}
+	return created, nil
+}
+
+// generateTemplateSchedules creates the missing schedules of one template within [from, to),
+// skipping exception dates and shifts that were already generated, even if since cancelled.
+// A shift generated concurrently by another run is skipped when its insert conflicts.
+func generateTemplateSchedules(schedules store.ScheduleStore, template models.ScheduleTemplate, from, to time.Time) (int, error) {
+	windows, err := templateOccurrences(template, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	skipped := make(map[string]bool, len(template.Exceptions))
+	for _, exception := range template.Exceptions {
+		skipped[exception.Date] = true
+	}
+
+	templateID := template.ID
+	created := 0
+	for _, window := range windows {
+		if skipped[window.Start.Format("2006-01-02")] {
+			continue
+		}
+
+		exists, err := schedules.TemplateScheduleExists(template.ID, window.Start)
+		if err != nil {
+			return created, err
+		}
+		if exists {
+			continue
+		}
+
+		input := store.ScheduleInput{
+			ClientID:    template.ClientID,
+			CaregiverID: template.CaregiverID,
+			TemplateID:  &templateID,
+			ShiftStart:  window.Start,
+			ShiftEnd:    window.End,
+			Tasks:       template.Tasks,
+		}
+		_, err = schedules.CreateSchedule(input, store.SystemActor)
+
+		var overlapErr *store.OverlapError
+		if errors.As(err, &overlapErr) {
+			utils.LogWarn("Template shift overlaps caregiver's schedule, leaving it unassigned", logrus.Fields{
+				"template_id":          template.ID,
+				"caregiver_id":         overlapErr.CaregiverID,
+				"shift_start":          window.Start.Format(utils.DateTimeLayout),
+				"conflicting_schedule": overlapErr.ScheduleID,
+			})
+			input.CaregiverID = nil
+			_, err = schedules.CreateSchedule(input, store.SystemActor)
+		}
+		if errors.Is(err, store.ErrTemplateShiftExists) {
+			continue
+		}
+		if err != nil {
+			return created, err
+		}
+		created++
+	}
+
+	return created, nil
+}
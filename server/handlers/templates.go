@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
+	"visit-tracker-api/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// TemplateHandler serves the recurring schedule template endpoints
+type TemplateHandler struct {
+	Templates  store.TemplateStore
+	Schedules  store.ScheduleStore
+	Clients    store.ClientStore
+	Caregivers store.CaregiverStore
+}
+
+// NewTemplateHandler returns a template handler that keeps templates in the first store and
+// materialises schedules into the second, for the clients and caregivers in the others
+func NewTemplateHandler(templates store.TemplateStore, schedules store.ScheduleStore, clients store.ClientStore, caregivers store.CaregiverStore) *TemplateHandler {
+	return &TemplateHandler{Templates: templates, Schedules: schedules, Clients: clients, Caregivers: caregivers}
+}
+
+// templateInput converts a validated template payload to the store's input
+func templateInput(req models.ScheduleTemplateRequest, active bool) store.TemplateInput {
+	return store.TemplateInput{
+		ClientID:    req.ClientID,
+		CaregiverID: req.CaregiverID,
+		RRule:       req.RRule,
+		StartTime:   req.StartTime,
+		EndTime:     req.EndTime,
+		StartsOn:    req.StartsOn,
+		EndsOn:      req.EndsOn,
+		Active:      active,
+		Tasks:       req.Tasks,
+	}
+}
+
+// validateTemplateRequest checks the recurrence rule, times of day and date range
+func validateTemplateRequest(req models.ScheduleTemplateRequest) *ValidationError {
+	if _, err := parseRRule(req.RRule); err != nil {
+		return &ValidationError{Field: "rrule", Message: "Invalid RRULE: " + err.Error()}
+	}
+
+	startTime, err := time.Parse("15:04", req.StartTime)
+	if err != nil {
+		return &ValidationError{Field: "start_time", Message: "start_time must be in HH:MM format"}
+	}
+	endTime, err := time.Parse("15:04", req.EndTime)
+	if err != nil {
+		return &ValidationError{Field: "end_time", Message: "end_time must be in HH:MM format"}
+	}
+	if startTime.Equal(endTime) {
+		return &ValidationError{Field: "end_time", Message: "end_time must differ from start_time"}
+	}
+
+	startsOn, err := time.Parse("2006-01-02", req.StartsOn)
+	if err != nil {
+		return &ValidationError{Field: "starts_on", Message: "starts_on must be in YYYY-MM-DD format"}
+	}
+	if req.EndsOn != nil {
+		endsOn, err := time.Parse("2006-01-02", *req.EndsOn)
+		if err != nil {
+			return &ValidationError{Field: "ends_on", Message: "ends_on must be in YYYY-MM-DD format"}
+		}
+		if endsOn.Before(startsOn) {
+			return &ValidationError{Field: "ends_on", Message: "ends_on must not be before starts_on"}
+		}
+	}
+	return nil
+}
+
+// regenerateTemplate materialises a template's schedules for the horizon after it changed
+func (h *TemplateHandler) regenerateTemplate(c *gin.Context, templateID int) (models.ScheduleTemplate, bool) {
+	template, err := h.Templates.GetScheduleTemplate(templateID)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "get_schedule_template")
+		return template, false
+	}
+	if !template.Active {
+		return template, true
+	}
+
+	now := time.Now()
+	created, err := generateTemplateSchedules(h.Schedules, template, now, now.AddDate(0, 0, templateConfig.HorizonDays))
+	if err != nil {
+		utils.HandleError(c, err, "Failed to generate schedules from template")
+		return template, false
+	}
+
+	utils.LogInfo("Schedule template materialised", logrus.Fields{
+		"request_id":  c.GetString("request_id"),
+		"template_id": templateID,
+		"created":     created,
+	})
+	return template, true
+}
+
+// GetScheduleTemplates godoc
+// @Summary List schedule templates
+// @Description Get all recurring schedule templates, optionally only active ones
+// @Tags schedule-templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param active query bool false "Only return active templates"
+// @Success 200 {array} models.ScheduleTemplate
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /schedule-templates [get]
+func (h *TemplateHandler) GetScheduleTemplates(c *gin.Context) {
+	activeOnly := false
+	if activeParam := c.Query("active"); activeParam != "" {
+		active, err := strconv.ParseBool(activeParam)
+		if err != nil {
+			utils.HandleValidationError(c, err, "active")
+			return
+		}
+		activeOnly = active
+	}
+
+	templates, err := h.Templates.ListScheduleTemplates(activeOnly)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "list_schedule_templates")
+		return
+	}
+
+	utils.JSONSuccess(c, templates)
+}
+
+// GetScheduleTemplateByID godoc
+// @Summary Get schedule template by ID
+// @Description Get a recurring schedule template with its default tasks and skipped dates
+// @Tags schedule-templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} models.ScheduleTemplate
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /schedule-templates/{id} [get]
+func (h *TemplateHandler) GetScheduleTemplateByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "template_id")
+		return
+	}
+
+	template, err := h.Templates.GetScheduleTemplate(id)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "get_schedule_template")
+		return
+	}
+
+	utils.JSONSuccess(c, template)
+}
+
+// CreateScheduleTemplate godoc
+// @Summary Create a schedule template
+// @Description Create a standing visit from an iCalendar RRULE and materialise its schedules for the rolling horizon
+// @Tags schedule-templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param template body models.ScheduleTemplateRequest true "Template data"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 201 {object} models.ScheduleTemplate
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /schedule-templates [post]
+func (h *TemplateHandler) CreateScheduleTemplate(c *gin.Context) {
+	var req models.ScheduleTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err, "request_body")
+		return
+	}
+	if verr := validateTemplateRequest(req); verr != nil {
+		utils.HandleValidationError(c, verr, verr.Field)
+		return
+	}
+	if !validateScheduleAssignment(c, h.Clients, h.Caregivers, req.ClientID, req.CaregiverID) {
+		return
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	templateID, err := h.Templates.CreateScheduleTemplate(templateInput(req, active), actorFromContext(c))
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "create_schedule_template")
+		return
+	}
+
+	template, ok := h.regenerateTemplate(c, templateID)
+	if !ok {
+		return
+	}
+
+	utils.LogInfo("Schedule template created", logrus.Fields{
+		"request_id":  c.GetString("request_id"),
+		"template_id": template.ID,
+		"client_id":   template.ClientID,
+		"rrule":       template.RRule,
+	})
+
+	utils.JSONCreated(c, template)
+}
+
+// UpdateScheduleTemplate godoc
+// @Summary Update a schedule template
+// @Description Update a recurring template; its upcoming generated schedules are cancelled and regenerated, and deactivating it cancels them
+// @Tags schedule-templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Param template body models.ScheduleTemplateRequest true "Template data"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 200 {object} models.ScheduleTemplate
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /schedule-templates/{id} [put]
+func (h *TemplateHandler) UpdateScheduleTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "template_id")
+		return
+	}
+
+	var req models.ScheduleTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err, "request_body")
+		return
+	}
+	if verr := validateTemplateRequest(req); verr != nil {
+		utils.HandleValidationError(c, verr, verr.Field)
+		return
+	}
+
+	existing, err := h.Templates.GetScheduleTemplate(id)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "get_schedule_template")
+		return
+	}
+	if !validateScheduleAssignment(c, h.Clients, h.Caregivers, req.ClientID, req.CaregiverID) {
+		return
+	}
+
+	active := existing.Active
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	actor := actorFromContext(c)
+	if err := h.Templates.UpdateScheduleTemplate(id, templateInput(req, active), actor); err != nil {
+		utils.HandleDatabaseError(c, err, "update_schedule_template")
+		return
+	}
+
+	if err := h.Schedules.CancelTemplateSchedules(id, time.Now(), time.Time{}, "Replaced after the schedule template changed", actor); err != nil {
+		utils.HandleDatabaseError(c, err, "cancel_generated_schedules")
+		return
+	}
+
+	template, ok := h.regenerateTemplate(c, id)
+	if !ok {
+		return
+	}
+
+	utils.JSONSuccess(c, template)
+}
+
+// AddTemplateException godoc
+// @Summary Skip a template date
+// @Description Skip one date of a recurring template; an upcoming schedule already generated for that date is cancelled
+// @Tags schedule-templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Param exception body models.TemplateExceptionRequest true "Date to skip"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 201 {object} models.ScheduleTemplate
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 409 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /schedule-templates/{id}/exceptions [post]
+func (h *TemplateHandler) AddTemplateException(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "template_id")
+		return
+	}
+
+	var req models.TemplateExceptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleValidationError(c, err, "request_body")
+		return
+	}
+
+	day, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		utils.HandleValidationError(c,
+			&ValidationError{Field: "date", Message: "date must be in YYYY-MM-DD format"},
+			"date")
+		return
+	}
+
+	template, err := h.Templates.GetScheduleTemplate(id)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "get_schedule_template")
+		return
+	}
+	for _, exception := range template.Exceptions {
+		if exception.Date == req.Date {
+			utils.HandleConflictError(c, "This date is already skipped", gin.H{"date": req.Date})
+			return
+		}
+	}
+	// The skipped date is a day in the client's time zone
+	date := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, utils.Location(template.Timezone))
+
+	actor := actorFromContext(c)
+	if err := h.Templates.AddTemplateException(id, req.Date, req.Reason, actor); err != nil {
+		utils.HandleDatabaseError(c, err, "create_template_exception")
+		return
+	}
+
+	from := date
+	if now := time.Now(); from.Before(now) {
+		from = now
+	}
+	if err := h.Schedules.CancelTemplateSchedules(id, from, date.AddDate(0, 0, 1), "Date skipped on the schedule template", actor); err != nil {
+		utils.HandleDatabaseError(c, err, "cancel_generated_schedules")
+		return
+	}
+
+	template, err = h.Templates.GetScheduleTemplate(id)
+	if err != nil {
+		utils.HandleDatabaseError(c, err, "get_schedule_template")
+		return
+	}
+
+	utils.LogInfo("Schedule template date skipped", logrus.Fields{
+		"request_id":  c.GetString("request_id"),
+		"template_id": id,
+		"date":        req.Date,
+	})
+
+	utils.JSONCreated(c, template)
+}
+
+// DeleteTemplateException godoc
+// @Summary Restore a skipped template date
+// @Description Remove a skipped date from a recurring template and regenerate its schedule
+// @Tags schedule-templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Param exceptionId path int true "Exception ID"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 200 {object} models.ScheduleTemplate
+// @Failure 400 {object} middleware.ErrorResponse
+// @Failure 404 {object} middleware.ErrorResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /schedule-templates/{id}/exceptions/{exceptionId} [delete]
+func (h *TemplateHandler) DeleteTemplateException(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "template_id")
+		return
+	}
+	exceptionID, err := strconv.Atoi(c.Param("exceptionId"))
+	if err != nil {
+		utils.HandleValidationError(c, err, "exception_id")
+		return
+	}
+
+	if err := h.Templates.DeleteTemplateException(id, exceptionID, actorFromContext(c)); err != nil {
+		utils.HandleDatabaseError(c, err, "delete_template_exception")
+		return
+	}
+
+	template, ok := h.regenerateTemplate(c, id)
+	if !ok {
+		return
+	}
+
+	utils.JSONSuccess(c, template)
+}
+
+// GenerateSchedules godoc
+// @Summary Generate schedules from templates
+// @Description Materialise schedules from every active template for the rolling horizon; already generated shifts are left alone
+// @Tags schedule-templates
+// @Produce json
+// @Security BearerAuth
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
+// @Success 200 {object} models.GenerateSchedulesResponse
+// @Failure 500 {object} middleware.ErrorResponse
+// @Router /schedule-templates/generate [post]
+func (h *TemplateHandler) GenerateSchedules(c *gin.Context) {
+	now := time.Now()
+	created, err := GenerateTemplateSchedules(h.Templates, h.Schedules, now)
+	if err != nil {
+		utils.HandleError(c, err, "Failed to generate schedules from templates")
+		return
+	}
+
+	utils.JSONSuccess(c, models.GenerateSchedulesResponse{
+		Created:    created,
+		HorizonEnd: now.AddDate(0, 0, templateConfig.HorizonDays),
+	})
+}
@@ -2,32 +2,48 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
-	"visit-tracker-api/database"
 	"visit-tracker-api/models"
+	"visit-tracker-api/store"
 	"visit-tracker-api/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
+// VisitHandler serves the clock-in and clock-out endpoints
+type VisitHandler struct {
+	Schedules store.ScheduleStore
+	Visits    store.VisitStore
+	Notes     store.NoteStore
+}
+
+// NewVisitHandler returns a visit handler backed by the given stores
+func NewVisitHandler(schedules store.ScheduleStore, visits store.VisitStore, notes store.NoteStore) *VisitHandler {
+	return &VisitHandler{Schedules: schedules, Visits: visits, Notes: notes}
+}
+
 // StartVisit godoc
 // @Summary Start a visit
 // @Description Start a caregiver visit by logging timestamp and geolocation
 // @Tags visits
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Schedule ID"
 // @Param startVisitRequest body models.StartVisitRequest true "Start visit data"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 422 {object} middleware.ErrorResponse
 // @Failure 500 {object} map[string]string
 // @Router /schedules/{id}/start [post]
-func StartVisit(c *gin.Context) {
+func (h *VisitHandler) StartVisit(c *gin.Context) {
 	// Log the start of the operation
 	utils.LogInfo("Starting visit", logrus.Fields{
 		"request_id": c.GetString("request_id"),
@@ -41,6 +57,10 @@ func StartVisit(c *gin.Context) {
 		return
 	}
 
+	if !authorizeScheduleAccess(c, h.Schedules, scheduleID) {
+		return
+	}
+
 	var req models.StartVisitRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.HandleValidationError(c, err, "request_body")
@@ -56,69 +76,46 @@ func StartVisit(c *gin.Context) {
 	}
 
 	// Check if schedule exists and is not already started
-	var currentStatus string
-	err = database.DB.QueryRow("SELECT status FROM schedules WHERE id = ?", scheduleID).Scan(&currentStatus)
+	schedule, err := h.Schedules.GetSchedule(scheduleID)
 	if err != nil {
 		utils.HandleDatabaseError(c, err, "get_schedule_status")
 		return
 	}
 
-	if currentStatus == "completed" {
-		utils.HandleValidationError(c, 
-			&ValidationError{Field: "status", Message: "Visit already completed"}, 
-			"visit_status")
-		return
-	}
-
-	if currentStatus == "in_progress" {
-		utils.HandleValidationError(c, 
-			&ValidationError{Field: "status", Message: "Visit already started"}, 
-			"visit_status")
+	if !models.IsStartable(schedule.Status) {
+		handleTransitionError(c, &models.TransitionError{From: schedule.Status, To: models.StatusInProgress}, "start_visit")
 		return
 	}
 
-	// Start transaction
-	tx, err := database.DB.Begin()
-	if err != nil {
-		utils.HandleDatabaseError(c, err, "begin_transaction")
+	// Verify the caregiver is at the client's home
+	geofence, allowed := evaluateGeofence(schedule.Latitude, schedule.Longitude, req.Latitude, req.Longitude, req.OverrideReason, actorFromContext(c).Role)
+	if !allowed {
+		handleGeofenceViolation(c, scheduleID, geofence)
 		return
 	}
-	defer tx.Rollback()
 
-	// Update visit record with start time and location
-	now := time.Now()
-	_, err = tx.Exec(`
-		UPDATE visits 
-		SET start_time = ?, start_lat = ?, start_lng = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE schedule_id = ?`,
-		now.Format("2006-01-02 15:04:05"), req.Latitude, req.Longitude, scheduleID)
+	// Record the clock-in and move the schedule to in_progress
+	now := time.Now().UTC()
+	err = h.Visits.StartVisit(scheduleID, store.VisitCheckpoint{
+		Time:           now,
+		Latitude:       req.Latitude,
+		Longitude:      req.Longitude,
+		Geofence:       geofence,
+		OverrideReason: req.OverrideReason,
+	}, actorFromContext(c))
 	if err != nil {
-		utils.HandleDatabaseError(c, err, "update_visit_record")
-		return
-	}
-
-	// Update schedule status to in_progress
-	_, err = tx.Exec(`
-		UPDATE schedules 
-		SET status = 'in_progress', updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?`, scheduleID)
-	if err != nil {
-		utils.HandleDatabaseError(c, err, "update_schedule_status")
-		return
-	}
-
-	// Commit transaction
-	if err = tx.Commit(); err != nil {
-		utils.HandleDatabaseError(c, err, "commit_transaction")
+		handleTransitionError(c, err, "start_visit")
 		return
 	}
 
 	// Log successful operation
 	utils.LogInfo("Visit started successfully", logrus.Fields{
-		"request_id":  c.GetString("request_id"),
-		"schedule_id": scheduleID,
-		"latitude":    req.Latitude,
-		"longitude":   req.Longitude,
+		"request_id":      c.GetString("request_id"),
+		"schedule_id":     scheduleID,
+		"latitude":        req.Latitude,
+		"longitude":       req.Longitude,
+		"geofence_status": geofence.Status,
+		"distance_meters": geofence.DistanceMeters,
 	})
 
 	// Return success response
@@ -129,23 +126,27 @@ func StartVisit(c *gin.Context) {
 			"latitude":  req.Latitude,
 			"longitude": req.Longitude,
 		},
+		"geofence": geofence,
 	})
 }
 
 // EndVisit godoc
 // @Summary End a visit
-// @Description End a caregiver visit by logging timestamp and geolocation
+// @Description End a caregiver visit by logging timestamp and geolocation. Under the reject policy any pending task, required or optional, refuses the clock-out with TASKS_PENDING (422) listing them; under auto_close they are marked not_completed and listed under closed_tasks.
 // @Tags visits
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Schedule ID"
 // @Param endVisitRequest body models.EndVisitRequest true "End visit data"
+// @Param Idempotency-Key header string false "Unique key that makes retrying this request safe"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 422 {object} middleware.ErrorResponse
 // @Failure 500 {object} map[string]string
 // @Router /schedules/{id}/end [post]
-func EndVisit(c *gin.Context) {
+func (h *VisitHandler) EndVisit(c *gin.Context) {
 	idParam := c.Param("id")
 	scheduleID, err := strconv.Atoi(idParam)
 	if err != nil {
@@ -153,6 +154,10 @@ func EndVisit(c *gin.Context) {
 		return
 	}
 
+	if !authorizeScheduleAccess(c, h.Schedules, scheduleID) {
+		return
+	}
+
 	var req models.EndVisitRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
@@ -160,8 +165,7 @@ func EndVisit(c *gin.Context) {
 	}
 
 	// Check if schedule exists and is in progress
-	var currentStatus string
-	err = database.DB.QueryRow("SELECT status FROM schedules WHERE id = ?", scheduleID).Scan(&currentStatus)
+	schedule, err := h.Schedules.GetSchedule(scheduleID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
@@ -171,67 +175,76 @@ func EndVisit(c *gin.Context) {
 		return
 	}
 
-	if currentStatus != "in_progress" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Visit not started yet or already completed"})
+	if !models.CanTransition(schedule.Status, models.StatusCompleted) {
+		transitionErr := &models.TransitionError{From: schedule.Status, To: models.StatusCompleted}
+		c.JSON(http.StatusBadRequest, gin.H{"error": transitionErr.Error()})
 		return
 	}
 
 	// Check if visit has start time
-	var startTime sql.NullString
-	err = database.DB.QueryRow("SELECT start_time FROM visits WHERE schedule_id = ?", scheduleID).Scan(&startTime)
-	if err != nil || !startTime.Valid {
+	visit, err := h.Visits.GetVisit(scheduleID)
+	if err != nil || visit.StartTime == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Visit not properly started"})
 		return
 	}
 
-	// Start transaction
-	tx, err := database.DB.Begin()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+	// Verify the caregiver is still at the client's home
+	geofence, allowed := evaluateGeofence(schedule.Latitude, schedule.Longitude, req.Latitude, req.Longitude, req.OverrideReason, actorFromContext(c).Role)
+	if !allowed {
+		handleGeofenceViolation(c, scheduleID, geofence)
 		return
 	}
-	defer tx.Rollback()
 
-	// Update visit record with end time and location
-	now := time.Now()
-	_, err = tx.Exec(`
-		UPDATE visits 
-		SET end_time = ?, end_lat = ?, end_lng = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE schedule_id = ?`,
-		now.Format("2006-01-02 15:04:05"), req.Latitude, req.Longitude, scheduleID)
+	// Record the clock-out and move the schedule to completed
+	now := time.Now().UTC()
+	closedTasks, err := h.Visits.EndVisit(scheduleID, store.VisitCheckpoint{
+		Time:           now,
+		Latitude:       req.Latitude,
+		Longitude:      req.Longitude,
+		Geofence:       geofence,
+		OverrideReason: req.OverrideReason,
+		CloseTasks:     closesPendingTasks(),
+	}, actorFromContext(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update visit record"})
+		var transitionErr *models.TransitionError
+		if errors.As(err, &transitionErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": transitionErr.Error()})
+			return
+		}
+		var pendingErr *store.PendingTasksError
+		if errors.As(err, &pendingErr) {
+			handlePendingTasks(c, scheduleID, pendingErr)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to end visit"})
 		return
 	}
 
-	// Update schedule status to completed
-	_, err = tx.Exec(`
-		UPDATE schedules 
-		SET status = 'completed', updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?`, scheduleID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update schedule status"})
-		return
-	}
+	// Calculate visit duration
+	startTime := *visit.StartTime
+	duration := now.Sub(startTime)
 
-	// Commit transaction
-	if err = tx.Commit(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
-		return
+	// The visit has ended, so include the notes written during it in the summary
+	notes, err := h.Notes.ListVisitNotes(scheduleID)
+	if err != nil {
+		utils.LogError(err, "Failed to fetch visit notes", logrus.Fields{
+			"request_id":  c.GetString("request_id"),
+			"schedule_id": scheduleID,
+		})
+		notes = []models.VisitNote{}
 	}
 
-	// Calculate visit duration
-	startTimeObj, _ := time.Parse("2006-01-02 15:04:05", startTime.String)
-	duration := now.Sub(startTimeObj)
-
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Visit ended successfully",
-		"start_time": startTimeObj,
+		"start_time": startTime,
 		"end_time": now,
 		"duration_minutes": int(duration.Minutes()),
 		"end_location": gin.H{
 			"latitude":  req.Latitude,
 			"longitude": req.Longitude,
 		},
+		"geofence": geofence,
+		"closed_tasks": closedTasks,
+		"notes": notes,
 	})
-} 
\ No newline at end of file
+} 
@@ -1,15 +1,23 @@
 package main
 
 import (
+	"fmt"
 	"os"
+	"strings"
+	"time"
+	_ "time/tzdata" // time zones for clients, even where the system has no zoneinfo
 
+	"visit-tracker-api/config"
 	"visit-tracker-api/database"
 	"visit-tracker-api/handlers"
 	"visit-tracker-api/middleware"
+	"visit-tracker-api/models"
+	"visit-tracker-api/store"
 	"visit-tracker-api/utils"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
@@ -31,29 +39,137 @@ import (
 // @host localhost:8080
 // @BasePath /api/v1
 
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Type "Bearer" followed by a space and the token from /auth/login
+
 // @externalDocs.description OpenAPI
 // @externalDocs.url https://swagger.io/resources/open-api/
 func main() {
+	// Settings come from flags, the environment and .env, in that order
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid configuration:", err)
+		os.Exit(2)
+	}
+
+	// Local dates are worked out in each client's time zone, or the agency's
+	agencyLocation, _ := time.LoadLocation(cfg.Timezone)
+	utils.SetAgencyLocation(agencyLocation)
+
+	// "migrate up|down|status" manages the schema without starting the server
+	if len(args) > 0 && args[0] == "migrate" {
+		os.Exit(runMigrate(cfg.Database, args[1:]))
+	}
+	// "evv-export" writes visits for a state EVV aggregator without starting the server
+	if len(args) > 0 && args[0] == "evv-export" {
+		os.Exit(runEVVExport(cfg, args[1:]))
+	}
+	if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n", args[0])
+		os.Exit(2)
+	}
+
 	// Initialize logger
-	logger := utils.InitLogger()
-	logger.Info("Starting Visit Tracker API")
+	logger := utils.InitLogger(cfg.Log)
+	logger.WithFields(logrus.Fields{
+		"version":  cfg.App.Version,
+		"gin_mode": cfg.GinMode,
+	}).Info("Starting Visit Tracker API")
+
+	// Aggregator layouts for EVV exports, including any from EVV_LAYOUTS_FILE
+	evvLayouts, err := loadEVVLayouts(cfg.EVV)
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid EVV export configuration")
+	}
 
 	// Initialize database
-	database.Initialize()
+	database.Initialize(cfg.Database)
 	defer database.Close()
 
+	// Build the stores and the handlers that depend on them
+	sqlStore := store.NewSQLStore(database.DB)
+
+	// Create the admin named by ADMIN_EMAIL and ADMIN_PASSWORD if it does not exist yet,
+	// so databases created before authentication can be signed in to
+	if cfg.Database.AdminEmail != "" {
+		created, err := sqlStore.EnsureAdminUser(cfg.Database.AdminEmail, cfg.Database.AdminPassword)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to create admin user")
+		}
+		if created {
+			logger.WithField("email", cfg.Database.AdminEmail).Info("Admin user created")
+		}
+	}
+	scheduleHandler := handlers.NewScheduleHandler(sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore)
+	visitHandler := handlers.NewVisitHandler(sqlStore, sqlStore, sqlStore)
+	noteHandler := handlers.NewNoteHandler(sqlStore, sqlStore)
+	incidentHandler := handlers.NewIncidentHandler(sqlStore, sqlStore)
+	taskHandler := handlers.NewTaskHandler(sqlStore, sqlStore)
+	activityHandler := handlers.NewActivityHandler(sqlStore, sqlStore)
+	templateHandler := handlers.NewTemplateHandler(sqlStore, sqlStore, sqlStore, sqlStore)
+	syncHandler := handlers.NewSyncHandler(sqlStore, sqlStore, sqlStore, sqlStore, sqlStore)
+	evvHandler := handlers.NewEVVHandler(sqlStore, evvLayouts, cfg.EVV)
+	auditHandler := handlers.NewAuditHandler(sqlStore)
+	carePlanHandler := handlers.NewCarePlanHandler(sqlStore, sqlStore)
+	clientHandler := handlers.NewClientHandler(sqlStore)
+	caregiverHandler := handlers.NewCaregiverHandler(sqlStore)
+	authHandler := handlers.NewAuthHandler(sqlStore, sqlStore)
+
+	// Configure geofence verification for clock-in/out
+	geofence := cfg.Geofence
+	handlers.SetGeofenceConfig(geofence)
+	logger.WithFields(logrus.Fields{
+		"radius_meters": geofence.RadiusMeters,
+		"mode":          geofence.Mode,
+	}).Info("Geofence verification configured")
+
+	// Decide what ending a visit does with tasks still pending
+	taskPolicy := cfg.Tasks.CompletionPolicy
+	handlers.SetTaskCompletionPolicy(taskPolicy)
+	logger.WithField("policy", taskPolicy).Info("Task completion policy configured")
+
+	// Bound the times offline clock-ins and clock-outs may be recorded at
+	handlers.SetSyncConfig(cfg.Sync)
+
+	// Materialise recurring schedule templates for the rolling horizon
+	templates := cfg.Templates
+	handlers.SetTemplateConfig(templates)
+	handlers.StartTemplateGenerator(templates, sqlStore, sqlStore, sqlStore)
+	logger.WithFields(logrus.Fields{
+		"horizon_days": templates.HorizonDays,
+		"interval":     templates.Interval.String(),
+	}).Info("Schedule template generator started")
+
+	// Mark schedules that were never started as late, then missed once the grace period has passed
+	missedVisits := cfg.MissedVisit
+	handlers.SetMissedVisitConfig(missedVisits)
+	handlers.StartMissedVisitDetector(missedVisits, sqlStore, sqlStore)
+	logger.WithFields(logrus.Fields{
+		"grace_period": missedVisits.GracePeriod.String(),
+		"interval":     missedVisits.Interval.String(),
+	}).Info("Missed visit detection started")
+
+	// Configure authentication
+	authConfig := middleware.NewAuthConfig(cfg.Auth, logger)
+	handlers.SetAuthConfig(authConfig)
+
+	// Remember responses to requests sent with an Idempotency-Key so retries can be replayed
+	idempotency := cfg.Idempotency
+	middleware.StartIdempotencyKeyCleanup(idempotency, sqlStore, logger)
+	logger.WithField("ttl", idempotency.TTL.String()).Info("Idempotency keys enabled")
+
 	// Configure Swagger info
-	docs.SwaggerInfo.Title = "Visit Tracker API"
-	docs.SwaggerInfo.Description = "RESTful API for caregiver visit tracking and Electronic Visit Verification (EVV) compliance"
-	docs.SwaggerInfo.Version = "1.0"
-	docs.SwaggerInfo.Host = "localhost:8080"
-	docs.SwaggerInfo.BasePath = "/api/v1"
+	docs.SwaggerInfo.Title = cfg.Swagger.Title
+	docs.SwaggerInfo.Description = cfg.Swagger.Description
+	docs.SwaggerInfo.Version = cfg.App.Version
+	docs.SwaggerInfo.Host = cfg.Swagger.Host
+	docs.SwaggerInfo.BasePath = cfg.Swagger.BasePath
 	docs.SwaggerInfo.Schemes = []string{"http"}
 
 	// Set Gin mode
-	if os.Getenv("GIN_MODE") == "" {
-		gin.SetMode(gin.DebugMode)
-	}
+	gin.SetMode(cfg.GinMode)
 
 	// Create Gin router with no default middleware
 	router := gin.New()
@@ -64,95 +180,219 @@ func main() {
 	router.Use(middleware.ErrorHandlerMiddleware(logger))
 	router.Use(gin.Recovery()) // Keep gin's recovery as backup
 
-	// Configure CORS - Allow requests from anywhere
-	config := cors.DefaultConfig()
-	config.AllowAllOrigins = true
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "HEAD", "PATCH"}
-	config.AllowHeaders = []string{
-		"Origin", "Content-Type", "Accept", "Authorization", "X-Request-ID",
-		"X-Requested-With", "Access-Control-Allow-Origin", "Access-Control-Allow-Headers",
-		"Access-Control-Allow-Methods", "Access-Control-Expose-Headers", "Access-Control-Max-Age",
-		"Access-Control-Allow-Credentials", "Cache-Control", "Pragma",
+	// Configure CORS from CORS_ALLOW_ORIGINS, CORS_ALLOW_METHODS and CORS_ALLOW_HEADERS
+	corsConfig := cors.DefaultConfig()
+	if cfg.CORS.AllowAllOrigins() {
+		corsConfig.AllowAllOrigins = true
+	} else {
+		corsConfig.AllowOrigins = cfg.CORS.AllowOrigins
 	}
-	config.ExposeHeaders = []string{"Content-Length", "Access-Control-Allow-Origin", "Access-Control-Allow-Headers"}
-	config.AllowCredentials = false
-	config.MaxAge = 12 * 60 * 60 // 12 hours
-	router.Use(cors.New(config))
+	corsConfig.AllowMethods = cfg.CORS.AllowMethods
+	corsConfig.AllowHeaders = cfg.CORS.AllowHeaders
+	corsConfig.ExposeHeaders = []string{"Content-Length", "Access-Control-Allow-Origin", "Access-Control-Allow-Headers", middleware.IdempotentReplayedHeader}
+	corsConfig.AllowCredentials = false
+	corsConfig.MaxAge = 12 * 60 * 60 // 12 hours
+	router.Use(cors.New(corsConfig))
+	logger.WithFields(logrus.Fields{
+		"origins": strings.Join(cfg.CORS.AllowOrigins, ","),
+	}).Info("CORS configured")
 
 	// Health check endpoint (supports both GET and HEAD methods)
 	healthHandler := func(c *gin.Context) {
 		utils.JSONSuccess(c, gin.H{
-			"status":  "healthy",
-			"service": "visit-tracker-api",
-			"version": "1.0.0",
+			"status":      "healthy",
+			"service":     cfg.App.Name,
+			"version":     cfg.App.Version,
+			"api_version": cfg.API.Version,
 		})
 	}
 	router.GET("/health", healthHandler)
 	router.HEAD("/health", healthHandler)
 
-	// CORS debug endpoint (temporary)
-	router.OPTIONS("/*path", func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, HEAD, PATCH")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization, X-Request-ID, X-Requested-With")
-		c.Status(204)
-	})
-
-	// Swagger documentation
-	// Redirect root swagger path to index for better UX  
-	router.GET("/swagger", func(c *gin.Context) {
-		c.Redirect(301, "/swagger/index.html")
-	})
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	// Swagger documentation, unless ENABLE_SWAGGER turns it off
+	if cfg.Swagger.Enabled {
+		// Redirect root swagger path to index for better UX
+		router.GET("/swagger", func(c *gin.Context) {
+			c.Redirect(301, "/swagger/index.html")
+		})
+		router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	}
 
 	// API routes
-	api := router.Group("/api/v1")
+	api := router.Group(cfg.API.BasePath)
+	{
+		// Authentication endpoints
+		api.POST("/auth/login", authHandler.Login)
+	}
+
+	// Authenticated endpoints, available to every role. Caregivers are limited
+	// to their own schedules inside the handlers.
+	authenticated := api.Group("")
+	authenticated.Use(middleware.Auth(authConfig, sqlStore))
+	authenticated.Use(middleware.Idempotency(idempotency, sqlStore, logger))
 	{
+		authenticated.GET("/auth/me", authHandler.GetCurrentUser)
+
 		// Schedule endpoints
-		api.GET("/schedules", handlers.GetAllSchedules)
-		api.GET("/schedules/today", handlers.GetTodaySchedules)
-		api.GET("/schedules/:id", handlers.GetScheduleByID)
-		api.GET("/schedules/:id/tasks", handlers.GetTasksBySchedule)
+		authenticated.GET("/schedules", scheduleHandler.GetAllSchedules)
+		authenticated.GET("/schedules/today", scheduleHandler.GetTodaySchedules)
+		authenticated.GET("/schedules/:id", scheduleHandler.GetScheduleByID)
+		authenticated.GET("/schedules/:id/tasks", taskHandler.GetTasksBySchedule)
+		authenticated.POST("/schedules/:id/tasks/bulk", taskHandler.BulkUpdateTasks)
+		authenticated.GET("/schedules/:id/history", scheduleHandler.GetScheduleStatusHistory)
+		authenticated.GET("/calendar", scheduleHandler.GetCalendar)
 		
 		// Visit endpoints
-		api.POST("/schedules/:id/start", handlers.StartVisit)
-		api.POST("/schedules/:id/end", handlers.EndVisit)
+		authenticated.POST("/schedules/:id/start", visitHandler.StartVisit)
+		authenticated.POST("/schedules/:id/end", visitHandler.EndVisit)
+		authenticated.GET("/schedules/:id/notes", noteHandler.GetVisitNotes)
+		authenticated.POST("/schedules/:id/notes", noteHandler.CreateVisitNote)
+
+		// Incident endpoints
+		authenticated.GET("/schedules/:id/incidents", incidentHandler.GetScheduleIncidents)
+		authenticated.POST("/schedules/:id/incidents", incidentHandler.CreateIncident)
+		authenticated.GET("/incidents/:id", incidentHandler.GetIncident)
 		
 		// Task endpoints
-		api.POST("/tasks/:taskId/update", handlers.UpdateTask)
+		authenticated.POST("/tasks/:taskId/update", taskHandler.UpdateTask)
 		
 		// Activity endpoints
-		api.GET("/activities/:id", handlers.GetActivityByID)
-		api.GET("/schedules/:id/activities", handlers.GetActivitiesBySchedule)
-		api.POST("/schedules/:id/activities", handlers.CreateActivity)
-		api.PUT("/activities/:id", handlers.UpdateActivity)
+		authenticated.GET("/activities/:id", activityHandler.GetActivityByID)
+		authenticated.GET("/schedules/:id/activities", activityHandler.GetActivitiesBySchedule)
+		authenticated.POST("/schedules/:id/activities", activityHandler.CreateActivity)
+		authenticated.PUT("/activities/:id", activityHandler.UpdateActivity)
+		
+		// Offline sync endpoint
+		authenticated.POST("/sync", syncHandler.SyncEvents)
 		
 		// Stats endpoint
-		api.GET("/stats", handlers.GetStats)
+		authenticated.GET("/stats", scheduleHandler.GetStats)
 	}
 
-	// Get port from environment or default to 8080
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// Coordinator endpoints for managing schedules, clients and caregivers
+	coordinator := authenticated.Group("")
+	coordinator.Use(middleware.RequireRole(models.RoleCoordinator, models.RoleAdmin))
+	{
+		// Schedule management endpoints
+		coordinator.POST("/schedules", scheduleHandler.CreateSchedule)
+		coordinator.PUT("/schedules/:id", scheduleHandler.UpdateSchedule)
+		coordinator.POST("/schedules/:id/cancel", scheduleHandler.CancelSchedule)
+
+		// Visit correction endpoints
+		coordinator.POST("/schedules/:id/adjustments", visitHandler.AdjustVisit)
+		coordinator.GET("/visit-adjustment-reasons", handlers.GetAdjustmentReasons)
+
+		// Incident follow-up endpoints
+		coordinator.GET("/incidents", incidentHandler.GetIncidentQueue)
+		coordinator.PUT("/incidents/:id", incidentHandler.UpdateIncident)
+
+		// Recurring schedule template endpoints
+		coordinator.GET("/schedule-templates", templateHandler.GetScheduleTemplates)
+		coordinator.GET("/schedule-templates/:id", templateHandler.GetScheduleTemplateByID)
+		coordinator.POST("/schedule-templates", templateHandler.CreateScheduleTemplate)
+		coordinator.PUT("/schedule-templates/:id", templateHandler.UpdateScheduleTemplate)
+		coordinator.POST("/schedule-templates/:id/exceptions", templateHandler.AddTemplateException)
+		coordinator.DELETE("/schedule-templates/:id/exceptions/:exceptionId", templateHandler.DeleteTemplateException)
+		coordinator.POST("/schedule-templates/generate", templateHandler.GenerateSchedules)
+
+		// Client endpoints
+		coordinator.GET("/clients", clientHandler.GetClients)
+		coordinator.GET("/clients/:id", clientHandler.GetClientByID)
+		coordinator.POST("/clients", clientHandler.CreateClient)
+		coordinator.PUT("/clients/:id", clientHandler.UpdateClient)
+
+		// Care plan endpoints
+		coordinator.GET("/clients/:id/care-plan", carePlanHandler.GetCarePlan)
+		coordinator.POST("/clients/:id/care-plan", carePlanHandler.CreateCarePlanTask)
+		coordinator.PUT("/clients/:id/care-plan/:taskId", carePlanHandler.UpdateCarePlanTask)
+		coordinator.DELETE("/clients/:id/care-plan/:taskId", carePlanHandler.DeleteCarePlanTask)
+		
+		// Caregiver endpoints
+		coordinator.GET("/caregivers", caregiverHandler.GetCaregivers)
+		coordinator.GET("/caregivers/:id", caregiverHandler.GetCaregiverByID)
+		coordinator.POST("/caregivers", caregiverHandler.CreateCaregiver)
+		coordinator.PUT("/caregivers/:id", caregiverHandler.UpdateCaregiver)
+		coordinator.DELETE("/caregivers/:id", caregiverHandler.DeleteCaregiver)
+
+		// EVV compliance export
+		coordinator.GET("/evv/export", evvHandler.ExportEVV)
+	}
+
+	// Admin endpoints
+	admin := authenticated.Group("")
+	admin.Use(middleware.RequireRole(models.RoleAdmin))
+	{
+		admin.GET("/users", authHandler.GetUsers)
+		admin.POST("/users", authHandler.CreateUser)
+
+		// Audit log endpoints
+		admin.GET("/audit-events", auditHandler.GetAuditEvents)
+		admin.GET("/audit-events/verify", auditHandler.VerifyAuditChain)
 	}
 
+	port := cfg.Port
+
 	logger.WithField("port", port).Info("Server starting")
 	logger.WithField("health_check", "http://localhost:"+port+"/health").Info("Health check endpoint")
-	logger.WithField("swagger", "http://localhost:"+port+"/swagger/").Info("Swagger documentation")
-	logger.Info("API endpoints:")
-	logger.Info("  GET    /api/v1/schedules           - Get all schedules")
-	logger.Info("  GET    /api/v1/schedules/today     - Get today's schedules")
-	logger.Info("  GET    /api/v1/schedules/:id       - Get schedule details with tasks")
-	logger.Info("  GET    /api/v1/schedules/:id/tasks - Get tasks for a schedule")
-	logger.Info("  POST   /api/v1/schedules/:id/start - Start visit (requires lat/lng)")
-	logger.Info("  POST   /api/v1/schedules/:id/end   - End visit (requires lat/lng)")
-	logger.Info("  POST   /api/v1/tasks/:taskId/update - Update task status")
-	logger.Info("  GET    /api/v1/activities/:id      - Get activity by ID")
-	logger.Info("  GET    /api/v1/schedules/:id/activities - Get activities for a schedule")
-	logger.Info("  POST   /api/v1/schedules/:id/activities - Create new activity")
-	logger.Info("  PUT    /api/v1/activities/:id      - Update activity progress")
-	logger.Info("  GET    /api/v1/stats               - Get dashboard statistics")
+	if cfg.Swagger.Enabled {
+		logger.WithField("swagger", "http://localhost:"+port+"/swagger/").Info("Swagger documentation")
+	}
+	logger.Info("API endpoints under " + cfg.API.BasePath + " (all except login require a bearer token):")
+	logger.Info("  POST   /auth/login          - Sign in and receive a bearer token")
+	logger.Info("  GET    /auth/me             - Get the signed-in user")
+	logger.Info("  GET    /schedules           - List schedules a page at a time (?status=&client_id=&from=&to=&sort=&cursor=)")
+	logger.Info("  GET    /schedules/today     - Get today's schedules (?caregiver_id=)")
+	logger.Info("  GET    /schedules/:id       - Get schedule details with client and tasks")
+	logger.Info("  GET    /schedules/:id/tasks - Get tasks for a schedule")
+	logger.Info("  POST   /schedules/:id/tasks/bulk - Update several tasks at once")
+	logger.Info("  GET    /schedules/:id/history - Get schedule status history")
+	logger.Info("  GET    /calendar            - Count schedules per day for a week or month (?view=week|month&date=)")
+	logger.Info("  POST   /schedules/:id/start - Start visit (requires lat/lng)")
+	logger.Info("  POST   /schedules/:id/end   - End visit (requires lat/lng)")
+	logger.Info("  GET    /schedules/:id/notes - Get a visit's notes")
+	logger.Info("  POST   /schedules/:id/notes - Add a note to a visit")
+	logger.Info("  GET    /schedules/:id/incidents - Get incidents filed against a schedule")
+	logger.Info("  POST   /schedules/:id/incidents - File an incident")
+	logger.Info("  GET    /incidents/:id       - Get an incident")
+	logger.Info("  POST   /tasks/:taskId/update - Update task status")
+	logger.Info("  GET    /activities/:id      - Get activity by ID")
+	logger.Info("  GET    /schedules/:id/activities - Get activities for a schedule")
+	logger.Info("  POST   /schedules/:id/activities - Create new activity")
+	logger.Info("  PUT    /activities/:id      - Update activity progress")
+	logger.Info("  POST   /sync                - Apply events queued on a device while offline")
+	logger.Info("  GET    /users               - List users (admin)")
+	logger.Info("  POST   /users               - Create user (admin)")
+	logger.Info("  GET    /audit-events        - Query the audit log of writes (admin)")
+	logger.Info("  GET    /audit-events/verify - Verify the audit log's hash chain (admin)")
+	logger.Info("  POST   /schedules           - Create schedule with tasks (coordinator)")
+	logger.Info("  PUT    /schedules/:id       - Edit upcoming schedule (coordinator)")
+	logger.Info("  POST   /schedules/:id/cancel - Cancel upcoming schedule (coordinator)")
+	logger.Info("  POST   /schedules/:id/adjustments - Correct a visit's clock-in/out times (coordinator)")
+	logger.Info("  GET    /visit-adjustment-reasons - List visit adjustment reason codes (coordinator)")
+	logger.Info("  GET    /incidents           - List open incidents, most severe first (coordinator)")
+	logger.Info("  PUT    /incidents/:id       - Update an incident's follow-up (coordinator)")
+	logger.Info("  GET    /schedule-templates  - List recurring schedule templates (coordinator)")
+	logger.Info("  GET    /schedule-templates/:id - Get schedule template (coordinator)")
+	logger.Info("  POST   /schedule-templates  - Create schedule template (coordinator)")
+	logger.Info("  PUT    /schedule-templates/:id - Update schedule template (coordinator)")
+	logger.Info("  POST   /schedule-templates/:id/exceptions - Skip a template date (coordinator)")
+	logger.Info("  DELETE /schedule-templates/:id/exceptions/:exceptionId - Restore a skipped date (coordinator)")
+	logger.Info("  POST   /schedule-templates/generate - Generate schedules from templates (coordinator)")
+	logger.Info("  GET    /clients             - List clients (coordinator)")
+	logger.Info("  GET    /clients/:id         - Get client profile (coordinator)")
+	logger.Info("  POST   /clients             - Create client (coordinator)")
+	logger.Info("  PUT    /clients/:id         - Update client (coordinator)")
+	logger.Info("  GET    /clients/:id/care-plan - Get client's care-plan tasks (coordinator)")
+	logger.Info("  POST   /clients/:id/care-plan - Add care-plan task (coordinator)")
+	logger.Info("  PUT    /clients/:id/care-plan/:taskId - Update care-plan task (coordinator)")
+	logger.Info("  DELETE /clients/:id/care-plan/:taskId - Deactivate care-plan task (coordinator)")
+	logger.Info("  GET    /caregivers          - List caregivers (coordinator)")
+	logger.Info("  GET    /caregivers/:id      - Get caregiver by ID (coordinator)")
+	logger.Info("  POST   /caregivers          - Create caregiver (coordinator)")
+	logger.Info("  PUT    /caregivers/:id      - Update caregiver (coordinator)")
+	logger.Info("  DELETE /caregivers/:id      - Deactivate caregiver (coordinator)")
+	logger.Info("  GET    /evv/export          - Export EVV visit data as JSON or CSV (coordinator)")
+	logger.Info("  GET    /stats               - Get dashboard statistics")
 
 	if err := router.Run(":" + port); err != nil {
 		logger.WithError(err).Fatal("Failed to start server")
@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"visit-tracker-api/config"
+	"visit-tracker-api/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// claimsContextKey is the gin context key holding the authenticated user's claims
+const claimsContextKey = "auth_claims"
+
+// AuthConfig holds the settings used to sign and verify access tokens
+type AuthConfig struct {
+	Secret   []byte
+	TokenTTL time.Duration
+}
+
+// Claims are the JWT claims issued at login
+type Claims struct {
+	UserID      int    `json:"uid"`
+	Role        string `json:"role"`
+	CaregiverID *int   `json:"caregiver_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// NewAuthConfig builds the token settings from the configuration. Without a JWT secret
+// a random one is generated, so tokens do not survive restarts.
+func NewAuthConfig(settings config.AuthConfig, logger *logrus.Logger) AuthConfig {
+	cfg := AuthConfig{Secret: []byte(settings.JWTSecret), TokenTTL: settings.TokenTTL}
+	if settings.JWTSecret != "" {
+		return cfg
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		logger.WithError(err).Fatal("Failed to generate JWT secret")
+	}
+	cfg.Secret = []byte(hex.EncodeToString(random))
+	logger.Warn("JWT_SECRET is not set; using a random secret, issued tokens will be invalid after restart")
+	return cfg
+}
+
+// GenerateToken issues a signed access token for a user
+func GenerateToken(cfg AuthConfig, user models.User) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(cfg.TokenTTL)
+
+	claims := Claims{
+		UserID:      user.ID,
+		Role:        user.Role,
+		CaregiverID: user.CaregiverID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(cfg.Secret)
+	return token, expiresAt, err
+}
+
+// UserLookup reads the current state of the account a token was issued to
+type UserLookup interface {
+	// GetUser returns a user, or sql.ErrNoRows when it does not exist
+	GetUser(id int) (models.User, error)
+}
+
+// Auth validates the bearer token on each request and stores its claims in the context.
+// The account is looked up on every request, so deactivating a user revokes their
+// tokens at once and a role change takes effect on their next request.
+func Auth(cfg AuthConfig, users UserLookup) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		tokenString, found := strings.CutPrefix(header, "Bearer ")
+		if !found || tokenString == "" {
+			abortWithError(c, ErrUnauthorized, "Missing bearer token")
+			return
+		}
+
+		claims := &Claims{}
+		_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+			return cfg.Secret, nil
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+		if err != nil {
+			message := "Invalid token"
+			if errors.Is(err, jwt.ErrTokenExpired) {
+				message = "Token expired"
+			}
+			abortWithError(c, ErrUnauthorized, message)
+			return
+		}
+
+		user, err := users.GetUser(claims.UserID)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && !user.Active) {
+			abortWithError(c, ErrUnauthorized, "Account is deactivated")
+			return
+		}
+		if err != nil {
+			apiErr := *ErrInternalServer
+			apiErr.Err = err
+			c.Error(&apiErr)
+			c.Abort()
+			return
+		}
+		claims.Role = user.Role
+		claims.CaregiverID = user.CaregiverID
+
+		c.Set(claimsContextKey, claims)
+		c.Next()
+	}
+}
+
+// RequireRole rejects requests whose authenticated user has none of the given roles
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := CurrentClaims(c)
+		if !ok {
+			abortWithError(c, ErrUnauthorized, "Authentication required")
+			return
+		}
+
+		for _, role := range roles {
+			if claims.Role == role {
+				c.Next()
+				return
+			}
+		}
+
+		abortWithError(c, ErrForbidden, "Role "+claims.Role+" cannot access this resource")
+	}
+}
+
+// CurrentClaims returns the claims of the authenticated user, if any
+func CurrentClaims(c *gin.Context) (*Claims, bool) {
+	value, exists := c.Get(claimsContextKey)
+	if !exists {
+		return nil, false
+	}
+	claims, ok := value.(*Claims)
+	return claims, ok
+}
+
+// abortWithError stops the handler chain with a copy of a common API error
+func abortWithError(c *gin.Context, base *APIError, details string) {
+	apiErr := *base
+	apiErr.Details = details
+	c.Error(&apiErr)
+	c.Abort()
+}
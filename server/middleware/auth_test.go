@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"visit-tracker-api/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeUsers is a UserLookup over a fixed set of accounts
+type fakeUsers map[int]models.User
+
+func (f fakeUsers) GetUser(id int) (models.User, error) {
+	user, ok := f[id]
+	if !ok {
+		return user, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func TestAuthChecksTheCurrentAccount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := AuthConfig{Secret: []byte("test-secret"), TokenTTL: time.Hour}
+	issued := models.User{ID: 1, Role: "coordinator", Active: true}
+
+	tests := []struct {
+		name       string
+		current    fakeUsers
+		wantStatus int
+		wantRole   string
+	}{
+		{
+			name:       "active account",
+			current:    fakeUsers{1: issued},
+			wantStatus: http.StatusOK,
+			wantRole:   "coordinator",
+		},
+		{
+			name:       "deactivated account",
+			current:    fakeUsers{1: {ID: 1, Role: "coordinator", Active: false}},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "deleted account",
+			current:    fakeUsers{},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "demoted since the token was issued",
+			current:    fakeUsers{1: {ID: 1, Role: "caregiver", Active: true}},
+			wantStatus: http.StatusOK,
+			wantRole:   "caregiver",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, _, err := GenerateToken(cfg, issued)
+			if err != nil {
+				t.Fatalf("GenerateToken: %v", err)
+			}
+
+			var role string
+			router := gin.New()
+			logger := logrus.New()
+			logger.SetOutput(io.Discard)
+			router.Use(ErrorHandlerMiddleware(logger))
+			router.GET("/", Auth(cfg, tt.current), func(c *gin.Context) {
+				claims, _ := CurrentClaims(c)
+				role = claims.Role
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if role != tt.wantRole {
+				t.Errorf("role = %q, want %q", role, tt.wantRole)
+			}
+		})
+	}
+}
@@ -58,6 +58,7 @@ var (
 	ErrUnauthorized   = NewAPIError("UNAUTHORIZED", "Unauthorized", http.StatusUnauthorized, nil)
 	ErrForbidden      = NewAPIError("FORBIDDEN", "Forbidden", http.StatusForbidden, nil)
 	ErrValidation     = NewAPIError("VALIDATION_ERROR", "Validation failed", http.StatusBadRequest, nil)
+	ErrConflict       = NewAPIError("CONFLICT", "Resource conflict", http.StatusConflict, nil)
 )
 
 // ErrorHandlerMiddleware handles panics and errors
@@ -138,5 +139,5 @@ func ErrorHandlerMiddleware(logger *logrus.Logger) gin.HandlerFunc {
 
 // Helper function to get current timestamp
 func getCurrentTimestamp() string {
-	return time.Now().Format("2006-01-02T15:04:05Z07:00")
+	return time.Now().UTC().Format("2006-01-02T15:04:05Z07:00")
 } 
\ No newline at end of file
@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"visit-tracker-api/config"
+	"visit-tracker-api/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header that makes a mutating request safe to retry
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed from an earlier request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// Errors returned when an idempotency key cannot be used
+var (
+	ErrIdempotencyKeyReused     = NewAPIError("IDEMPOTENCY_KEY_REUSED", "Idempotency key was already used for a different request", http.StatusUnprocessableEntity, nil)
+	ErrIdempotencyKeyInProgress = NewAPIError("IDEMPOTENCY_KEY_IN_PROGRESS", "A request with this idempotency key is still being processed", http.StatusConflict, nil)
+)
+
+// IdempotencyStore remembers the requests made under each user's idempotency keys.
+// The SQL store implements it.
+type IdempotencyStore interface {
+	// ClaimIdempotencyKey reserves a user's key for a request, first forgetting the key if
+	// it was claimed before expiredBefore. When the key is in use it returns the earlier
+	// record and false instead.
+	ClaimIdempotencyKey(record models.IdempotencyRecord, expiredBefore time.Time) (models.IdempotencyRecord, bool, error)
+	// CompleteIdempotencyKey stores the response to a claimed key's request
+	CompleteIdempotencyKey(id int, response models.IdempotentResponse) error
+	// ReleaseIdempotencyKey forgets a claimed key, so the request can be retried under it
+	ReleaseIdempotencyKey(id int) error
+	// PurgeIdempotencyKeys forgets keys claimed before the cutoff, returning how many
+	PurgeIdempotencyKeys(before time.Time) (int64, error)
+}
+
+// Idempotency makes POST, PUT, PATCH and DELETE requests that carry an Idempotency-Key
+// header safe to retry. The first request under a key runs normally and its successful
+// response is stored; a retry with the same key, path and body gets that response back
+// without running again. Reusing a key for a different request returns 422, and a retry
+// while the first request is still running returns 409. Error responses are not stored,
+// so a failed request, including one whose handler panicked, can be retried under the
+// same key. Keys belong to the
+// authenticated user, so this must run after Auth.
+func Idempotency(cfg config.IdempotencyConfig, store IdempotencyStore, logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || !isMutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			abortWithError(c, ErrValidation, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		claims, ok := CurrentClaims(c)
+		if !ok {
+			abortWithError(c, ErrUnauthorized, "Authentication required")
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			body, _ = io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		path := c.Request.URL.RequestURI()
+		fingerprint := requestFingerprint(c.Request.Method, path, body)
+		record, claimed, err := store.ClaimIdempotencyKey(models.IdempotencyRecord{
+			UserID:      claims.UserID,
+			Key:         key,
+			Method:      c.Request.Method,
+			Path:        path,
+			Fingerprint: fingerprint,
+		}, time.Now().Add(-cfg.TTL))
+		if err != nil {
+			apiErr := *ErrInternalServer
+			apiErr.Err = err
+			c.Error(&apiErr)
+			c.Abort()
+			return
+		}
+		if !claimed {
+			replayIdempotentRequest(c, record, fingerprint)
+			return
+		}
+
+		logFailure := func(err error, message string) {
+			logger.WithFields(logrus.Fields{
+				"request_id":      c.GetString("request_id"),
+				"user_id":         claims.UserID,
+				"idempotency_key": key,
+				"error":           err.Error(),
+			}).Error(message)
+		}
+
+		writer := &responseWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+
+		// Unless the response is stored, release the key once the request is over, also
+		// when the handler panics, so the request can be retried under it
+		stored := false
+		defer func() {
+			c.Writer = writer.ResponseWriter
+			if stored {
+				return
+			}
+			if err := store.ReleaseIdempotencyKey(record.ID); err != nil {
+				logFailure(err, "Failed to release idempotency key")
+			}
+		}()
+
+		c.Next()
+
+		// Errors reported with c.Error are written after this middleware returns, so only
+		// responses the handler wrote itself can be stored
+		status := writer.Status()
+		if !writer.Written() || status >= http.StatusBadRequest {
+			return
+		}
+		err = store.CompleteIdempotencyKey(record.ID, models.IdempotentResponse{
+			StatusCode:  status,
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		})
+		if err != nil {
+			logFailure(err, "Failed to store idempotent response")
+			return
+		}
+		stored = true
+	}
+}
+
+// StartIdempotencyKeyCleanup forgets expired idempotency keys now and then every hour
+func StartIdempotencyKeyCleanup(cfg config.IdempotencyConfig, store IdempotencyStore, logger *logrus.Logger) {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			if purged, err := store.PurgeIdempotencyKeys(time.Now().Add(-cfg.TTL)); err != nil {
+				logger.WithError(err).Error("Failed to purge expired idempotency keys")
+			} else if purged > 0 {
+				logger.WithField("purged", purged).Info("Expired idempotency keys purged")
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// replayIdempotentRequest answers a retry with the stored response, or rejects it when
+// the key was used for a different request or the first one has not finished
+func replayIdempotentRequest(c *gin.Context, record models.IdempotencyRecord, fingerprint string) {
+	switch {
+	case record.Fingerprint != fingerprint:
+		abortWithError(c, ErrIdempotencyKeyReused,
+			"Key was first used for "+record.Method+" "+record.Path+"; retry with the original request or use a new key")
+	case record.Response == nil:
+		abortWithError(c, ErrIdempotencyKeyInProgress, "Retry once the original request has completed")
+	default:
+		c.Header(IdempotentReplayedHeader, "true")
+		c.Data(record.Response.StatusCode, record.Response.ContentType, record.Response.Body)
+		c.Abort()
+	}
+}
+
+// requestFingerprint hashes what identifies a request. JSON bodies are compared by
+// content, so retries that re-encode the same values with different spacing or key
+// order still match.
+func requestFingerprint(method, path string, body []byte) string {
+	var decoded interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err == nil {
+		if canonical, err := json.Marshal(decoded); err == nil {
+			body = canonical
+		}
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// isMutatingMethod reports whether requests with the method can change server state
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"visit-tracker-api/config"
+	"visit-tracker-api/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeIdempotencyStore is an IdempotencyStore over one user's keys in memory
+type fakeIdempotencyStore struct {
+	records map[string]models.IdempotencyRecord
+	nextID  int
+}
+
+func newFakeIdempotencyStore() *fakeIdempotencyStore {
+	return &fakeIdempotencyStore{records: map[string]models.IdempotencyRecord{}}
+}
+
+func (f *fakeIdempotencyStore) ClaimIdempotencyKey(record models.IdempotencyRecord, expiredBefore time.Time) (models.IdempotencyRecord, bool, error) {
+	if existing, ok := f.records[record.Key]; ok {
+		return existing, false, nil
+	}
+	f.nextID++
+	record.ID = f.nextID
+	f.records[record.Key] = record
+	return record, true, nil
+}
+
+func (f *fakeIdempotencyStore) CompleteIdempotencyKey(id int, response models.IdempotentResponse) error {
+	for key, record := range f.records {
+		if record.ID == id {
+			record.Response = &response
+			f.records[key] = record
+		}
+	}
+	return nil
+}
+
+func (f *fakeIdempotencyStore) ReleaseIdempotencyKey(id int) error {
+	for key, record := range f.records {
+		if record.ID == id {
+			delete(f.records, key)
+		}
+	}
+	return nil
+}
+
+func (f *fakeIdempotencyStore) PurgeIdempotencyKeys(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// idempotentRequest is one request of a test and the response expected for it
+type idempotentRequest struct {
+	path         string
+	key          string
+	body         string
+	wantStatus   int
+	wantReplayed bool
+}
+
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		running   string // a key already claimed for the first request, still being processed
+		requests  []idempotentRequest
+		wantCalls int
+		wantKeys  int
+	}{
+		{
+			name: "replays the stored response to a retry",
+			requests: []idempotentRequest{
+				{path: "/ok", key: "k1", body: `{"a":1,"b":2}`, wantStatus: http.StatusCreated},
+				{path: "/ok", key: "k1", body: `{"b": 2, "a": 1}`, wantStatus: http.StatusCreated, wantReplayed: true},
+			},
+			wantCalls: 1,
+			wantKeys:  1,
+		},
+		{
+			name: "rejects a key reused for a different request",
+			requests: []idempotentRequest{
+				{path: "/ok", key: "k1", body: `{"a":1}`, wantStatus: http.StatusCreated},
+				{path: "/ok", key: "k1", body: `{"a":2}`, wantStatus: http.StatusUnprocessableEntity},
+			},
+			wantCalls: 1,
+			wantKeys:  1,
+		},
+		{
+			name:    "rejects a retry while the first request is running",
+			running: "k1",
+			requests: []idempotentRequest{
+				{path: "/ok", key: "k1", body: `{"a":1}`, wantStatus: http.StatusConflict},
+			},
+			wantCalls: 0,
+			wantKeys:  1,
+		},
+		{
+			name: "releases the key after an error response",
+			requests: []idempotentRequest{
+				{path: "/fail", key: "k1", body: `{"a":1}`, wantStatus: http.StatusBadRequest},
+				{path: "/fail", key: "k1", body: `{"a":1}`, wantStatus: http.StatusBadRequest},
+			},
+			wantCalls: 2,
+			wantKeys:  0,
+		},
+		{
+			name: "releases the key when the handler panics",
+			requests: []idempotentRequest{
+				{path: "/panic", key: "k1", body: `{"a":1}`, wantStatus: http.StatusInternalServerError},
+				{path: "/panic", key: "k1", body: `{"a":1}`, wantStatus: http.StatusInternalServerError},
+			},
+			wantCalls: 2,
+			wantKeys:  0,
+		},
+		{
+			name: "runs every request without a key",
+			requests: []idempotentRequest{
+				{path: "/ok", body: `{"a":1}`, wantStatus: http.StatusCreated},
+				{path: "/ok", body: `{"a":1}`, wantStatus: http.StatusCreated},
+			},
+			wantCalls: 2,
+			wantKeys:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := newFakeIdempotencyStore()
+			if tt.running != "" {
+				keys.ClaimIdempotencyKey(models.IdempotencyRecord{
+					UserID:      1,
+					Key:         tt.running,
+					Method:      http.MethodPost,
+					Path:        "/ok",
+					Fingerprint: requestFingerprint(http.MethodPost, "/ok", []byte(`{"a":1}`)),
+				}, time.Time{})
+			}
+
+			logger := logrus.New()
+			logger.SetOutput(io.Discard)
+			calls := 0
+
+			router := gin.New()
+			router.Use(ErrorHandlerMiddleware(logger))
+			router.Use(gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, _ any) {
+				c.AbortWithStatus(http.StatusInternalServerError)
+			}))
+			router.Use(func(c *gin.Context) {
+				c.Set(claimsContextKey, &Claims{UserID: 1, Role: models.RoleCaregiver})
+			})
+			router.Use(Idempotency(config.IdempotencyConfig{TTL: time.Hour}, keys, logger))
+			router.POST("/ok", func(c *gin.Context) {
+				calls++
+				c.JSON(http.StatusCreated, gin.H{"call": calls})
+			})
+			router.POST("/fail", func(c *gin.Context) {
+				calls++
+				c.Error(ErrValidation)
+			})
+			router.POST("/panic", func(c *gin.Context) {
+				calls++
+				panic("handler failed")
+			})
+
+			var first string
+			for i, request := range tt.requests {
+				req := httptest.NewRequest(http.MethodPost, request.path, strings.NewReader(request.body))
+				req.Header.Set("Content-Type", "application/json")
+				if request.key != "" {
+					req.Header.Set(IdempotencyKeyHeader, request.key)
+				}
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+
+				if rec.Code != request.wantStatus {
+					t.Fatalf("request %d: status = %d, want %d", i, rec.Code, request.wantStatus)
+				}
+				replayed := rec.Header().Get(IdempotentReplayedHeader) == "true"
+				if replayed != request.wantReplayed {
+					t.Errorf("request %d: replayed = %v, want %v", i, replayed, request.wantReplayed)
+				}
+				if i == 0 {
+					first = rec.Body.String()
+				} else if request.wantReplayed && rec.Body.String() != first {
+					t.Errorf("request %d: body = %s, want the first response %s", i, rec.Body.String(), first)
+				}
+			}
+
+			if calls != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", calls, tt.wantCalls)
+			}
+			if len(keys.records) != tt.wantKeys {
+				t.Errorf("%d keys held, want %d", len(keys.records), tt.wantKeys)
+			}
+		})
+	}
+}
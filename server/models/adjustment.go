@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// Reason codes for correcting a visit's clock-in or clock-out. They follow the manual
+// edit reasons state EVV aggregators accept and are reported with the visit in EVV exports.
+const (
+	AdjustmentForgotClockIn  = "forgot_clock_in"
+	AdjustmentForgotClockOut = "forgot_clock_out"
+	AdjustmentDeviceIssue    = "device_issue"
+	AdjustmentNoConnectivity = "no_connectivity"
+	AdjustmentIncorrectTime  = "incorrect_time"
+	AdjustmentOther          = "other" // requires a note
+)
+
+// AdjustmentReason describes a reason code for visit adjustments
+type AdjustmentReason struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// AdjustmentReasons lists the reason codes a visit adjustment may give
+var AdjustmentReasons = []AdjustmentReason{
+	{AdjustmentForgotClockIn, "Caregiver did not clock in"},
+	{AdjustmentForgotClockOut, "Caregiver did not clock out"},
+	{AdjustmentDeviceIssue, "Mobile device or application malfunction"},
+	{AdjustmentNoConnectivity, "No cellular or internet service at the service location"},
+	{AdjustmentIncorrectTime, "Clock-in or clock-out recorded at the wrong time"},
+	{AdjustmentOther, "Other, explained in the note"},
+}
+
+// IsAdjustmentReason reports whether code is a known adjustment reason code
+func IsAdjustmentReason(code string) bool {
+	for _, reason := range AdjustmentReasons {
+		if reason.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// VisitAdjustment records a correction to a visit's clock-in or clock-out times. The
+// original times are the ones the adjustment replaced and are never changed.
+type VisitAdjustment struct {
+	ID                int        `json:"id" db:"id"`
+	VisitID           int        `json:"visit_id" db:"visit_id"`
+	ScheduleID        int        `json:"schedule_id" db:"schedule_id"`
+	ReasonCode        string     `json:"reason_code" db:"reason_code"`
+	Note              string     `json:"note,omitempty" db:"note"`
+	OriginalStartTime *time.Time `json:"original_start_time,omitempty" db:"original_start_time"`
+	OriginalEndTime   *time.Time `json:"original_end_time,omitempty" db:"original_end_time"` // empty for a forgotten clock-out
+	AdjustedStartTime *time.Time `json:"adjusted_start_time,omitempty" db:"adjusted_start_time"`
+	AdjustedEndTime   *time.Time `json:"adjusted_end_time,omitempty" db:"adjusted_end_time"`
+	AdjustedBy        *int       `json:"adjusted_by,omitempty" db:"adjusted_by"` // user ID
+	ActorRole         string     `json:"actor_role" db:"actor_role"`
+	IPAddress         string     `json:"ip_address,omitempty" db:"ip_address"`
+	RequestID         string     `json:"request_id,omitempty" db:"request_id"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	// ClosedTasks are the pending tasks marked not_completed when the adjustment completed the visit
+	ClosedTasks []Task `json:"closed_tasks,omitempty" db:"-"`
+}
+
+// VisitAdjustmentRequest represents the request payload for correcting a visit's times.
+// Omitted times are left as they are.
+type VisitAdjustmentRequest struct {
+	StartTime  *time.Time `json:"start_time"`
+	EndTime    *time.Time `json:"end_time"` // completes a visit still in progress
+	ReasonCode string     `json:"reason_code" binding:"required"`
+	Note       string     `json:"note,omitempty" binding:"max=1000"`
+}
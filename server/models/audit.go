@@ -0,0 +1,42 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit event actions
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// AuditEvent records one write to a row: who made it, from where, and the row before and
+// after. Events form a hash chain, each hash covering the event and the hash before it,
+// so changing or removing an event is detected.
+type AuditEvent struct {
+	ID          int             `json:"id" db:"id"`
+	Entity      string          `json:"entity" db:"entity"` // table written to, e.g. tasks
+	EntityID    int             `json:"entity_id" db:"entity_id"`
+	Action      string          `json:"action" db:"action"`                         // create, update or delete
+	ActorUserID *int            `json:"actor_user_id,omitempty" db:"actor_user_id"` // empty for system jobs
+	ActorRole   string          `json:"actor_role" db:"actor_role"`                 // caregiver, coordinator, admin or system
+	RequestID   string          `json:"request_id,omitempty" db:"request_id"`
+	IPAddress   string          `json:"ip_address,omitempty" db:"ip_address"`
+	Before      json.RawMessage `json:"before,omitempty" db:"before_json" swaggertype:"object"` // empty for creates
+	After       json.RawMessage `json:"after,omitempty" db:"after_json" swaggertype:"object"`   // empty for deletes
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	PrevHash    string          `json:"prev_hash" db:"prev_hash"` // empty for the first event
+	Hash        string          `json:"hash" db:"hash"`
+}
+
+// AuditVerification reports whether the audit log's hash chain is intact
+type AuditVerification struct {
+	Valid          bool      `json:"valid"`
+	Checked        int       `json:"checked"`                    // events verified before the first invalid one
+	FirstInvalidID *int      `json:"first_invalid_id,omitempty"` // first event that was changed or does not follow its predecessor
+	Message        string    `json:"message,omitempty"`
+	LastHash       string    `json:"last_hash,omitempty"` // hash of the last verified event, to compare with a saved copy
+	CheckedAt      time.Time `json:"checked_at"`
+}
@@ -0,0 +1,39 @@
+package models
+
+// Calendar views
+const (
+	CalendarWeek  = "week"
+	CalendarMonth = "month"
+)
+
+// CalendarDay counts the schedules starting on one date
+type CalendarDay struct {
+	Date     string         `json:"date"` // YYYY-MM-DD
+	Total    int            `json:"total"`
+	ByStatus map[string]int `json:"by_status"` // every schedule status, zero when none
+}
+
+// Calendar counts the schedules of each day in a week or month, by the date each shift
+// starts on in its client's time zone
+type Calendar struct {
+	View     string         `json:"view"`
+	From     string         `json:"from"` // first day, YYYY-MM-DD
+	To       string         `json:"to"`   // last day, inclusive
+	Total    int            `json:"total"`
+	ByStatus map[string]int `json:"by_status"`
+	Days     []CalendarDay  `json:"days"`
+}
+
+// NewCalendarDay returns a day with no schedules
+func NewCalendarDay(date string) CalendarDay {
+	return CalendarDay{Date: date, ByStatus: StatusCounts()}
+}
+
+// StatusCounts returns a count of zero for every schedule status
+func StatusCounts() map[string]int {
+	counts := make(map[string]int, len(ScheduleStatuses))
+	for _, status := range ScheduleStatuses {
+		counts[status] = 0
+	}
+	return counts
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// How often a care-plan task is added to a client's shifts. Daily and weekly tasks go on
+// the first shift created in each day or week (Monday to Sunday) in the client's time
+// zone, unless that shift is cancelled.
+const (
+	FrequencyEveryVisit = "every_visit"
+	FrequencyDaily      = "daily"
+	FrequencyWeekly     = "weekly"
+)
+
+// IsCarePlanFrequency reports whether frequency is a known care-plan task frequency
+func IsCarePlanFrequency(frequency string) bool {
+	return frequency == FrequencyEveryVisit || frequency == FrequencyDaily || frequency == FrequencyWeekly
+}
+
+// CarePlanTask is a task from a client's care plan, copied onto their shifts as they are
+// created or generated from templates
+type CarePlanTask struct {
+	ID          int       `json:"id" db:"id"`
+	ClientID    int       `json:"client_id" db:"client_id"`
+	Description string    `json:"description" db:"description"`
+	Frequency   string    `json:"frequency" db:"frequency"` // every_visit, daily or weekly
+	Required    bool      `json:"required" db:"required"`   // optional tasks may be left undone
+	Active      bool      `json:"active" db:"active"`       // inactive tasks are no longer added to new shifts
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CarePlanTaskRequest is the payload for adding or changing a care-plan task
+type CarePlanTaskRequest struct {
+	Description string `json:"description" binding:"required"`
+	Frequency   string `json:"frequency"` // every_visit (default), daily or weekly
+	Required    *bool  `json:"required"`  // defaults to true
+	Active      *bool  `json:"active"`    // defaults to true
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// EVVVisit is a clock-in and clock-out with the schedule, client and caregiver details
+// an EVV export reports
+type EVVVisit struct {
+	VisitID          int
+	ScheduleID       int
+	ServiceCode      string
+	Status           string
+	ClientID         int
+	ClientName       string
+	ClientMedicaidID string
+	Address          string
+	CaregiverID      *int
+	CaregiverName    string
+	StartTime        *time.Time
+	EndTime          *time.Time
+	StartLatitude    *float64
+	StartLongitude   *float64
+	EndLatitude      *float64
+	EndLongitude     *float64
+	StartGeofence    string // inside, outside or overridden
+	EndGeofence      string
+	AdjustmentReason string // reason code of the latest correction to the visit's times, if any
+	Timezone         string // client's IANA time zone, or the agency's; dates and times are exported in it
+}
+
+// EVVExport is an EVV export laid out for an aggregator. Each record maps the layout's
+// column headers to values.
+type EVVExport struct {
+	Layout      string              `json:"layout"`
+	From        string              `json:"from"` // first service date, YYYY-MM-DD
+	To          string              `json:"to"`   // last service date, YYYY-MM-DD
+	GeneratedAt time.Time           `json:"generated_at"`
+	Total       int                 `json:"total"`
+	Valid       int                 `json:"valid"`
+	Invalid     int                 `json:"invalid"`
+	Columns     []string            `json:"columns"`
+	Records     []map[string]string `json:"records"`
+	Errors      []EVVVisitErrors    `json:"errors"` // every visit that failed validation
+}
+
+// EVVVisitErrors lists why a visit cannot be submitted to the aggregator
+type EVVVisitErrors struct {
+	VisitID    int      `json:"visit_id"`
+	ScheduleID int      `json:"schedule_id"`
+	Errors     []string `json:"errors"`
+}
@@ -0,0 +1,27 @@
+package models
+
+// Reason codes a caregiver may give to clock in or out outside the geofence when it
+// rejects out-of-range locations. Coordinators and admins may give any reason.
+const (
+	GeofenceOverrideGPSInaccurate  = "gps_inaccurate"
+	GeofenceOverrideClientOffSite  = "client_off_site" // care given away from the client's home, e.g. at an appointment
+	GeofenceOverrideAddressChanged = "address_changed" // the client has moved and the registry is out of date
+)
+
+// GeofenceOverrideReasons lists the reason codes caregivers may override the geofence with
+var GeofenceOverrideReasons = []string{
+	GeofenceOverrideGPSInaccurate,
+	GeofenceOverrideClientOffSite,
+	GeofenceOverrideAddressChanged,
+}
+
+// IsGeofenceOverrideReason reports whether code is a reason code caregivers may override
+// the geofence with
+func IsGeofenceOverrideReason(code string) bool {
+	for _, reason := range GeofenceOverrideReasons {
+		if reason == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// IdempotencyRecord is a request made under an idempotency key
+type IdempotencyRecord struct {
+	ID          int
+	UserID      int
+	Key         string
+	Method      string
+	Path        string
+	Fingerprint string              // hash of the method, path and body
+	Response    *IdempotentResponse // nil while the request is being processed
+	CreatedAt   time.Time
+}
+
+// IdempotentResponse is the stored response replayed to retries
+type IdempotentResponse struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
@@ -0,0 +1,111 @@
+package models
+
+import "time"
+
+// Incident categories
+const (
+	IncidentFall            = "fall"
+	IncidentMedicationError = "medication_error"
+	IncidentInjury          = "injury"
+	IncidentBehavioral      = "behavioral"
+	IncidentOther           = "other"
+)
+
+// IncidentCategories lists every incident category
+var IncidentCategories = []string{
+	IncidentFall, IncidentMedicationError, IncidentInjury, IncidentBehavioral, IncidentOther,
+}
+
+// Incident severities
+const (
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// IncidentSeverities lists every incident severity, least severe first
+var IncidentSeverities = []string{SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// Incident follow-up statuses
+const (
+	IncidentOpen     = "open"      // filed, not yet looked at by a coordinator
+	IncidentInReview = "in_review" // a coordinator is following it up
+	IncidentResolved = "resolved"  // follow-up finished; requires follow_up_notes
+)
+
+// IncidentStatuses lists every incident follow-up status
+var IncidentStatuses = []string{IncidentOpen, IncidentInReview, IncidentResolved}
+
+// OpenIncidentStatuses lists the statuses shown in the coordinator queue
+var OpenIncidentStatuses = []string{IncidentOpen, IncidentInReview}
+
+// contains reports whether value is one of values
+func contains(values []string, value string) bool {
+	for _, known := range values {
+		if known == value {
+			return true
+		}
+	}
+	return false
+}
+
+// IsIncidentCategory reports whether category is a known incident category
+func IsIncidentCategory(category string) bool {
+	return contains(IncidentCategories, category)
+}
+
+// IsIncidentSeverity reports whether severity is a known incident severity
+func IsIncidentSeverity(severity string) bool {
+	return contains(IncidentSeverities, severity)
+}
+
+// IsIncidentStatus reports whether status is a known incident follow-up status
+func IsIncidentStatus(status string) bool {
+	return contains(IncidentStatuses, status)
+}
+
+// Incident is a fall, medication error, injury or other event during a visit that has
+// to be escalated and followed up
+type Incident struct {
+	ID            int        `json:"id" db:"id"`
+	ScheduleID    int        `json:"schedule_id" db:"schedule_id"`
+	ClientID      int        `json:"client_id" db:"client_id"`
+	ClientName    string     `json:"client_name,omitempty" db:"client_name"`
+	CaregiverID   *int       `json:"caregiver_id,omitempty" db:"caregiver_id"`
+	Category      string     `json:"category" db:"category"`
+	Severity      string     `json:"severity" db:"severity"`
+	Status        string     `json:"status" db:"status"`
+	Description   string     `json:"description" db:"description"`
+	OccurredAt    time.Time  `json:"occurred_at" db:"occurred_at"`
+	Injury        bool       `json:"injury" db:"injury"`                             // whether anyone was hurt
+	InjuryDetails string     `json:"injury_details,omitempty" db:"injury_details"`   // required when injury is set
+	FollowUpNotes string     `json:"follow_up_notes,omitempty" db:"follow_up_notes"` // required to resolve
+	ReportedBy    *int       `json:"reported_by,omitempty" db:"reported_by"`         // user ID
+	ReporterRole  string     `json:"reporter_role" db:"reporter_role"`
+	ResolvedBy    *int       `json:"resolved_by,omitempty" db:"resolved_by"` // user ID
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// IncidentRequest represents the request payload for filing an incident
+type IncidentRequest struct {
+	Category      string     `json:"category" binding:"required"`
+	Severity      string     `json:"severity" binding:"required"`
+	Description   string     `json:"description" binding:"required"`
+	OccurredAt    *time.Time `json:"occurred_at"` // defaults to now
+	Injury        bool       `json:"injury"`
+	InjuryDetails string     `json:"injury_details"`
+}
+
+// IncidentUpdateRequest represents the request payload for updating an incident.
+// Omitted fields are left as they are.
+type IncidentUpdateRequest struct {
+	Category      *string `json:"category"`
+	Severity      *string `json:"severity"`
+	Status        *string `json:"status"`
+	Injury        *bool   `json:"injury"`
+	InjuryDetails *string `json:"injury_details"`
+	FollowUpNotes *string `json:"follow_up_notes"`
+}
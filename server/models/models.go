@@ -4,17 +4,205 @@ import (
 	"time"
 )
 
+// User roles used for authorization
+const (
+	RoleCaregiver   = "caregiver"
+	RoleCoordinator = "coordinator"
+	RoleAdmin       = "admin"
+)
+
+// User represents an account that can sign in to the API
+type User struct {
+	ID           int       `json:"id" db:"id"`
+	Email        string    `json:"email" db:"email"`
+	PasswordHash string    `json:"-" db:"password_hash"`
+	Role         string    `json:"role" db:"role"`                                 // caregiver, coordinator, admin
+	CaregiverID  *int      `json:"caregiver_id,omitempty" db:"caregiver_id"` // set for caregiver accounts
+	Active       bool      `json:"active" db:"active"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// LoginRequest represents the request payload for signing in
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// LoginResponse represents a successful sign-in
+type LoginResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      User      `json:"user"`
+}
+
+// CreateUserRequest represents the request payload for creating a user account
+type CreateUserRequest struct {
+	Email       string `json:"email" binding:"required,email"`
+	Password    string `json:"password" binding:"required,min=8"`
+	Role        string `json:"role" binding:"required,oneof=caregiver coordinator admin"`
+	CaregiverID *int   `json:"caregiver_id,omitempty"` // required for caregiver accounts
+}
+
+// Caregiver represents a care worker who can be assigned to schedules
+type Caregiver struct {
+	ID        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Email     string    `json:"email" db:"email"`
+	Phone     string    `json:"phone,omitempty" db:"phone"`
+	Active    bool      `json:"active" db:"active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateCaregiverRequest represents the request payload for creating a caregiver
+type CreateCaregiverRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email" binding:"required,email"`
+	Phone string `json:"phone,omitempty"`
+}
+
+// UpdateCaregiverRequest represents the request payload for updating a caregiver
+type UpdateCaregiverRequest struct {
+	Name   string `json:"name" binding:"required"`
+	Email  string `json:"email" binding:"required,email"`
+	Phone  string `json:"phone,omitempty"`
+	Active *bool  `json:"active,omitempty"` // unchanged when omitted
+}
+
+// Client represents a care recipient and the home where visits take place
+type Client struct {
+	ID                int                `json:"id" db:"id"`
+	Name              string             `json:"name" db:"name"`
+	Address           string             `json:"address" db:"address"`
+	Latitude          float64            `json:"latitude" db:"latitude"`
+	Longitude         float64            `json:"longitude" db:"longitude"`
+	CarePlanNotes     string             `json:"care_plan_notes,omitempty" db:"care_plan_notes"`
+	MedicaidID        string             `json:"medicaid_id,omitempty" db:"medicaid_id"` // recipient identifier reported in EVV exports
+	Timezone          string             `json:"timezone,omitempty" db:"timezone"`       // IANA time zone; empty uses the agency's
+	Active            bool               `json:"active" db:"active"`
+	EmergencyContacts []EmergencyContact `json:"emergency_contacts"`
+	CreatedAt         time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// EmergencyContact represents a person to call about a client
+type EmergencyContact struct {
+	ID           int    `json:"id" db:"id"`
+	ClientID     int    `json:"client_id" db:"client_id"`
+	Name         string `json:"name" db:"name"`
+	Relationship string `json:"relationship,omitempty" db:"relationship"`
+	Phone        string `json:"phone" db:"phone"`
+}
+
+// EmergencyContactRequest represents an emergency contact in a client payload
+type EmergencyContactRequest struct {
+	Name         string `json:"name" binding:"required"`
+	Relationship string `json:"relationship,omitempty"`
+	Phone        string `json:"phone" binding:"required"`
+}
+
+// ClientRequest represents the request payload for creating or updating a client
+type ClientRequest struct {
+	Name              string                    `json:"name" binding:"required"`
+	Address           string                    `json:"address" binding:"required"`
+	Latitude          float64                   `json:"latitude" binding:"required"`
+	Longitude         float64                   `json:"longitude" binding:"required"`
+	CarePlanNotes     string                    `json:"care_plan_notes,omitempty"`
+	MedicaidID        string                    `json:"medicaid_id,omitempty"`
+	Timezone          string                    `json:"timezone,omitempty"` // IANA time zone, e.g. America/Chicago; empty uses the agency's
+	Active            *bool                     `json:"active,omitempty"` // unchanged when omitted, defaults to true on create
+	EmergencyContacts []EmergencyContactRequest `json:"emergency_contacts" binding:"dive"` // replaces existing contacts
+}
+
 // Schedule represents a caregiver's assigned shift
 type Schedule struct {
 	ID          int       `json:"id" db:"id"`
-	ClientName  string    `json:"client_name" db:"client_name"`
+	CaregiverID *int      `json:"caregiver_id,omitempty" db:"caregiver_id"`
+	ClientID    int       `json:"client_id" db:"client_id"`
+	ClientName  string    `json:"client_name" db:"client_name"` // from the client registry
 	ShiftStart  time.Time `json:"shift_start" db:"shift_start"`
 	ShiftEnd    time.Time `json:"shift_end" db:"shift_end"`
-	Latitude    float64   `json:"latitude" db:"latitude"`
-	Longitude   float64   `json:"longitude" db:"longitude"`
-	Status      string    `json:"status" db:"status"` // upcoming, in_progress, completed, missed
+	Timezone    string    `json:"timezone" db:"timezone"`   // client's IANA time zone, or the agency's
+	Latitude    float64   `json:"latitude" db:"latitude"`   // client's home, from the client registry
+	Longitude   float64   `json:"longitude" db:"longitude"` // client's home, from the client registry
+	Status      string    `json:"status" db:"status"` // see the Status constants: upcoming, late, in_progress, completed, missed, cancelled
+	ServiceCode string    `json:"service_code,omitempty" db:"service_code"` // procedure code billed for the visit, e.g. T1019
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+
+	CancellationReason string     `json:"cancellation_reason,omitempty" db:"cancellation_reason"`
+	CancelledAt        *time.Time `json:"cancelled_at,omitempty" db:"cancelled_at"`
+	TemplateID         *int       `json:"template_id,omitempty" db:"template_id"` // recurring template the shift was generated from
+}
+
+// ScheduleRequest represents the request payload for creating or editing a schedule.
+// On update, a nil Tasks list keeps the schedule's existing tasks.
+type ScheduleRequest struct {
+	ClientID    int       `json:"client_id" binding:"required"`
+	CaregiverID *int      `json:"caregiver_id"`
+	ShiftStart  time.Time `json:"shift_start" binding:"required"`
+	ShiftEnd    time.Time `json:"shift_end" binding:"required"`
+	ServiceCode string    `json:"service_code,omitempty" binding:"max=20"` // EVV_DEFAULT_SERVICE_CODE is exported when empty
+	Tasks       []string  `json:"tasks" binding:"omitempty,dive,required"`
+}
+
+// CancelScheduleRequest represents the request payload for cancelling a schedule
+type CancelScheduleRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// ScheduleTemplate represents a standing visit that recurs according to an iCalendar RRULE
+type ScheduleTemplate struct {
+	ID          int                         `json:"id" db:"id"`
+	ClientID    int                         `json:"client_id" db:"client_id"`
+	ClientName  string                      `json:"client_name" db:"client_name"` // from the client registry
+	Timezone    string                      `json:"timezone" db:"timezone"`       // client's IANA time zone, or the agency's; times of day are local to it
+	CaregiverID *int                        `json:"caregiver_id,omitempty" db:"caregiver_id"`
+	RRule       string                      `json:"rrule" db:"rrule"`           // e.g. FREQ=WEEKLY;BYDAY=MO,WE,FR
+	StartTime   string                      `json:"start_time" db:"start_time"` // local time of day, HH:MM
+	EndTime     string                      `json:"end_time" db:"end_time"`     // local time of day, HH:MM; earlier than start_time for overnight shifts
+	StartsOn    string                      `json:"starts_on" db:"starts_on"`   // first date of the recurrence, YYYY-MM-DD
+	EndsOn      *string                     `json:"ends_on,omitempty" db:"ends_on"`
+	Active      bool                        `json:"active" db:"active"`
+	Tasks       []string                    `json:"tasks"`
+	Exceptions  []ScheduleTemplateException `json:"exceptions"`
+	CreatedAt   time.Time                   `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time                   `json:"updated_at" db:"updated_at"`
+}
+
+// ScheduleTemplateException represents a date on which a template does not produce a shift
+type ScheduleTemplateException struct {
+	ID         int       `json:"id" db:"id"`
+	TemplateID int       `json:"template_id" db:"template_id"`
+	Date       string    `json:"date" db:"exception_date"` // YYYY-MM-DD
+	Reason     string    `json:"reason,omitempty" db:"reason"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// ScheduleTemplateRequest represents the request payload for creating or updating a schedule template
+type ScheduleTemplateRequest struct {
+	ClientID    int      `json:"client_id" binding:"required"`
+	CaregiverID *int     `json:"caregiver_id"`
+	RRule       string   `json:"rrule" binding:"required"`
+	StartTime   string   `json:"start_time" binding:"required"`
+	EndTime     string   `json:"end_time" binding:"required"`
+	StartsOn    string   `json:"starts_on" binding:"required"`
+	EndsOn      *string  `json:"ends_on"`
+	Active      *bool    `json:"active"`
+	Tasks       []string `json:"tasks" binding:"omitempty,dive,required"`
+}
+
+// TemplateExceptionRequest represents the request payload for skipping a template date
+type TemplateExceptionRequest struct {
+	Date   string `json:"date" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+// GenerateSchedulesResponse reports the outcome of materialising schedules from templates
+type GenerateSchedulesResponse struct {
+	Created    int       `json:"created"`
+	HorizonEnd time.Time `json:"horizon_end"`
 }
 
 // Task represents a care activity assigned to a schedule
@@ -24,6 +212,8 @@ type Task struct {
 	Description string `json:"description" db:"description"`
 	Status      string `json:"status" db:"status"` // pending, completed, not_completed
 	Reason      string `json:"reason,omitempty" db:"reason"` // reason if not completed
+	Required    bool   `json:"required" db:"required"` // optional tasks may be left undone
+	CarePlanTaskID *int `json:"care_plan_task_id,omitempty" db:"care_plan_task_id"` // care-plan task it was copied from
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -38,6 +228,15 @@ type Visit struct {
 	StartLng   *float64   `json:"start_lng,omitempty" db:"start_lng"`
 	EndLat     *float64   `json:"end_lat,omitempty" db:"end_lat"`
 	EndLng     *float64   `json:"end_lng,omitempty" db:"end_lng"`
+
+	// Geofence verification results, computed server-side for EVV audits
+	StartDistanceMeters *float64 `json:"start_distance_meters,omitempty" db:"start_distance_meters"`
+	StartGeofenceStatus string   `json:"start_geofence_status,omitempty" db:"start_geofence_status"` // inside, outside, overridden
+	StartOverrideReason string   `json:"start_override_reason,omitempty" db:"start_override_reason"`
+	EndDistanceMeters   *float64 `json:"end_distance_meters,omitempty" db:"end_distance_meters"`
+	EndGeofenceStatus   string   `json:"end_geofence_status,omitempty" db:"end_geofence_status"` // inside, outside, overridden
+	EndOverrideReason   string   `json:"end_override_reason,omitempty" db:"end_override_reason"`
+
 	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
 }
@@ -45,20 +244,40 @@ type Visit struct {
 // ScheduleWithTasks represents a schedule with its associated tasks
 type ScheduleWithTasks struct {
 	Schedule
-	Tasks []Task `json:"tasks"`
-	Visit *Visit `json:"visit,omitempty"`
+	Client *Client `json:"client,omitempty"`
+	Tasks  []Task  `json:"tasks"`
+	Visit  *Visit  `json:"visit,omitempty"`
+
+	Adjustments []VisitAdjustment `json:"adjustments"` // corrections to the visit's times, oldest first
+	Notes       []VisitNote       `json:"notes"`       // narrative notes about the visit, oldest first
+}
+
+// Geofence statuses recorded on a visit's start and end
+const (
+	GeofenceInside     = "inside"
+	GeofenceOutside    = "outside"
+	GeofenceOverridden = "overridden"
+)
+
+// GeofenceResult describes how a submitted location compares to the client's home
+type GeofenceResult struct {
+	Status         string  `json:"status"`
+	DistanceMeters float64 `json:"distance_meters"`
+	RadiusMeters   float64 `json:"radius_meters"`
 }
 
 // StartVisitRequest represents the request payload for starting a visit
 type StartVisitRequest struct {
-	Latitude  float64 `json:"latitude" binding:"required"`
-	Longitude float64 `json:"longitude" binding:"required"`
+	Latitude       float64 `json:"latitude" binding:"required"`
+	Longitude      float64 `json:"longitude" binding:"required"`
+	OverrideReason string  `json:"override_reason,omitempty"` // required to clock in outside the geofence when rejecting: a reason code, or any reason from a coordinator
 }
 
 // EndVisitRequest represents the request payload for ending a visit
 type EndVisitRequest struct {
-	Latitude  float64 `json:"latitude" binding:"required"`
-	Longitude float64 `json:"longitude" binding:"required"`
+	Latitude       float64 `json:"latitude" binding:"required"`
+	Longitude      float64 `json:"longitude" binding:"required"`
+	OverrideReason string  `json:"override_reason,omitempty"` // required to clock out outside the geofence when rejecting: a reason code, or any reason from a coordinator
 }
 
 // UpdateTaskRequest represents the request payload for updating a task
@@ -67,6 +286,27 @@ type UpdateTaskRequest struct {
 	Reason string `json:"reason,omitempty"`
 }
 
+// BulkTaskUpdate is one task's new status within a bulk update
+type BulkTaskUpdate struct {
+	TaskID int    `json:"task_id"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// BulkTaskUpdateRequest represents the request payload for updating several of a
+// schedule's tasks at once
+type BulkTaskUpdateRequest struct {
+	Tasks []BulkTaskUpdate `json:"tasks" binding:"required"`
+}
+
+// BulkTaskError describes why one item of a bulk task update was rejected
+type BulkTaskError struct {
+	Index   int    `json:"index"`
+	TaskID  int    `json:"task_id"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
 // Activity represents a care activity assigned to a schedule
 type Activity struct {
 	ID          int       `json:"id" db:"id"`
@@ -97,4 +337,19 @@ type StatsResponse struct {
 	MissedSchedules   int `json:"missed_schedules"`
 	UpcomingToday     int `json:"upcoming_today"`
 	CompletedToday    int `json:"completed_today"`
-} 
\ No newline at end of file
+}
+
+// Pagination describes one page of a list and how to fetch the next
+type Pagination struct {
+	Limit      int     `json:"limit"`
+	Total      int     `json:"total"`       // items matching the filters across every page
+	NextCursor *string `json:"next_cursor"` // pass as cursor to fetch the next page; null on the last page
+}
+
+// SchedulePage is a page of schedules as returned by GET /schedules
+type SchedulePage struct {
+	Data       []Schedule `json:"data"`
+	Pagination Pagination `json:"pagination"`
+	RequestID  string     `json:"request_id"`
+	Timestamp  string     `json:"timestamp"`
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Schedule statuses
+const (
+	StatusUpcoming   = "upcoming"    // scheduled, shift not yet started
+	StatusLate       = "late"        // shift start has passed without the visit starting
+	StatusInProgress = "in_progress" // caregiver has clocked in
+	StatusCompleted  = "completed"   // caregiver has clocked out
+	StatusMissed     = "missed"      // grace period passed without the visit starting
+	StatusCancelled  = "cancelled"   // called off by a coordinator
+)
+
+// ScheduleStatuses lists every schedule status, in the order a schedule moves through them
+var ScheduleStatuses = []string{
+	StatusUpcoming, StatusLate, StatusInProgress, StatusCompleted, StatusMissed, StatusCancelled,
+}
+
+// IsScheduleStatus reports whether status is a known schedule status
+func IsScheduleStatus(status string) bool {
+	for _, known := range ScheduleStatuses {
+		if known == status {
+			return true
+		}
+	}
+	return false
+}
+
+// scheduleTransitions lists the statuses each status may move to
+var scheduleTransitions = map[string][]string{
+	StatusUpcoming:   {StatusInProgress, StatusLate, StatusMissed, StatusCancelled},
+	StatusLate:       {StatusInProgress, StatusMissed, StatusCancelled},
+	StatusInProgress: {StatusCompleted},
+}
+
+// reconciledTransitions lists the further transitions allowed for a change a device
+// recorded offline before the schedule moved to its current status: a visit started on
+// time is still started when it syncs after the schedule was marked missed
+var reconciledTransitions = map[string][]string{
+	StatusMissed: {StatusInProgress},
+}
+
+// CanTransition reports whether a schedule may move from one status to another
+func CanTransition(from, to string) bool {
+	return isTransition(scheduleTransitions, from, to)
+}
+
+// CanReconcile reports whether a change recorded offline may still move a schedule from a
+// status it only reached after the change happened
+func CanReconcile(from, to string) bool {
+	return isTransition(reconciledTransitions, from, to)
+}
+
+// isTransition reports whether transitions lets from move to to
+func isTransition(transitions map[string][]string, from, to string) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStartable reports whether a visit may still be started from the status
+func IsStartable(status string) bool {
+	return CanTransition(status, StatusInProgress)
+}
+
+// TransitionError is returned when a schedule cannot move to the requested status
+type TransitionError struct {
+	From string
+	To   string
+}
+
+func (e *TransitionError) Error() string {
+	switch {
+	case e.To == StatusInProgress && e.From == StatusInProgress:
+		return "Visit already started"
+	case e.To == StatusInProgress && e.From == StatusCompleted:
+		return "Visit already completed"
+	case e.To == StatusInProgress && e.From == StatusCancelled:
+		return "Visit has been cancelled"
+	case e.To == StatusInProgress && e.From == StatusMissed:
+		return "Visit was missed"
+	case e.To == StatusCompleted:
+		return "Visit not started yet or already completed"
+	case e.To == StatusCancelled:
+		return "Only upcoming or late schedules can be cancelled"
+	}
+	return fmt.Sprintf("Cannot change schedule status from %s to %s", e.From, e.To)
+}
+
+// ScheduleStatusChange records a single status transition of a schedule
+type ScheduleStatusChange struct {
+	ID         int       `json:"id" db:"id"`
+	ScheduleID int       `json:"schedule_id" db:"schedule_id"`
+	FromStatus string    `json:"from_status,omitempty" db:"from_status"` // empty when the schedule was created
+	ToStatus   string    `json:"to_status" db:"to_status"`
+	ChangedBy  *int      `json:"changed_by,omitempty" db:"changed_by"` // user ID, empty for system jobs
+	ActorRole  string    `json:"actor_role" db:"actor_role"`           // caregiver, coordinator, admin or system
+	Reason     string    `json:"reason,omitempty" db:"reason"`
+	Latitude   *float64  `json:"latitude,omitempty" db:"latitude"`
+	Longitude  *float64  `json:"longitude,omitempty" db:"longitude"`
+	IPAddress  string    `json:"ip_address,omitempty" db:"ip_address"`
+	RequestID  string    `json:"request_id,omitempty" db:"request_id"`
+	ChangedAt  time.Time `json:"changed_at" db:"changed_at"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	allowed := map[string][]string{
+		StatusUpcoming:   {StatusInProgress, StatusLate, StatusMissed, StatusCancelled},
+		StatusLate:       {StatusInProgress, StatusMissed, StatusCancelled},
+		StatusInProgress: {StatusCompleted},
+		StatusCompleted:  nil,
+		StatusMissed:     nil,
+		StatusCancelled:  nil,
+	}
+
+	for _, from := range ScheduleStatuses {
+		for _, to := range ScheduleStatuses {
+			want := false
+			for _, status := range allowed[from] {
+				want = want || status == to
+			}
+			if got := CanTransition(from, to); got != want {
+				t.Errorf("CanTransition(%q, %q) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestIsStartable(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{StatusUpcoming, true},
+		{StatusLate, true},
+		{StatusInProgress, false},
+		{StatusCompleted, false},
+		{StatusMissed, false},
+		{StatusCancelled, false},
+		{"unknown", false},
+	}
+	for _, tt := range tests {
+		if got := IsStartable(tt.status); got != tt.want {
+			t.Errorf("IsStartable(%q) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestTransitionErrorMessage(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     string
+	}{
+		{StatusInProgress, StatusInProgress, "Visit already started"},
+		{StatusCompleted, StatusInProgress, "Visit already completed"},
+		{StatusCancelled, StatusInProgress, "Visit has been cancelled"},
+		{StatusMissed, StatusInProgress, "Visit was missed"},
+		{StatusUpcoming, StatusCompleted, "Visit not started yet or already completed"},
+		{StatusCompleted, StatusCancelled, "Only upcoming or late schedules can be cancelled"},
+		{StatusCompleted, StatusLate, "Cannot change schedule status from completed to late"},
+	}
+	for _, tt := range tests {
+		err := &TransitionError{From: tt.from, To: tt.to}
+		if got := err.Error(); got != tt.want {
+			t.Errorf("TransitionError{%q, %q} = %q, want %q", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestCanReconcile(t *testing.T) {
+	for _, from := range ScheduleStatuses {
+		for _, to := range ScheduleStatuses {
+			want := from == StatusMissed && to == StatusInProgress
+			if got := CanReconcile(from, to); got != want {
+				t.Errorf("CanReconcile(%q, %q) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// Offline event types accepted by POST /sync
+const (
+	SyncStartVisit     = "start_visit"
+	SyncEndVisit       = "end_visit"
+	SyncTaskUpdate     = "task_update"
+	SyncActivityUpdate = "activity_update"
+)
+
+// Outcomes of a synced event
+const (
+	SyncApplied   = "applied"   // the event changed the server's records
+	SyncDuplicate = "duplicate" // the idempotency key was already synced; the original result is repeated
+	SyncConflict  = "conflict"  // the server's records changed in a way the event cannot be applied over
+	SyncRejected  = "rejected"  // the event is invalid and will never apply
+	SyncFailed    = "failed"    // a server error; the event was not recorded and can be retried
+)
+
+// SyncEvent is a clock-in, clock-out, task or activity update captured on a device,
+// possibly while offline
+type SyncEvent struct {
+	IdempotencyKey string    `json:"idempotency_key" binding:"required,max=128"` // generated by the device, unique per event
+	Type           string    `json:"type" binding:"required,oneof=start_visit end_visit task_update activity_update"`
+	OccurredAt     time.Time `json:"occurred_at" binding:"required"` // device time the event happened, RFC3339
+	ScheduleID     int       `json:"schedule_id,omitempty"`          // start_visit and end_visit
+	TaskID         int       `json:"task_id,omitempty"`              // task_update
+	ActivityID     int       `json:"activity_id,omitempty"`          // activity_update
+	Latitude       *float64  `json:"latitude,omitempty"`             // start_visit and end_visit
+	Longitude      *float64  `json:"longitude,omitempty"`            // start_visit and end_visit
+	OverrideReason string    `json:"override_reason,omitempty"`      // clock in/out outside the geofence
+	Status         string    `json:"status,omitempty"`               // task_update: completed or not_completed
+	IsResolved     *bool     `json:"is_resolved,omitempty"`          // activity_update
+	Reason         string    `json:"reason,omitempty"`               // task_update and activity_update
+}
+
+// SyncRequest represents a batch of queued device events
+type SyncRequest struct {
+	DeviceID string      `json:"device_id,omitempty"`
+	Events   []SyncEvent `json:"events" binding:"required,min=1,max=100,dive"`
+}
+
+// SyncEventResult reports what happened to one synced event
+type SyncEventResult struct {
+	IdempotencyKey string      `json:"idempotency_key"`
+	Type           string      `json:"type"`
+	Status         string      `json:"status"`                    // applied, duplicate, conflict, rejected or failed
+	OriginalStatus string      `json:"original_status,omitempty"` // outcome of the first sync, for duplicates
+	Code           string      `json:"code,omitempty"`            // why the event was not applied
+	Message        string      `json:"message,omitempty"`
+	Data           interface{} `json:"data,omitempty"` // the updated record for applied events
+}
+
+// SyncResponse reports the outcome of every event in a batch, in the order they were sent
+type SyncResponse struct {
+	Results    []SyncEventResult `json:"results"`
+	Applied    int               `json:"applied"`
+	Duplicates int               `json:"duplicates"`
+	Conflicts  int               `json:"conflicts"`
+	Rejected   int               `json:"rejected"`
+	Failed     int               `json:"failed"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Visit note categories
+const (
+	NoteCategoryGeneral             = "general"
+	NoteCategoryObservation         = "observation"          // changes in the client's condition, mood or surroundings
+	NoteCategoryIncident            = "incident"             // falls, injuries and other events needing follow-up
+	NoteCategoryFamilyCommunication = "family_communication" // conversations with family members
+)
+
+// NoteCategories lists every visit note category
+var NoteCategories = []string{
+	NoteCategoryGeneral, NoteCategoryObservation, NoteCategoryIncident, NoteCategoryFamilyCommunication,
+}
+
+// IsNoteCategory reports whether category is a known visit note category
+func IsNoteCategory(category string) bool {
+	for _, known := range NoteCategories {
+		if known == category {
+			return true
+		}
+	}
+	return false
+}
+
+// VisitNote is a narrative progress note written about a visit. Notes are append-only.
+type VisitNote struct {
+	ID          int       `json:"id" db:"id"`
+	VisitID     int       `json:"visit_id" db:"visit_id"`
+	ScheduleID  int       `json:"schedule_id" db:"schedule_id"`
+	Category    string    `json:"category" db:"category"`
+	Body        string    `json:"body" db:"body"`
+	AuthorID    *int      `json:"author_id,omitempty" db:"author_id"` // user ID
+	AuthorEmail string    `json:"author_email,omitempty" db:"author_email"`
+	AuthorRole  string    `json:"author_role" db:"author_role"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// VisitNoteRequest represents the request payload for adding a note to a visit
+type VisitNoteRequest struct {
+	Category string `json:"category"` // general (default), observation, incident or family_communication
+	Body     string `json:"body" binding:"required"`
+}
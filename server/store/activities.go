@@ -0,0 +1,116 @@
+package store
+
+import (
+	"database/sql"
+
+	"visit-tracker-api/database"
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+)
+
+const activityColumns = `id, schedule_id, title, description, is_resolved, reason, created_at, updated_at`
+
+// scanActivity scans an activity row selected with activityColumns
+func scanActivity(row rowScanner) (models.Activity, error) {
+	var activity models.Activity
+	var reason sql.NullString
+	var createdAt, updatedAt string
+
+	err := row.Scan(
+		&activity.ID, &activity.ScheduleID, &activity.Title, &activity.Description,
+		&activity.IsResolved, &reason, &createdAt, &updatedAt,
+	)
+	if err != nil {
+		return activity, err
+	}
+
+	activity.Reason = reason.String
+	activity.CreatedAt = utils.ParseTime(createdAt)
+	activity.UpdatedAt = utils.ParseTime(updatedAt)
+	return activity, nil
+}
+
+// ListActivities returns a schedule's activities, oldest first
+func (s *SQLStore) ListActivities(scheduleID int) ([]models.Activity, error) {
+	rows, err := s.db.Query(`
+		SELECT `+activityColumns+`
+		FROM activities
+		WHERE schedule_id = ?
+		ORDER BY created_at ASC`, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	activities := []models.Activity{}
+	for rows.Next() {
+		activity, err := scanActivity(rows)
+		if err != nil {
+			return nil, err
+		}
+		activities = append(activities, activity)
+	}
+	return activities, rows.Err()
+}
+
+// GetActivity returns an activity, or sql.ErrNoRows when it does not exist
+func (s *SQLStore) GetActivity(id int) (models.Activity, error) {
+	return scanActivity(s.db.QueryRow("SELECT "+activityColumns+" FROM activities WHERE id = ?", id))
+}
+
+// CreateActivity logs an unresolved activity against a schedule
+func (s *SQLStore) CreateActivity(scheduleID int, req models.CreateActivityRequest, actor StatusActor) (models.Activity, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.Activity{}, err
+	}
+	defer tx.Rollback()
+
+	timestamp := now()
+	id, err := tx.Insert(`
+		INSERT INTO activities (schedule_id, title, description, is_resolved, created_at, updated_at)
+		VALUES (?, ?, ?, FALSE, ?, ?)`,
+		scheduleID, req.Title, req.Description, timestamp, timestamp)
+	if err != nil {
+		return models.Activity{}, err
+	}
+	if err := Audit(tx, actor, "activities", int(id), nil); err != nil {
+		return models.Activity{}, err
+	}
+	return getActivityAndCommit(tx, int(id))
+}
+
+// UpdateActivity records whether an activity was resolved and returns it
+func (s *SQLStore) UpdateActivity(id int, req models.UpdateActivityRequest, actor StatusActor) (models.Activity, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.Activity{}, err
+	}
+	defer tx.Rollback()
+
+	before, err := Snapshot(tx, "activities", id)
+	if err != nil {
+		return models.Activity{}, err
+	}
+	_, err = tx.Exec(`
+		UPDATE activities
+		SET is_resolved = ?, reason = ?, updated_at = ?
+		WHERE id = ?`,
+		req.IsResolved, req.Reason, now(), id)
+	if err != nil {
+		return models.Activity{}, err
+	}
+	if err := Audit(tx, actor, "activities", id, before); err != nil {
+		return models.Activity{}, err
+	}
+	return getActivityAndCommit(tx, id)
+}
+
+// getActivityAndCommit reads back an activity written in tx and commits it
+func getActivityAndCommit(tx *database.Tx, id int) (models.Activity, error) {
+	activity, err := scanActivity(tx.QueryRow("SELECT "+activityColumns+" FROM activities WHERE id = ?", id))
+	if err != nil {
+		return activity, err
+	}
+	return activity, tx.Commit()
+}
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"visit-tracker-api/database"
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+)
+
+const adjustmentColumns = `id, visit_id, schedule_id, reason_code, note,
+	original_start_time, original_end_time, adjusted_start_time, adjusted_end_time,
+	adjusted_by, actor_role, ip_address, request_id, created_at`
+
+// scanAdjustment scans a visit adjustment row selected with adjustmentColumns
+func scanAdjustment(row rowScanner) (models.VisitAdjustment, error) {
+	var adjustment models.VisitAdjustment
+	var note, originalStart, originalEnd, adjustedStart, adjustedEnd, ipAddress, requestID sql.NullString
+	var adjustedBy sql.NullInt64
+	var createdAt string
+
+	err := row.Scan(
+		&adjustment.ID, &adjustment.VisitID, &adjustment.ScheduleID, &adjustment.ReasonCode, &note,
+		&originalStart, &originalEnd, &adjustedStart, &adjustedEnd,
+		&adjustedBy, &adjustment.ActorRole, &ipAddress, &requestID, &createdAt,
+	)
+	if err != nil {
+		return adjustment, err
+	}
+
+	adjustment.Note = note.String
+	adjustment.OriginalStartTime = nullableTime(originalStart)
+	adjustment.OriginalEndTime = nullableTime(originalEnd)
+	adjustment.AdjustedStartTime = nullableTime(adjustedStart)
+	adjustment.AdjustedEndTime = nullableTime(adjustedEnd)
+	adjustment.AdjustedBy = nullableInt(adjustedBy)
+	adjustment.IPAddress = ipAddress.String
+	adjustment.RequestID = requestID.String
+	adjustment.CreatedAt = utils.ParseTime(createdAt)
+	return adjustment, nil
+}
+
+// AdjustVisit corrects a visit's clock-in and clock-out times in one transaction,
+// recording the times it replaces. Setting the end of a visit in progress moves the
+// schedule to completed through the state machine, resolving pending tasks as EndVisit
+// does: they are closed when CloseTasks is set, otherwise a *PendingTasksError is
+// returned and nothing is saved.
+func (s *SQLStore) AdjustVisit(scheduleID int, adjustment VisitAdjustmentInput, actor StatusActor) (models.VisitAdjustment, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.VisitAdjustment{}, err
+	}
+	defer tx.Rollback()
+
+	var visitID int
+	var status string
+	var startTime, endTime sql.NullString
+	err = tx.QueryRow(`
+		SELECT v.id, s.status, v.start_time, v.end_time
+		FROM visits v
+		JOIN schedules s ON s.id = v.schedule_id
+		WHERE v.schedule_id = ?`, scheduleID).Scan(&visitID, &status, &startTime, &endTime)
+	if err != nil {
+		return models.VisitAdjustment{}, err
+	}
+	if status != models.StatusInProgress && status != models.StatusCompleted {
+		return models.VisitAdjustment{}, ErrVisitNotAdjustable
+	}
+
+	visitBefore, err := Snapshot(tx, "visits", visitID)
+	if err != nil {
+		return models.VisitAdjustment{}, err
+	}
+	scheduleBefore, err := Snapshot(tx, "schedules", scheduleID)
+	if err != nil {
+		return models.VisitAdjustment{}, err
+	}
+
+	originalStart, originalEnd := nullableTime(startTime), nullableTime(endTime)
+	adjustedStart, adjustedEnd := originalStart, originalEnd
+	if adjustment.StartTime != nil {
+		adjustedStart = adjustment.StartTime
+	}
+	if adjustment.EndTime != nil {
+		adjustedEnd = adjustment.EndTime
+	}
+
+	_, err = tx.Exec(`
+		UPDATE visits
+		SET start_time = ?, end_time = ?, updated_at = ?
+		WHERE id = ?`,
+		optionalTime(adjustedStart), optionalTime(adjustedEnd), now(), visitID)
+	if err != nil {
+		return models.VisitAdjustment{}, err
+	}
+
+	id, err := tx.Insert(`
+		INSERT INTO visit_adjustments
+			(visit_id, schedule_id, reason_code, note, original_start_time, original_end_time,
+			 adjusted_start_time, adjusted_end_time, adjusted_by, actor_role, ip_address, request_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		visitID, scheduleID, adjustment.ReasonCode, nullableString(adjustment.Note),
+		optionalTime(originalStart), optionalTime(originalEnd), optionalTime(adjustedStart), optionalTime(adjustedEnd),
+		actor.UserID, actor.Role, nullableString(actor.IPAddress), nullableString(actor.RequestID), now())
+	if err != nil {
+		return models.VisitAdjustment{}, err
+	}
+
+	// A forgotten clock-out entered by a coordinator completes the visit
+	var closed []models.Task
+	if status == models.StatusInProgress && adjustedEnd != nil {
+		closed, err = closePendingTasks(tx, scheduleID, adjustment.CloseTasks, actor)
+		if err != nil {
+			return models.VisitAdjustment{}, err
+		}
+		_, err = transitionSchedule(tx, scheduleID, StatusChange{
+			To:     models.StatusCompleted,
+			Reason: "Clock-out entered by adjustment: " + adjustment.ReasonCode,
+		}, actor)
+		if err != nil {
+			return models.VisitAdjustment{}, err
+		}
+		if err := Audit(tx, actor, "schedules", scheduleID, scheduleBefore); err != nil {
+			return models.VisitAdjustment{}, err
+		}
+	}
+
+	if err := Audit(tx, actor, "visits", visitID, visitBefore); err != nil {
+		return models.VisitAdjustment{}, err
+	}
+	if err := Audit(tx, actor, "visit_adjustments", int(id), nil); err != nil {
+		return models.VisitAdjustment{}, err
+	}
+
+	recorded, err := getAdjustment(tx, int(id))
+	if err != nil {
+		return recorded, err
+	}
+	recorded.ClosedTasks = closed
+	return recorded, tx.Commit()
+}
+
+// getAdjustment reads back a visit adjustment inside a transaction
+func getAdjustment(tx *database.Tx, id int) (models.VisitAdjustment, error) {
+	return scanAdjustment(tx.QueryRow(`SELECT `+adjustmentColumns+` FROM visit_adjustments WHERE id = ?`, id))
+}
+
+// ListVisitAdjustments returns the adjustments made to a schedule's visit, oldest first
+func (s *SQLStore) ListVisitAdjustments(scheduleID int) ([]models.VisitAdjustment, error) {
+	rows, err := s.db.Query(`
+		SELECT `+adjustmentColumns+`
+		FROM visit_adjustments
+		WHERE schedule_id = ?
+		ORDER BY created_at ASC, id ASC`, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	adjustments := []models.VisitAdjustment{}
+	for rows.Next() {
+		adjustment, err := scanAdjustment(rows)
+		if err != nil {
+			return nil, err
+		}
+		adjustments = append(adjustments, adjustment)
+	}
+	return adjustments, rows.Err()
+}
+
+// optionalTime formats an optional time for storage, storing nil as NULL
+func optionalTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return formatTime(*t)
+}
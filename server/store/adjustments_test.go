@@ -0,0 +1,57 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"visit-tracker-api/models"
+)
+
+func TestAdjustVisitClockOutAppliesTaskCompletionPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		closeTasks bool
+		wantStatus string
+		wantClosed int
+	}{
+		{name: "reject", closeTasks: false, wantStatus: models.StatusInProgress},
+		{name: "auto_close", closeTasks: true, wantStatus: models.StatusCompleted, wantClosed: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t)
+			shiftStart := time.Now().Add(-3 * time.Hour).UTC().Truncate(time.Second)
+			scheduleID := createSchedule(t, s, ScheduleInput{
+				ClientID:   createClient(t, s, "Client"),
+				ShiftStart: shiftStart,
+				ShiftEnd:   shiftStart.Add(2 * time.Hour),
+				Tasks:      []string{"Medication"},
+			})
+			startVisit(t, s, scheduleID, shiftStart)
+
+			end := shiftStart.Add(2 * time.Hour)
+			adjustment, err := s.AdjustVisit(scheduleID, VisitAdjustmentInput{
+				EndTime:    &end,
+				ReasonCode: models.AdjustmentForgotClockOut,
+				CloseTasks: tt.closeTasks,
+			}, SystemActor)
+
+			var pending *PendingTasksError
+			if tt.closeTasks {
+				if err != nil {
+					t.Fatalf("AdjustVisit: %v", err)
+				}
+			} else if !errors.As(err, &pending) {
+				t.Fatalf("AdjustVisit error = %v, want *PendingTasksError", err)
+			}
+			if status := scheduleStatus(t, s, scheduleID); status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", status, tt.wantStatus)
+			}
+			if len(adjustment.ClosedTasks) != tt.wantClosed {
+				t.Errorf("closed %d tasks, want %d", len(adjustment.ClosedTasks), tt.wantClosed)
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package store
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"visit-tracker-api/database"
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+)
+
+// auditChainLock is the PostgreSQL advisory lock that serialises appends to the hash
+// chain. SQLite transactions already take the write lock when they begin.
+const auditChainLock = 7_419_001
+
+// redactedColumns are never copied into the audit log
+var redactedColumns = map[string]bool{"password_hash": true}
+
+// Snapshot returns a row's columns and values for the audit log, or nil when the row
+// does not exist. Take it inside the transaction before changing the row.
+func Snapshot(tx *database.Tx, table string, id int) (map[string]interface{}, error) {
+	snapshots, err := snapshotRows(tx, table, "id = ?", id)
+	if err != nil || len(snapshots) == 0 {
+		return nil, err
+	}
+	return snapshots[0], nil
+}
+
+// snapshotRows returns the table's rows matching where as column-value maps, by id
+func snapshotRows(tx *database.Tx, table, where string, args ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := tx.Query("SELECT * FROM "+table+" WHERE "+where+" ORDER BY id ASC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []map[string]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		snapshot := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			switch value := values[i].(type) {
+			case []byte:
+				snapshot[column] = string(value)
+			default:
+				snapshot[column] = value
+			}
+			if redactedColumns[column] && values[i] != nil {
+				snapshot[column] = "[redacted]"
+			}
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, rows.Err()
+}
+
+// Audit appends an event for a change to a row, made inside tx by actor. before is the
+// row's Snapshot from before the change, nil when the row was just created; the row's
+// state after the change is read here, and a row that no longer exists was deleted.
+func Audit(tx *database.Tx, actor StatusActor, table string, id int, before map[string]interface{}) error {
+	after, err := Snapshot(tx, table, id)
+	if err != nil {
+		return err
+	}
+
+	action := models.AuditUpdate
+	switch {
+	case before == nil:
+		action = models.AuditCreate
+	case after == nil:
+		action = models.AuditDelete
+	}
+	return appendAuditEvent(tx, actor, table, id, action, before, after)
+}
+
+// AuditDeletes records the deletion of the table's rows matching where. Call it inside
+// the transaction just before deleting them.
+func AuditDeletes(tx *database.Tx, actor StatusActor, table, where string, args ...interface{}) error {
+	snapshots, err := snapshotRows(tx, table, where, args...)
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots {
+		id, err := snapshotID(snapshot)
+		if err != nil {
+			return err
+		}
+		if err := appendAuditEvent(tx, actor, table, id, models.AuditDelete, snapshot, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotID returns the id column of a snapshot
+func snapshotID(snapshot map[string]interface{}) (int, error) {
+	switch id := snapshot["id"].(type) {
+	case int64:
+		return int(id), nil
+	case int32:
+		return int(id), nil
+	case string:
+		return strconv.Atoi(id)
+	}
+	return 0, sql.ErrNoRows
+}
+
+// appendAuditEvent inserts an event at the end of the hash chain
+func appendAuditEvent(tx *database.Tx, actor StatusActor, table string, id int, action string, before, after map[string]interface{}) error {
+	if tx.Dialect == database.DialectPostgres {
+		if _, err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLock); err != nil {
+			return err
+		}
+	}
+
+	var prevHash string
+	err := tx.QueryRow("SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1").Scan(&prevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	beforeJSON, err := encodeSnapshot(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := encodeSnapshot(after)
+	if err != nil {
+		return err
+	}
+
+	event := models.AuditEvent{
+		Entity:      table,
+		EntityID:    id,
+		Action:      action,
+		ActorUserID: actor.UserID,
+		ActorRole:   actor.Role,
+		RequestID:   actor.RequestID,
+		IPAddress:   actor.IPAddress,
+		PrevHash:    prevHash,
+	}
+	createdAt := now()
+	event.Hash = auditHash(event, beforeJSON, afterJSON, createdAt)
+
+	_, err = tx.Exec(`
+		INSERT INTO audit_events
+			(entity, entity_id, action, actor_user_id, actor_role, request_id, ip_address,
+			 before_json, after_json, created_at, prev_hash, hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		event.Entity, event.EntityID, event.Action, event.ActorUserID, event.ActorRole,
+		nullableString(event.RequestID), nullableString(event.IPAddress),
+		nullableString(beforeJSON), nullableString(afterJSON), createdAt, event.PrevHash, event.Hash)
+	return err
+}
+
+// encodeSnapshot encodes a snapshot as JSON with sorted keys, or "" for none
+func encodeSnapshot(snapshot map[string]interface{}) (string, error) {
+	if snapshot == nil {
+		return "", nil
+	}
+	encoded, err := json.Marshal(snapshot)
+	return string(encoded), err
+}
+
+// auditHash chains an event to the one before it: the SHA-256 of the previous event's
+// hash and every recorded field of this event
+func auditHash(event models.AuditEvent, beforeJSON, afterJSON, createdAt string) string {
+	var actorUserID string
+	if event.ActorUserID != nil {
+		actorUserID = strconv.Itoa(*event.ActorUserID)
+	}
+	fields, _ := json.Marshal([]string{
+		event.PrevHash, event.Entity, strconv.Itoa(event.EntityID), event.Action, actorUserID, event.ActorRole,
+		event.RequestID, event.IPAddress, beforeJSON, afterJSON, createdAt,
+	})
+	sum := sha256.Sum256(fields)
+	return hex.EncodeToString(sum[:])
+}
+
+const auditColumns = `id, entity, entity_id, action, actor_user_id, actor_role, request_id, ip_address,
+	before_json, after_json, created_at, prev_hash, hash`
+
+// scanAuditEvent scans an audit event row selected with auditColumns, returning the
+// stored JSON and timestamp as well so the hash can be recomputed
+func scanAuditEvent(row rowScanner) (models.AuditEvent, string, string, string, error) {
+	var event models.AuditEvent
+	var actorUserID sql.NullInt64
+	var requestID, ipAddress, beforeJSON, afterJSON sql.NullString
+	var createdAt string
+
+	err := row.Scan(
+		&event.ID, &event.Entity, &event.EntityID, &event.Action, &actorUserID, &event.ActorRole,
+		&requestID, &ipAddress, &beforeJSON, &afterJSON, &createdAt, &event.PrevHash, &event.Hash,
+	)
+	if err != nil {
+		return event, "", "", "", err
+	}
+
+	event.ActorUserID = nullableInt(actorUserID)
+	event.RequestID = requestID.String
+	event.IPAddress = ipAddress.String
+	if beforeJSON.Valid {
+		event.Before = json.RawMessage(beforeJSON.String)
+	}
+	if afterJSON.Valid {
+		event.After = json.RawMessage(afterJSON.String)
+	}
+	event.CreatedAt = utils.ParseTime(createdAt)
+	return event, beforeJSON.String, afterJSON.String, event.CreatedAt.Format(utils.DateTimeLayout), nil
+}
+
+// ListAuditEvents returns the audit events matching the filter, newest first
+func (s *SQLStore) ListAuditEvents(filter AuditFilter) ([]models.AuditEvent, error) {
+	query := "SELECT " + auditColumns + " FROM audit_events WHERE 1 = 1"
+	var args []interface{}
+	if filter.Entity != "" {
+		query += " AND entity = ?"
+		args = append(args, filter.Entity)
+	}
+	if filter.EntityID != nil {
+		query += " AND entity_id = ?"
+		args = append(args, *filter.EntityID)
+	}
+	if !filter.From.IsZero() {
+		query += " AND created_at >= ?"
+		args = append(args, formatTime(filter.From))
+	}
+	if !filter.To.IsZero() {
+		query += " AND created_at < ?"
+		args = append(args, formatTime(filter.To))
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, filter.Limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.AuditEvent{}
+	for rows.Next() {
+		event, _, _, _, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// VerifyAuditChain recomputes every event's hash in order and reports the first event
+// that was changed, removed or inserted out of the chain
+func (s *SQLStore) VerifyAuditChain() (models.AuditVerification, error) {
+	verification := models.AuditVerification{Valid: true, CheckedAt: time.Now().UTC()}
+
+	rows, err := s.db.Query("SELECT " + auditColumns + " FROM audit_events ORDER BY id ASC")
+	if err != nil {
+		return verification, err
+	}
+	defer rows.Close()
+
+	var prevHash string
+	for rows.Next() {
+		event, beforeJSON, afterJSON, createdAt, err := scanAuditEvent(rows)
+		if err != nil {
+			return verification, err
+		}
+
+		problem := ""
+		switch {
+		case event.PrevHash != prevHash:
+			problem = "prev_hash does not match the hash of the preceding event"
+		case auditHash(event, beforeJSON, afterJSON, createdAt) != event.Hash:
+			problem = "hash does not match the event's contents"
+		}
+		if problem != "" {
+			id := event.ID
+			verification.Valid = false
+			verification.FirstInvalidID = &id
+			verification.Message = problem
+			break
+		}
+
+		verification.Checked++
+		prevHash = event.Hash
+	}
+	if err := rows.Err(); err != nil {
+		return verification, err
+	}
+
+	verification.LastHash = prevHash
+	return verification, nil
+}
@@ -0,0 +1,82 @@
+package store
+
+import "testing"
+
+func TestVerifyAuditChain(t *testing.T) {
+	tests := []struct {
+		name        string
+		events      int    // admin accounts created, one audit event each
+		tamper      string // run with the append-only triggers dropped
+		wantValid   bool
+		wantChecked int
+		wantInvalid int
+		wantMessage string
+	}{
+		{name: "empty log", wantValid: true},
+		{name: "intact chain", events: 3, wantValid: true, wantChecked: 3},
+		{
+			name:        "edited event",
+			events:      3,
+			tamper:      `UPDATE audit_events SET after_json = '{"role":"caregiver"}' WHERE id = 2`,
+			wantChecked: 1,
+			wantInvalid: 2,
+			wantMessage: "hash does not match the event's contents",
+		},
+		{
+			name:        "deleted event",
+			events:      3,
+			tamper:      `DELETE FROM audit_events WHERE id = 2`,
+			wantChecked: 1,
+			wantInvalid: 3,
+			wantMessage: "prev_hash does not match the hash of the preceding event",
+		},
+		{
+			name:        "broken link",
+			events:      3,
+			tamper:      `UPDATE audit_events SET prev_hash = '' WHERE id = 3`,
+			wantChecked: 2,
+			wantInvalid: 3,
+			wantMessage: "prev_hash does not match the hash of the preceding event",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t)
+			for i := 0; i < tt.events; i++ {
+				if _, err := s.EnsureAdminUser(string(rune('a'+i))+"@agency.example", "password123"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.tamper != "" {
+				mustExec(t, s, "DROP TRIGGER audit_events_no_update")
+				mustExec(t, s, "DROP TRIGGER audit_events_no_delete")
+				mustExec(t, s, tt.tamper)
+			}
+
+			got, err := s.VerifyAuditChain()
+			if err != nil {
+				t.Fatalf("VerifyAuditChain() error = %v", err)
+			}
+			if got.Valid != tt.wantValid || got.Checked != tt.wantChecked || got.Message != tt.wantMessage {
+				t.Errorf("VerifyAuditChain() = valid %v, checked %d, %q; want valid %v, checked %d, %q",
+					got.Valid, got.Checked, got.Message, tt.wantValid, tt.wantChecked, tt.wantMessage)
+			}
+			switch {
+			case tt.wantInvalid == 0 && got.FirstInvalidID != nil:
+				t.Errorf("first invalid event = %d, want none", *got.FirstInvalidID)
+			case tt.wantInvalid != 0 && (got.FirstInvalidID == nil || *got.FirstInvalidID != tt.wantInvalid):
+				t.Errorf("first invalid event = %v, want %d", got.FirstInvalidID, tt.wantInvalid)
+			}
+			if tt.wantValid && tt.events > 0 {
+				var lastHash string
+				if err := s.db.QueryRow("SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1").Scan(&lastHash); err != nil {
+					t.Fatal(err)
+				}
+				if got.LastHash != lastHash {
+					t.Errorf("last hash = %q, want %q", got.LastHash, lastHash)
+				}
+			}
+		})
+	}
+}
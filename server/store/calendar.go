@@ -0,0 +1,46 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+)
+
+// CountSchedulesByDay counts the schedules matching the filter on each date in
+// [from, to), both midnight UTC, by the date each shift starts on in its client's time
+// zone. Every date is returned, in order.
+func (s *SQLStore) CountSchedulesByDay(filter ScheduleFilter, from, to time.Time) ([]models.CalendarDay, error) {
+	var days []models.CalendarDay
+	index := map[time.Time]int{}
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		index[day] = len(days)
+		days = append(days, models.NewCalendarDay(day.Format("2006-01-02")))
+	}
+
+	// Local dates start up to 14 hours before and 12 hours after the same date in UTC
+	filter.From, filter.To = from.Add(-14*time.Hour), to.Add(12*time.Hour)
+	conditions, args := scheduleConditions(filter)
+	rows, err := s.db.Query(`
+		SELECT s.shift_start, s.status, c.timezone`+scheduleFrom+whereClause(conditions), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var shiftStart, status string
+		var timezone sql.NullString
+		if err := rows.Scan(&shiftStart, &status, &timezone); err != nil {
+			return nil, err
+		}
+		i, ok := index[utils.LocalDate(utils.ParseTime(shiftStart), utils.Location(timezone.String))]
+		if !ok {
+			continue
+		}
+		days[i].Total++
+		days[i].ByStatus[status]++
+	}
+	return days, rows.Err()
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"visit-tracker-api/models"
+)
+
+func TestCountSchedulesByDayUsesClientTimeZones(t *testing.T) {
+	s := newTestStore(t)
+	auckland := createClient(t, s, "Auckland Client")
+	losAngeles := createClient(t, s, "Los Angeles Client")
+	mustExec(t, s, "UPDATE clients SET timezone = ? WHERE id = ?", "Pacific/Auckland", auckland)
+	mustExec(t, s, "UPDATE clients SET timezone = ? WHERE id = ?", "America/Los_Angeles", losAngeles)
+
+	shifts := []struct {
+		clientID int
+		start    string // UTC
+		status   string
+	}{
+		// 00:30 on Mar 2 in Auckland (UTC+13), before the range starts in UTC
+		{auckland, "2026-03-01T11:30:00Z", models.StatusCancelled},
+		// 09:00 on Mar 3 in Auckland, still Mar 2 in UTC
+		{auckland, "2026-03-02T20:00:00Z", models.StatusCancelled},
+		// 22:00 on Mar 2 in Los Angeles (UTC-8), already Mar 3 in UTC
+		{losAngeles, "2026-03-03T06:00:00Z", models.StatusUpcoming},
+		// 23:30 on Mar 7 in Los Angeles, just before its clocks go forward
+		{losAngeles, "2026-03-08T07:30:00Z", models.StatusUpcoming},
+		// 03:30 on Mar 8 in Los Angeles (UTC-7), just after
+		{losAngeles, "2026-03-08T10:30:00Z", models.StatusUpcoming},
+		// 16:30 on Mar 8 in Los Angeles, already Mar 9 in UTC
+		{losAngeles, "2026-03-08T23:30:00Z", models.StatusUpcoming},
+	}
+	for _, shift := range shifts {
+		start, err := time.Parse(time.RFC3339, shift.start)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id := createSchedule(t, s, ScheduleInput{ClientID: shift.clientID, ShiftStart: start, ShiftEnd: start.Add(time.Hour)})
+		mustExec(t, s, "UPDATE schedules SET status = ? WHERE id = ?", shift.status, id)
+	}
+
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	days, err := s.CountSchedulesByDay(ScheduleFilter{}, from, from.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]map[string]int{
+		"2026-03-02": {models.StatusCancelled: 1, models.StatusUpcoming: 1},
+		"2026-03-03": {models.StatusCancelled: 1},
+		"2026-03-07": {models.StatusUpcoming: 1},
+		"2026-03-08": {models.StatusUpcoming: 2},
+	}
+	if len(days) != 7 {
+		t.Fatalf("got %d days, want 7", len(days))
+	}
+	for i, day := range days {
+		if wantDate := from.AddDate(0, 0, i).Format("2006-01-02"); day.Date != wantDate {
+			t.Errorf("days[%d] = %s, want %s", i, day.Date, wantDate)
+		}
+		wantTotal := 0
+		for status, count := range want[day.Date] {
+			wantTotal += count
+			if day.ByStatus[status] != count {
+				t.Errorf("%s: %d %s, want %d", day.Date, day.ByStatus[status], status, count)
+			}
+		}
+		if day.Total != wantTotal {
+			t.Errorf("%s: total = %d, want %d", day.Date, day.Total, wantTotal)
+		}
+	}
+}
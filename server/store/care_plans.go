@@ -0,0 +1,246 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"visit-tracker-api/database"
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+)
+
+const carePlanTaskQuery = `
+	SELECT id, client_id, description, frequency, required, active, created_at, updated_at
+	FROM care_plan_tasks`
+
+// scanCarePlanTask scans a care-plan task row selected with carePlanTaskQuery
+func scanCarePlanTask(row rowScanner) (models.CarePlanTask, error) {
+	var task models.CarePlanTask
+	var createdAt, updatedAt string
+
+	err := row.Scan(
+		&task.ID, &task.ClientID, &task.Description, &task.Frequency,
+		&task.Required, &task.Active, &createdAt, &updatedAt,
+	)
+	if err != nil {
+		return task, err
+	}
+
+	task.CreatedAt = utils.ParseTime(createdAt)
+	task.UpdatedAt = utils.ParseTime(updatedAt)
+	return task, nil
+}
+
+// ListCarePlanTasks returns a client's care-plan tasks in the order they were added, only
+// those with the given active flag unless it is nil
+func (s *SQLStore) ListCarePlanTasks(clientID int, active *bool) ([]models.CarePlanTask, error) {
+	query := carePlanTaskQuery + " WHERE client_id = ?"
+	args := []interface{}{clientID}
+	if active != nil {
+		query += " AND active = ?"
+		args = append(args, *active)
+	}
+	query += " ORDER BY id ASC"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []models.CarePlanTask{}
+	for rows.Next() {
+		task, err := scanCarePlanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// GetCarePlanTask returns a task of a client's care plan, or sql.ErrNoRows when the client
+// has no such task
+func (s *SQLStore) GetCarePlanTask(clientID, id int) (models.CarePlanTask, error) {
+	return scanCarePlanTask(s.db.QueryRow(carePlanTaskQuery+" WHERE id = ? AND client_id = ?", id, clientID))
+}
+
+// CreateCarePlanTask adds a task to a client's care plan and returns it
+func (s *SQLStore) CreateCarePlanTask(clientID int, task CarePlanTaskInput, actor StatusActor) (models.CarePlanTask, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.CarePlanTask{}, err
+	}
+	defer tx.Rollback()
+
+	created := now()
+	id, err := tx.Insert(`
+		INSERT INTO care_plan_tasks (client_id, description, frequency, required, active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		clientID, task.Description, task.Frequency, task.Required, task.Active, created, created)
+	if err != nil {
+		return models.CarePlanTask{}, err
+	}
+	if err := Audit(tx, actor, "care_plan_tasks", int(id), nil); err != nil {
+		return models.CarePlanTask{}, err
+	}
+	return getCarePlanTaskAndCommit(tx, clientID, int(id))
+}
+
+// UpdateCarePlanTask saves a task of a client's care plan and returns it, or returns
+// sql.ErrNoRows when the client has no such task. Tasks already copied onto shifts are
+// not changed.
+func (s *SQLStore) UpdateCarePlanTask(clientID, id int, task CarePlanTaskInput, actor StatusActor) (models.CarePlanTask, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.CarePlanTask{}, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow("SELECT id FROM care_plan_tasks WHERE id = ? AND client_id = ?", id, clientID).Scan(&id)
+	if err != nil {
+		return models.CarePlanTask{}, err
+	}
+	before, err := Snapshot(tx, "care_plan_tasks", id)
+	if err != nil {
+		return models.CarePlanTask{}, err
+	}
+	_, err = tx.Exec(`
+		UPDATE care_plan_tasks
+		SET description = ?, frequency = ?, required = ?, active = ?, updated_at = ?
+		WHERE id = ?`,
+		task.Description, task.Frequency, task.Required, task.Active, now(), id)
+	if err != nil {
+		return models.CarePlanTask{}, err
+	}
+	if err := Audit(tx, actor, "care_plan_tasks", id, before); err != nil {
+		return models.CarePlanTask{}, err
+	}
+	return getCarePlanTaskAndCommit(tx, clientID, id)
+}
+
+// getCarePlanTaskAndCommit reads back a care-plan task written in tx and commits it
+func getCarePlanTaskAndCommit(tx *database.Tx, clientID, id int) (models.CarePlanTask, error) {
+	task, err := scanCarePlanTask(tx.QueryRow(carePlanTaskQuery+" WHERE id = ? AND client_id = ?", id, clientID))
+	if err != nil {
+		return models.CarePlanTask{}, err
+	}
+	return task, tx.Commit()
+}
+
+// insertCarePlanTasks adds the tasks of the client's care plan that are due on a new
+// shift: every-visit tasks always, and daily and weekly tasks unless another shift of
+// the client in the same day or week, in the client's time zone, already has them and
+// is not cancelled. Tasks the shift already has are not added twice.
+func insertCarePlanTasks(tx *database.Tx, scheduleID int, schedule ScheduleInput, actor StatusActor) error {
+	var timezone sql.NullString
+	if err := tx.QueryRow("SELECT timezone FROM clients WHERE id = ?", schedule.ClientID).Scan(&timezone); err != nil {
+		return err
+	}
+	loc := utils.Location(timezone.String)
+	local := schedule.ShiftStart.In(loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	week := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	periods := map[string][2]time.Time{
+		models.FrequencyDaily:  {day, day.AddDate(0, 0, 1)},
+		models.FrequencyWeekly: {week, week.AddDate(0, 0, 7)},
+	}
+
+	plan, err := activeCarePlan(tx, schedule.ClientID)
+	if err != nil {
+		return err
+	}
+	existing, err := taskDescriptions(tx, scheduleID)
+	if err != nil {
+		return err
+	}
+
+	for _, task := range plan {
+		if existing[task.Description] {
+			continue
+		}
+		if period, ok := periods[task.Frequency]; ok {
+			var done int
+			err := tx.QueryRow(`
+				SELECT COUNT(*)
+				FROM tasks t
+				JOIN schedules s ON s.id = t.schedule_id
+				WHERE t.care_plan_task_id = ? AND s.id <> ? AND s.status <> ?
+					AND s.shift_start >= ? AND s.shift_start < ?`,
+				task.ID, scheduleID, models.StatusCancelled, formatTime(period[0]), formatTime(period[1])).Scan(&done)
+			if err != nil {
+				return err
+			}
+			if done > 0 {
+				continue
+			}
+		}
+
+		id, err := tx.Insert(`
+			INSERT INTO tasks (schedule_id, description, status, required, care_plan_task_id)
+			VALUES (?, ?, 'pending', ?, ?)`,
+			scheduleID, task.Description, task.Required, task.ID)
+		if err != nil {
+			return err
+		}
+		if err := Audit(tx, actor, "tasks", int(id), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceCarePlanTasks removes a schedule's pending care-plan tasks and adds the ones due
+// on it as it now stands
+func replaceCarePlanTasks(tx *database.Tx, scheduleID int, schedule ScheduleInput, actor StatusActor) error {
+	pending := "schedule_id = ? AND care_plan_task_id IS NOT NULL AND status = 'pending'"
+	if err := AuditDeletes(tx, actor, "tasks", pending, scheduleID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM tasks WHERE "+pending, scheduleID); err != nil {
+		return err
+	}
+	return insertCarePlanTasks(tx, scheduleID, schedule, actor)
+}
+
+// activeCarePlan returns a client's active care-plan tasks in the order they were added
+func activeCarePlan(tx *database.Tx, clientID int) ([]models.CarePlanTask, error) {
+	rows, err := tx.Query(`
+		SELECT id, description, frequency, required
+		FROM care_plan_tasks
+		WHERE client_id = ? AND active = ?
+		ORDER BY id ASC`, clientID, true)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var plan []models.CarePlanTask
+	for rows.Next() {
+		task := models.CarePlanTask{ClientID: clientID, Active: true}
+		if err := rows.Scan(&task.ID, &task.Description, &task.Frequency, &task.Required); err != nil {
+			return nil, err
+		}
+		plan = append(plan, task)
+	}
+	return plan, rows.Err()
+}
+
+// taskDescriptions returns the descriptions of a schedule's tasks
+func taskDescriptions(tx *database.Tx, scheduleID int) (map[string]bool, error) {
+	rows, err := tx.Query("SELECT description FROM tasks WHERE schedule_id = ?", scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	descriptions := map[string]bool{}
+	for rows.Next() {
+		var description string
+		if err := rows.Scan(&description); err != nil {
+			return nil, err
+		}
+		descriptions[description] = true
+	}
+	return descriptions, rows.Err()
+}
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"visit-tracker-api/models"
+)
+
+func TestCreateScheduleAddsCarePlanTasksOnce(t *testing.T) {
+	s := newTestStore(t)
+	clientID := createClient(t, s, "Client")
+	for _, task := range []CarePlanTaskInput{
+		{Description: "Medication", Frequency: models.FrequencyEveryVisit, Required: true, Active: true},
+		{Description: "Laundry", Frequency: models.FrequencyDaily, Required: true, Active: true},
+		{Description: "Shopping", Frequency: models.FrequencyWeekly, Required: true, Active: true},
+		{Description: "Gardening", Frequency: models.FrequencyEveryVisit, Required: true, Active: false},
+	} {
+		if _, err := s.CreateCarePlanTask(clientID, task, SystemActor); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	monday := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	shift := func(start time.Time, tasks ...string) ScheduleInput {
+		return ScheduleInput{ClientID: clientID, ShiftStart: start, ShiftEnd: start.Add(2 * time.Hour), Tasks: tasks}
+	}
+	first := createSchedule(t, s, shift(monday))
+
+	tests := []struct {
+		name   string
+		cancel int // a schedule cancelled before this one is created
+		input  ScheduleInput
+		want   []string
+	}{
+		{"same task given by hand", 0, shift(monday.Add(3*time.Hour), "Medication"), []string{"Medication"}},
+		{"later the same day", 0, shift(monday.Add(6 * time.Hour)), []string{"Medication"}},
+		{"later the same week", 0, shift(monday.AddDate(0, 0, 6)), []string{"Laundry", "Medication"}},
+		{"the next week", 0, shift(monday.AddDate(0, 0, 7)), []string{"Laundry", "Medication", "Shopping"}},
+		{"after the day's first shift is cancelled", first, shift(monday.Add(8 * time.Hour)), []string{"Laundry", "Medication", "Shopping"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.cancel != 0 {
+				if err := s.CancelSchedule(tt.cancel, "Client away", SystemActor); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tasks, err := s.ListTasks(createSchedule(t, s, tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, task := range tasks {
+				got = append(got, task.Description)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tasks = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"database/sql"
+
+	"visit-tracker-api/database"
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+)
+
+const caregiverQuery = `
+	SELECT id, name, email, phone, active, created_at, updated_at
+	FROM caregivers`
+
+// scanCaregiver scans a caregiver row selected with caregiverQuery
+func scanCaregiver(row rowScanner) (models.Caregiver, error) {
+	var caregiver models.Caregiver
+	var phone sql.NullString
+	var createdAt, updatedAt string
+
+	err := row.Scan(
+		&caregiver.ID, &caregiver.Name, &caregiver.Email, &phone,
+		&caregiver.Active, &createdAt, &updatedAt,
+	)
+	if err != nil {
+		return caregiver, err
+	}
+
+	caregiver.Phone = phone.String
+	caregiver.CreatedAt = utils.ParseTime(createdAt)
+	caregiver.UpdatedAt = utils.ParseTime(updatedAt)
+	return caregiver, nil
+}
+
+// ListCaregivers returns the caregivers by name, only those with the given active flag
+// unless it is nil
+func (s *SQLStore) ListCaregivers(active *bool) ([]models.Caregiver, error) {
+	query := caregiverQuery
+	var args []interface{}
+	if active != nil {
+		query += " WHERE active = ?"
+		args = append(args, *active)
+	}
+	query += " ORDER BY name ASC"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	caregivers := []models.Caregiver{}
+	for rows.Next() {
+		caregiver, err := scanCaregiver(rows)
+		if err != nil {
+			return nil, err
+		}
+		caregivers = append(caregivers, caregiver)
+	}
+	return caregivers, rows.Err()
+}
+
+// GetCaregiver returns a caregiver, or sql.ErrNoRows when it does not exist
+func (s *SQLStore) GetCaregiver(id int) (models.Caregiver, error) {
+	return scanCaregiver(s.db.QueryRow(caregiverQuery+" WHERE id = ?", id))
+}
+
+// CaregiverEmailTaken reports whether a caregiver other than excludeID uses the email
+func (s *SQLStore) CaregiverEmailTaken(email string, excludeID int) (bool, error) {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM caregivers WHERE email = ? AND id != ?", email, excludeID).Scan(&count)
+	return count > 0, err
+}
+
+// CreateCaregiver registers a caregiver and returns it
+func (s *SQLStore) CreateCaregiver(caregiver CaregiverInput, actor StatusActor) (models.Caregiver, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.Caregiver{}, err
+	}
+	defer tx.Rollback()
+
+	created := now()
+	id, err := tx.Insert(`
+		INSERT INTO caregivers (name, email, phone, active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		caregiver.Name, caregiver.Email, nullableString(caregiver.Phone), caregiver.Active, created, created)
+	if err != nil {
+		return models.Caregiver{}, err
+	}
+	if err := Audit(tx, actor, "caregivers", int(id), nil); err != nil {
+		return models.Caregiver{}, err
+	}
+	return getCaregiverAndCommit(tx, int(id))
+}
+
+// UpdateCaregiver saves a caregiver and returns it, or returns sql.ErrNoRows when it does
+// not exist. Deactivated caregivers are kept so past visits stay attributable.
+func (s *SQLStore) UpdateCaregiver(id int, caregiver CaregiverInput, actor StatusActor) (models.Caregiver, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.Caregiver{}, err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow("SELECT id FROM caregivers WHERE id = ?", id).Scan(&id); err != nil {
+		return models.Caregiver{}, err
+	}
+	before, err := Snapshot(tx, "caregivers", id)
+	if err != nil {
+		return models.Caregiver{}, err
+	}
+	_, err = tx.Exec(`
+		UPDATE caregivers
+		SET name = ?, email = ?, phone = ?, active = ?, updated_at = ?
+		WHERE id = ?`,
+		caregiver.Name, caregiver.Email, nullableString(caregiver.Phone), caregiver.Active, now(), id)
+	if err != nil {
+		return models.Caregiver{}, err
+	}
+	if err := Audit(tx, actor, "caregivers", id, before); err != nil {
+		return models.Caregiver{}, err
+	}
+	return getCaregiverAndCommit(tx, id)
+}
+
+// getCaregiverAndCommit reads back a caregiver written in tx and commits it
+func getCaregiverAndCommit(tx *database.Tx, id int) (models.Caregiver, error) {
+	caregiver, err := scanCaregiver(tx.QueryRow(caregiverQuery+" WHERE id = ?", id))
+	if err != nil {
+		return models.Caregiver{}, err
+	}
+	return caregiver, tx.Commit()
+}
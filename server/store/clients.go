@@ -0,0 +1,208 @@
+package store
+
+import (
+	"database/sql"
+
+	"visit-tracker-api/database"
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+)
+
+const clientQuery = `
+	SELECT id, name, address, latitude, longitude, care_plan_notes, medicaid_id, timezone,
+	       active, created_at, updated_at
+	FROM clients`
+
+// scanClient scans a client row selected with clientQuery
+func scanClient(row rowScanner) (models.Client, error) {
+	var client models.Client
+	var carePlanNotes, medicaidID, timezone sql.NullString
+	var createdAt, updatedAt string
+
+	err := row.Scan(
+		&client.ID, &client.Name, &client.Address, &client.Latitude, &client.Longitude,
+		&carePlanNotes, &medicaidID, &timezone, &client.Active, &createdAt, &updatedAt,
+	)
+	if err != nil {
+		return client, err
+	}
+
+	client.CarePlanNotes = carePlanNotes.String
+	client.MedicaidID = medicaidID.String
+	client.Timezone = timezone.String
+	client.CreatedAt = utils.ParseTime(createdAt)
+	client.UpdatedAt = utils.ParseTime(updatedAt)
+	client.EmergencyContacts = []models.EmergencyContact{}
+	return client, nil
+}
+
+// emergencyContacts returns a client's emergency contacts in the order they were added
+func emergencyContacts(q queryer, clientID int) ([]models.EmergencyContact, error) {
+	rows, err := q.Query(`
+		SELECT id, client_id, name, relationship, phone
+		FROM emergency_contacts
+		WHERE client_id = ?
+		ORDER BY id ASC`, clientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := []models.EmergencyContact{}
+	for rows.Next() {
+		var contact models.EmergencyContact
+		var relationship sql.NullString
+
+		if err := rows.Scan(&contact.ID, &contact.ClientID, &contact.Name, &relationship, &contact.Phone); err != nil {
+			return nil, err
+		}
+		contact.Relationship = relationship.String
+		contacts = append(contacts, contact)
+	}
+	return contacts, rows.Err()
+}
+
+// ListClients returns the clients by name with their emergency contacts, only those with
+// the given active flag unless it is nil
+func (s *SQLStore) ListClients(active *bool) ([]models.Client, error) {
+	query := clientQuery
+	var args []interface{}
+	if active != nil {
+		query += " WHERE active = ?"
+		args = append(args, *active)
+	}
+	query += " ORDER BY name ASC"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clients := []models.Client{}
+	for rows.Next() {
+		client, err := scanClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range clients {
+		if clients[i].EmergencyContacts, err = emergencyContacts(s.db, clients[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return clients, nil
+}
+
+// GetClient returns a client with its emergency contacts, or sql.ErrNoRows when it does
+// not exist
+func (s *SQLStore) GetClient(id int) (models.Client, error) {
+	client, err := scanClient(s.db.QueryRow(clientQuery+" WHERE id = ?", id))
+	if err != nil {
+		return client, err
+	}
+	client.EmergencyContacts, err = emergencyContacts(s.db, id)
+	return client, err
+}
+
+// CreateClient registers a client with its emergency contacts and returns it
+func (s *SQLStore) CreateClient(client ClientInput, actor StatusActor) (models.Client, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.Client{}, err
+	}
+	defer tx.Rollback()
+
+	created := now()
+	id, err := tx.Insert(`
+		INSERT INTO clients (name, address, latitude, longitude, care_plan_notes, medicaid_id, timezone, active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		client.Name, client.Address, client.Latitude, client.Longitude, nullableString(client.CarePlanNotes),
+		nullableString(client.MedicaidID), nullableString(client.Timezone), client.Active, created, created)
+	if err != nil {
+		return models.Client{}, err
+	}
+	if err := Audit(tx, actor, "clients", int(id), nil); err != nil {
+		return models.Client{}, err
+	}
+	if err := replaceEmergencyContacts(tx, int(id), client.EmergencyContacts, actor); err != nil {
+		return models.Client{}, err
+	}
+	return getClientAndCommit(tx, int(id))
+}
+
+// UpdateClient saves a client and replaces its emergency contacts, returning the client,
+// or returns sql.ErrNoRows when it does not exist
+func (s *SQLStore) UpdateClient(id int, client ClientInput, actor StatusActor) (models.Client, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.Client{}, err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow("SELECT id FROM clients WHERE id = ?", id).Scan(&id); err != nil {
+		return models.Client{}, err
+	}
+	before, err := Snapshot(tx, "clients", id)
+	if err != nil {
+		return models.Client{}, err
+	}
+	_, err = tx.Exec(`
+		UPDATE clients
+		SET name = ?, address = ?, latitude = ?, longitude = ?, care_plan_notes = ?, medicaid_id = ?, timezone = ?, active = ?, updated_at = ?
+		WHERE id = ?`,
+		client.Name, client.Address, client.Latitude, client.Longitude, nullableString(client.CarePlanNotes),
+		nullableString(client.MedicaidID), nullableString(client.Timezone), client.Active, now(), id)
+	if err != nil {
+		return models.Client{}, err
+	}
+	if err := Audit(tx, actor, "clients", id, before); err != nil {
+		return models.Client{}, err
+	}
+	if err := replaceEmergencyContacts(tx, id, client.EmergencyContacts, actor); err != nil {
+		return models.Client{}, err
+	}
+	return getClientAndCommit(tx, id)
+}
+
+// replaceEmergencyContacts swaps a client's emergency contacts
+func replaceEmergencyContacts(tx *database.Tx, clientID int, contacts []models.EmergencyContactRequest, actor StatusActor) error {
+	if err := AuditDeletes(tx, actor, "emergency_contacts", "client_id = ?", clientID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM emergency_contacts WHERE client_id = ?", clientID); err != nil {
+		return err
+	}
+
+	for _, contact := range contacts {
+		id, err := tx.Insert(`
+			INSERT INTO emergency_contacts (client_id, name, relationship, phone)
+			VALUES (?, ?, ?, ?)`,
+			clientID, contact.Name, nullableString(contact.Relationship), contact.Phone)
+		if err != nil {
+			return err
+		}
+		if err := Audit(tx, actor, "emergency_contacts", int(id), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getClientAndCommit reads back a client written in tx and commits it
+func getClientAndCommit(tx *database.Tx, id int) (models.Client, error) {
+	client, err := scanClient(tx.QueryRow(clientQuery+" WHERE id = ?", id))
+	if err != nil {
+		return models.Client{}, err
+	}
+	if client.EmergencyContacts, err = emergencyContacts(tx, id); err != nil {
+		return models.Client{}, err
+	}
+	return client, tx.Commit()
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+)
+
+// ListEVVVisits returns the visits whose service date, the date of clock-in in the
+// client's time zone, is within [from, to), earliest first, and whose schedules are in
+// progress or completed. from and to are dates at midnight UTC.
+func (s *SQLStore) ListEVVVisits(from, to time.Time) ([]models.EVVVisit, error) {
+	// Local dates start up to 14 hours before and 12 hours after the same date in UTC
+	windowStart, windowEnd := formatTime(from.Add(-14*time.Hour)), formatTime(to.Add(12*time.Hour))
+	rows, err := s.db.Query(`
+		SELECT v.id, s.id, s.service_code, s.status, c.id, c.name, c.medicaid_id, c.address, c.timezone,
+			s.caregiver_id, cg.name, v.start_time, v.end_time, v.start_lat, v.start_lng, v.end_lat, v.end_lng,
+			v.start_geofence_status, v.end_geofence_status,
+			(SELECT a.reason_code FROM visit_adjustments a WHERE a.visit_id = v.id ORDER BY a.id DESC LIMIT 1)
+		FROM visits v
+		JOIN schedules s ON s.id = v.schedule_id
+		JOIN clients c ON c.id = s.client_id
+		LEFT JOIN caregivers cg ON cg.id = s.caregiver_id
+		WHERE v.start_time >= ? AND v.start_time < ? AND s.status IN (?, ?)
+		ORDER BY v.start_time ASC, v.id ASC`,
+		windowStart, windowEnd, models.StatusInProgress, models.StatusCompleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	visits := []models.EVVVisit{}
+	for rows.Next() {
+		var visit models.EVVVisit
+		var timezone, serviceCode, medicaidID, caregiverName, startTime, endTime, startGeofence, endGeofence, adjustmentReason sql.NullString
+		var caregiverID sql.NullInt64
+		var startLat, startLng, endLat, endLng sql.NullFloat64
+
+		err := rows.Scan(
+			&visit.VisitID, &visit.ScheduleID, &serviceCode, &visit.Status, &visit.ClientID, &visit.ClientName,
+			&medicaidID, &visit.Address, &timezone, &caregiverID, &caregiverName, &startTime, &endTime,
+			&startLat, &startLng, &endLat, &endLng, &startGeofence, &endGeofence, &adjustmentReason,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		visit.ServiceCode = serviceCode.String
+		visit.ClientMedicaidID = medicaidID.String
+		visit.CaregiverID = nullableInt(caregiverID)
+		visit.CaregiverName = caregiverName.String
+		visit.StartTime = nullableTime(startTime)
+		visit.EndTime = nullableTime(endTime)
+		visit.StartLatitude = nullableFloat(startLat)
+		visit.StartLongitude = nullableFloat(startLng)
+		visit.EndLatitude = nullableFloat(endLat)
+		visit.EndLongitude = nullableFloat(endLng)
+		visit.StartGeofence = startGeofence.String
+		visit.EndGeofence = endGeofence.String
+		visit.AdjustmentReason = adjustmentReason.String
+		visit.Timezone = utils.Location(timezone.String).String()
+
+		if visit.StartTime != nil {
+			serviceDate := utils.LocalDate(*visit.StartTime, utils.Location(visit.Timezone))
+			if serviceDate.Before(from) || !serviceDate.Before(to) {
+				continue
+			}
+		}
+		visits = append(visits, visit)
+	}
+	return visits, rows.Err()
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+)
+
+// ClaimIdempotencyKey reserves a user's key for a request, first forgetting the key if it
+// was claimed before expiredBefore. When the key is in use it returns the earlier record
+// and false instead.
+func (s *SQLStore) ClaimIdempotencyKey(record models.IdempotencyRecord, expiredBefore time.Time) (models.IdempotencyRecord, bool, error) {
+	_, err := s.db.Exec(
+		"DELETE FROM idempotency_keys WHERE user_id = ? AND idempotency_key = ? AND created_at < ?",
+		record.UserID, record.Key, formatTime(expiredBefore))
+	if err != nil {
+		return record, false, err
+	}
+
+	result, err := s.db.Exec(`
+		INSERT INTO idempotency_keys (user_id, idempotency_key, request_method, request_path, request_fingerprint, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, idempotency_key) DO NOTHING`,
+		record.UserID, record.Key, record.Method, record.Path, record.Fingerprint, now())
+	if err != nil {
+		return record, false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return record, false, err
+	}
+
+	stored, err := s.getIdempotencyKey(record.UserID, record.Key)
+	return stored, affected == 1, err
+}
+
+// getIdempotencyKey returns the request a user made under an idempotency key
+func (s *SQLStore) getIdempotencyKey(userID int, key string) (models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	var status sql.NullInt64
+	var contentType, body sql.NullString
+	var createdAt string
+
+	err := s.db.QueryRow(`
+		SELECT id, user_id, idempotency_key, request_method, request_path, request_fingerprint,
+			response_status, response_content_type, response_body, created_at
+		FROM idempotency_keys
+		WHERE user_id = ? AND idempotency_key = ?`, userID, key).Scan(
+		&record.ID, &record.UserID, &record.Key, &record.Method, &record.Path, &record.Fingerprint,
+		&status, &contentType, &body, &createdAt,
+	)
+	if err != nil {
+		return record, err
+	}
+
+	record.CreatedAt = utils.ParseTime(createdAt)
+	if status.Valid {
+		record.Response = &models.IdempotentResponse{
+			StatusCode:  int(status.Int64),
+			ContentType: contentType.String,
+			Body:        []byte(body.String),
+		}
+	}
+	return record, nil
+}
+
+// CompleteIdempotencyKey stores the response to a claimed key's request
+func (s *SQLStore) CompleteIdempotencyKey(id int, response models.IdempotentResponse) error {
+	_, err := s.db.Exec(`
+		UPDATE idempotency_keys
+		SET response_status = ?, response_content_type = ?, response_body = ?, completed_at = ?
+		WHERE id = ?`,
+		response.StatusCode, nullableString(response.ContentType), string(response.Body), now(), id)
+	return err
+}
+
+// ReleaseIdempotencyKey forgets a claimed key, so the request can be retried under it
+func (s *SQLStore) ReleaseIdempotencyKey(id int) error {
+	_, err := s.db.Exec("DELETE FROM idempotency_keys WHERE id = ?", id)
+	return err
+}
+
+// PurgeIdempotencyKeys forgets keys claimed before the cutoff, returning how many
+func (s *SQLStore) PurgeIdempotencyKeys(before time.Time) (int64, error) {
+	result, err := s.db.Exec("DELETE FROM idempotency_keys WHERE created_at < ?", formatTime(before))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,234 @@
+package store
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"visit-tracker-api/database"
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+)
+
+const incidentColumns = `i.id, i.schedule_id, i.client_id, c.name, i.caregiver_id,
+	i.category, i.severity, i.status, i.description, i.occurred_at,
+	i.injury, i.injury_details, i.follow_up_notes,
+	i.reported_by, i.reporter_role, i.resolved_by, i.resolved_at,
+	i.created_at, i.updated_at`
+
+// incidentQuery selects incidents with their client's name
+const incidentQuery = `SELECT ` + incidentColumns + `
+	FROM incidents i
+	JOIN clients c ON c.id = i.client_id`
+
+// scanIncident scans an incident row selected with incidentQuery
+func scanIncident(row rowScanner) (models.Incident, error) {
+	var incident models.Incident
+	var caregiverID, reportedBy, resolvedBy sql.NullInt64
+	var injuryDetails, followUpNotes, resolvedAt sql.NullString
+	var occurredAt, createdAt, updatedAt string
+
+	err := row.Scan(
+		&incident.ID, &incident.ScheduleID, &incident.ClientID, &incident.ClientName, &caregiverID,
+		&incident.Category, &incident.Severity, &incident.Status, &incident.Description, &occurredAt,
+		&incident.Injury, &injuryDetails, &followUpNotes,
+		&reportedBy, &incident.ReporterRole, &resolvedBy, &resolvedAt,
+		&createdAt, &updatedAt,
+	)
+	if err != nil {
+		return incident, err
+	}
+
+	incident.CaregiverID = nullableInt(caregiverID)
+	incident.OccurredAt = utils.ParseTime(occurredAt)
+	incident.InjuryDetails = injuryDetails.String
+	incident.FollowUpNotes = followUpNotes.String
+	incident.ReportedBy = nullableInt(reportedBy)
+	incident.ResolvedBy = nullableInt(resolvedBy)
+	incident.ResolvedAt = nullableTime(resolvedAt)
+	incident.CreatedAt = utils.ParseTime(createdAt)
+	incident.UpdatedAt = utils.ParseTime(updatedAt)
+	return incident, nil
+}
+
+// severityRank orders incidents from the most to the least severe
+func severityRank() string {
+	rank := "CASE i.severity"
+	for i, severity := range models.IncidentSeverities {
+		rank += " WHEN '" + severity + "' THEN " + strconv.Itoa(i)
+	}
+	return rank + " ELSE -1 END"
+}
+
+// ListIncidents returns the incidents matching the filter, most severe first and, within
+// a severity, the longest waiting first
+func (s *SQLStore) ListIncidents(filter IncidentFilter) ([]models.Incident, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.ScheduleID != nil {
+		conditions = append(conditions, "i.schedule_id = ?")
+		args = append(args, *filter.ScheduleID)
+	}
+	if filter.ClientID != nil {
+		conditions = append(conditions, "i.client_id = ?")
+		args = append(args, *filter.ClientID)
+	}
+	if len(filter.Statuses) > 0 {
+		conditions = append(conditions, "i.status IN (?"+strings.Repeat(", ?", len(filter.Statuses)-1)+")")
+		for _, status := range filter.Statuses {
+			args = append(args, status)
+		}
+	}
+	if len(filter.Severities) > 0 {
+		conditions = append(conditions, "i.severity IN (?"+strings.Repeat(", ?", len(filter.Severities)-1)+")")
+		for _, severity := range filter.Severities {
+			args = append(args, severity)
+		}
+	}
+
+	query := incidentQuery + whereClause(conditions)
+	query += "\n\tORDER BY " + severityRank() + " DESC, i.occurred_at ASC, i.id ASC"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	incidents := []models.Incident{}
+	for rows.Next() {
+		incident, err := scanIncident(rows)
+		if err != nil {
+			return nil, err
+		}
+		incidents = append(incidents, incident)
+	}
+	return incidents, rows.Err()
+}
+
+// GetIncident returns an incident, or sql.ErrNoRows when it does not exist
+func (s *SQLStore) GetIncident(id int) (models.Incident, error) {
+	return scanIncident(s.db.QueryRow(incidentQuery+" WHERE i.id = ?", id))
+}
+
+// CreateIncident files an open incident against a schedule, for the schedule's client and
+// caregiver. It returns ErrIncidentBeforeVisit unless the visit is in progress or completed.
+func (s *SQLStore) CreateIncident(scheduleID int, input IncidentInput, actor StatusActor) (models.Incident, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.Incident{}, err
+	}
+	defer tx.Rollback()
+
+	var clientID int
+	var caregiverID sql.NullInt64
+	var status string
+	err = tx.QueryRow("SELECT client_id, caregiver_id, status FROM schedules WHERE id = ?", scheduleID).
+		Scan(&clientID, &caregiverID, &status)
+	if err != nil {
+		return models.Incident{}, err
+	}
+	if status != models.StatusInProgress && status != models.StatusCompleted {
+		return models.Incident{}, ErrIncidentBeforeVisit
+	}
+
+	timestamp := now()
+	id, err := tx.Insert(`
+		INSERT INTO incidents
+			(schedule_id, client_id, caregiver_id, category, severity, status, description, occurred_at,
+			 injury, injury_details, reported_by, reporter_role, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		scheduleID, clientID, nullableInt(caregiverID), input.Category, input.Severity, models.IncidentOpen,
+		input.Description, formatTime(input.OccurredAt), input.Injury, nullableString(input.InjuryDetails),
+		actor.UserID, actor.Role, timestamp, timestamp)
+	if err != nil {
+		return models.Incident{}, err
+	}
+	if err := Audit(tx, actor, "incidents", int(id), nil); err != nil {
+		return models.Incident{}, err
+	}
+	return getIncidentAndCommit(tx, int(id))
+}
+
+// UpdateIncident applies changes to an incident's classification and follow-up, merged
+// with its current state in the same transaction so concurrent updates of different
+// fields are not lost. Moving it to resolved records who resolved it and when; moving it
+// back out of resolved clears them. It returns sql.ErrNoRows when the incident does not
+// exist, and ErrInjuryDetailsRequired or ErrFollowUpRequired when the merged incident
+// would be left without them.
+func (s *SQLStore) UpdateIncident(id int, update IncidentUpdate, actor StatusActor) (models.Incident, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.Incident{}, err
+	}
+	defer tx.Rollback()
+
+	incident, err := scanIncident(tx.QueryRow(incidentQuery+" WHERE i.id = ?", id))
+	if err != nil {
+		return models.Incident{}, err
+	}
+	before, err := Snapshot(tx, "incidents", id)
+	if err != nil {
+		return models.Incident{}, err
+	}
+
+	wasResolved := incident.Status == models.IncidentResolved
+	if update.Category != nil {
+		incident.Category = *update.Category
+	}
+	if update.Severity != nil {
+		incident.Severity = *update.Severity
+	}
+	if update.Status != nil {
+		incident.Status = *update.Status
+	}
+	if update.Injury != nil {
+		incident.Injury = *update.Injury
+	}
+	if update.InjuryDetails != nil {
+		incident.InjuryDetails = *update.InjuryDetails
+	}
+	if update.FollowUpNotes != nil {
+		incident.FollowUpNotes = *update.FollowUpNotes
+	}
+	if incident.Injury && incident.InjuryDetails == "" {
+		return models.Incident{}, ErrInjuryDetailsRequired
+	}
+	if incident.Status == models.IncidentResolved && incident.FollowUpNotes == "" {
+		return models.Incident{}, ErrFollowUpRequired
+	}
+
+	var resolver, resolved interface{}
+	switch {
+	case incident.Status != models.IncidentResolved:
+	case wasResolved:
+		resolver, resolved = incident.ResolvedBy, optionalTime(incident.ResolvedAt)
+	default:
+		resolver, resolved = actor.UserID, now()
+	}
+
+	_, err = tx.Exec(`
+		UPDATE incidents
+		SET category = ?, severity = ?, status = ?, injury = ?, injury_details = ?, follow_up_notes = ?,
+			resolved_by = ?, resolved_at = ?, updated_at = ?
+		WHERE id = ?`,
+		incident.Category, incident.Severity, incident.Status, incident.Injury,
+		nullableString(incident.InjuryDetails), nullableString(incident.FollowUpNotes),
+		resolver, resolved, now(), id)
+	if err != nil {
+		return models.Incident{}, err
+	}
+	if err := Audit(tx, actor, "incidents", id, before); err != nil {
+		return models.Incident{}, err
+	}
+	return getIncidentAndCommit(tx, id)
+}
+
+// getIncidentAndCommit reads back an incident written in tx and commits it
+func getIncidentAndCommit(tx *database.Tx, id int) (models.Incident, error) {
+	incident, err := scanIncident(tx.QueryRow(incidentQuery+" WHERE i.id = ?", id))
+	if err != nil {
+		return models.Incident{}, err
+	}
+	return incident, tx.Commit()
+}
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"visit-tracker-api/models"
+)
+
+// newIncidentSchedule adds a schedule whose visit is in progress and returns its ID
+func newIncidentSchedule(t *testing.T, s *SQLStore) int {
+	t.Helper()
+	shiftStart := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	scheduleID := createSchedule(t, s, ScheduleInput{
+		ClientID:   createClient(t, s, "Client"),
+		ShiftStart: shiftStart,
+		ShiftEnd:   shiftStart.Add(2 * time.Hour),
+	})
+	startVisit(t, s, scheduleID, shiftStart)
+	return scheduleID
+}
+
+// fileIncident files an incident of the given severity that occurred at the given time
+func fileIncident(t *testing.T, s *SQLStore, scheduleID int, severity string, occurredAt time.Time) models.Incident {
+	t.Helper()
+	incident, err := s.CreateIncident(scheduleID, IncidentInput{
+		Category:    models.IncidentFall,
+		Severity:    severity,
+		Description: "Client slipped in the bathroom",
+		OccurredAt:  occurredAt,
+	}, SystemActor)
+	if err != nil {
+		t.Fatalf("create incident: %v", err)
+	}
+	return incident
+}
+
+func TestListIncidentsQueueOrder(t *testing.T) {
+	s := newTestStore(t)
+	scheduleID := newIncidentSchedule(t, s)
+	base := time.Now().Add(-3 * time.Hour).UTC().Truncate(time.Second)
+
+	recentLow := fileIncident(t, s, scheduleID, models.SeverityLow, base.Add(2*time.Hour))
+	recentCritical := fileIncident(t, s, scheduleID, models.SeverityCritical, base.Add(time.Hour))
+	oldLow := fileIncident(t, s, scheduleID, models.SeverityLow, base)
+	oldCritical := fileIncident(t, s, scheduleID, models.SeverityCritical, base)
+	resolved := fileIncident(t, s, scheduleID, models.SeverityHigh, base)
+	notes, status := "Checked by the nurse", models.IncidentResolved
+	if _, err := s.UpdateIncident(resolved.ID, IncidentUpdate{Status: &status, FollowUpNotes: &notes}, SystemActor); err != nil {
+		t.Fatal(err)
+	}
+
+	queue, err := s.ListIncidents(IncidentFilter{Statuses: models.OpenIncidentStatuses})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{oldCritical.ID, recentCritical.ID, oldLow.ID, recentLow.ID}
+	if len(queue) != len(want) {
+		t.Fatalf("queue has %d incidents, want %d", len(queue), len(want))
+	}
+	for i, incident := range queue {
+		if incident.ID != want[i] {
+			t.Errorf("queue[%d] = incident %d (%s), want %d", i, incident.ID, incident.Severity, want[i])
+		}
+	}
+}
+
+func TestUpdateIncidentResolution(t *testing.T) {
+	s := newTestStore(t)
+	scheduleID := newIncidentSchedule(t, s)
+	incident := fileIncident(t, s, scheduleID, models.SeverityHigh, time.Now().Add(-time.Minute))
+
+	coordinatorID := createCoordinator(t, s)
+	coordinator := StatusActor{UserID: &coordinatorID, Role: models.RoleCoordinator}
+	resolved, inReview := models.IncidentResolved, models.IncidentInReview
+
+	if _, err := s.UpdateIncident(incident.ID, IncidentUpdate{Status: &resolved}, coordinator); !errors.Is(err, ErrFollowUpRequired) {
+		t.Fatalf("resolving without follow-up notes: error = %v, want ErrFollowUpRequired", err)
+	}
+	injury := true
+	if _, err := s.UpdateIncident(incident.ID, IncidentUpdate{Injury: &injury}, coordinator); !errors.Is(err, ErrInjuryDetailsRequired) {
+		t.Fatalf("recording an injury without details: error = %v, want ErrInjuryDetailsRequired", err)
+	}
+
+	notes := "Checked by the nurse, no injury"
+	updated, err := s.UpdateIncident(incident.ID, IncidentUpdate{Status: &resolved, FollowUpNotes: &notes}, coordinator)
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if updated.ResolvedBy == nil || *updated.ResolvedBy != coordinatorID || updated.ResolvedAt == nil {
+		t.Fatalf("resolved_by = %v, resolved_at = %v, want the coordinator and a time", updated.ResolvedBy, updated.ResolvedAt)
+	}
+	if updated.Category != incident.Category || updated.Severity != incident.Severity {
+		t.Errorf("category, severity = %s, %s; want them unchanged", updated.Category, updated.Severity)
+	}
+	resolvedAt := *updated.ResolvedAt
+
+	critical := models.SeverityCritical
+	updated, err = s.UpdateIncident(incident.ID, IncidentUpdate{Severity: &critical}, SystemActor)
+	if err != nil {
+		t.Fatalf("reclassify: %v", err)
+	}
+	if updated.ResolvedBy == nil || *updated.ResolvedBy != coordinatorID || updated.ResolvedAt == nil || !updated.ResolvedAt.Equal(resolvedAt) {
+		t.Errorf("after re-saving: resolved_by = %v, resolved_at = %v; want them kept", updated.ResolvedBy, updated.ResolvedAt)
+	}
+	if updated.Status != models.IncidentResolved || updated.FollowUpNotes != notes {
+		t.Errorf("status, follow_up_notes = %s, %q; want them kept", updated.Status, updated.FollowUpNotes)
+	}
+
+	updated, err = s.UpdateIncident(incident.ID, IncidentUpdate{Status: &inReview}, coordinator)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if updated.ResolvedBy != nil || updated.ResolvedAt != nil {
+		t.Errorf("after reopening: resolved_by = %v, resolved_at = %v; want them cleared", updated.ResolvedBy, updated.ResolvedAt)
+	}
+}
+
+// createCoordinator adds a coordinator account and returns its ID
+func createCoordinator(t *testing.T, s *SQLStore) int {
+	t.Helper()
+	id, err := s.db.Insert("INSERT INTO users (email, password_hash, role) VALUES ('coordinator@example.com', '-', 'coordinator')")
+	if err != nil {
+		t.Fatalf("create coordinator: %v", err)
+	}
+	return int(id)
+}
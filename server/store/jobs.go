@@ -0,0 +1,7 @@
+package store
+
+// RunExclusive runs fn unless another instance is already running the named job, and
+// reports whether it ran
+func (s *SQLStore) RunExclusive(job string, fn func() error) (bool, error) {
+	return s.db.TryLock(job, fn)
+}
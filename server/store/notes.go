@@ -0,0 +1,99 @@
+package store
+
+import (
+	"database/sql"
+
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+)
+
+const noteColumns = `n.id, n.visit_id, n.schedule_id, n.category, n.body,
+	n.author_id, u.email, n.author_role, n.created_at`
+
+// noteQuery selects notes with their author's email
+const noteQuery = `SELECT ` + noteColumns + `
+	FROM visit_notes n
+	LEFT JOIN users u ON u.id = n.author_id`
+
+// scanNote scans a visit note row selected with noteQuery
+func scanNote(row rowScanner) (models.VisitNote, error) {
+	var note models.VisitNote
+	var authorID sql.NullInt64
+	var authorEmail sql.NullString
+	var createdAt string
+
+	err := row.Scan(
+		&note.ID, &note.VisitID, &note.ScheduleID, &note.Category, &note.Body,
+		&authorID, &authorEmail, &note.AuthorRole, &createdAt,
+	)
+	if err != nil {
+		return note, err
+	}
+
+	note.AuthorID = nullableInt(authorID)
+	note.AuthorEmail = authorEmail.String
+	note.CreatedAt = utils.ParseTime(createdAt)
+	return note, nil
+}
+
+// ListVisitNotes returns the notes written about a schedule's visit, oldest first
+func (s *SQLStore) ListVisitNotes(scheduleID int) ([]models.VisitNote, error) {
+	rows, err := s.db.Query(noteQuery+`
+		WHERE n.schedule_id = ?
+		ORDER BY n.created_at ASC, n.id ASC`, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := []models.VisitNote{}
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+	return notes, rows.Err()
+}
+
+// AddVisitNote appends a note to a schedule's visit, written by the actor. It returns
+// ErrVisitNotStarted unless the visit is in progress or completed.
+func (s *SQLStore) AddVisitNote(scheduleID int, category, body string, actor StatusActor) (models.VisitNote, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.VisitNote{}, err
+	}
+	defer tx.Rollback()
+
+	var visitID int
+	var status string
+	err = tx.QueryRow(`
+		SELECT v.id, s.status
+		FROM visits v
+		JOIN schedules s ON s.id = v.schedule_id
+		WHERE v.schedule_id = ?`, scheduleID).Scan(&visitID, &status)
+	if err != nil {
+		return models.VisitNote{}, err
+	}
+	if status != models.StatusInProgress && status != models.StatusCompleted {
+		return models.VisitNote{}, ErrVisitNotStarted
+	}
+
+	id, err := tx.Insert(`
+		INSERT INTO visit_notes (visit_id, schedule_id, category, body, author_id, author_role, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		visitID, scheduleID, category, body, actor.UserID, actor.Role, now())
+	if err != nil {
+		return models.VisitNote{}, err
+	}
+	if err := Audit(tx, actor, "visit_notes", int(id), nil); err != nil {
+		return models.VisitNote{}, err
+	}
+
+	note, err := scanNote(tx.QueryRow(noteQuery+" WHERE n.id = ?", id))
+	if err != nil {
+		return models.VisitNote{}, err
+	}
+	return note, tx.Commit()
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"visit-tracker-api/models"
+)
+
+func TestAddVisitNote(t *testing.T) {
+	s := newTestStore(t)
+	shiftStart := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	scheduleID := createSchedule(t, s, ScheduleInput{
+		ClientID:   createClient(t, s, "Client"),
+		ShiftStart: shiftStart,
+		ShiftEnd:   shiftStart.Add(2 * time.Hour),
+	})
+	userID, err := s.db.Insert("INSERT INTO users (email, password_hash, role) VALUES ('carer@example.com', '-', 'caregiver')")
+	if err != nil {
+		t.Fatal(err)
+	}
+	author := int(userID)
+	actor := StatusActor{UserID: &author, Role: models.RoleCaregiver}
+
+	if _, err := s.AddVisitNote(scheduleID, models.NoteCategoryGeneral, "Arrived early", actor); !errors.Is(err, ErrVisitNotStarted) {
+		t.Fatalf("AddVisitNote before clock-in: error = %v, want ErrVisitNotStarted", err)
+	}
+	if _, err := s.AddVisitNote(scheduleID+1, models.NoteCategoryGeneral, "Arrived early", actor); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("AddVisitNote for an unknown schedule: error = %v, want sql.ErrNoRows", err)
+	}
+
+	startVisit(t, s, scheduleID, shiftStart)
+	note, err := s.AddVisitNote(scheduleID, models.NoteCategoryObservation, "Client seemed tired", actor)
+	if err != nil {
+		t.Fatalf("AddVisitNote during the visit: %v", err)
+	}
+	if note.AuthorID == nil || *note.AuthorID != author || note.AuthorEmail != "carer@example.com" || note.AuthorRole != models.RoleCaregiver {
+		t.Errorf("note author = %v %q %q, want the caregiver", note.AuthorID, note.AuthorEmail, note.AuthorRole)
+	}
+
+	mustExec(t, s, "UPDATE schedules SET status = ? WHERE id = ?", models.StatusCompleted, scheduleID)
+	if _, err := s.AddVisitNote(scheduleID, models.NoteCategoryFamilyCommunication, "Called her daughter", actor); err != nil {
+		t.Fatalf("AddVisitNote after clock-out: %v", err)
+	}
+
+	notes, err := s.ListVisitNotes(scheduleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 2 || notes[0].Body != "Client seemed tired" || notes[1].Category != models.NoteCategoryFamilyCommunication {
+		t.Errorf("notes = %+v, want the observation then the family call", notes)
+	}
+}
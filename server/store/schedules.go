@@ -0,0 +1,580 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"visit-tracker-api/database"
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+)
+
+const scheduleColumns = `s.id, s.caregiver_id, s.client_id, c.name, s.shift_start, s.shift_end, c.timezone, c.latitude, c.longitude,
+	s.status, s.created_at, s.updated_at, s.cancellation_reason, s.cancelled_at, s.template_id, s.service_code`
+
+const scheduleFrom = `
+	FROM schedules s
+	JOIN clients c ON c.id = s.client_id`
+
+// scanSchedule scans a schedule row selected with scheduleColumns
+func scanSchedule(row rowScanner) (models.Schedule, error) {
+	var schedule models.Schedule
+	var caregiverID, templateID sql.NullInt64
+	var shiftStart, shiftEnd, createdAt, updatedAt string
+	var timezone, cancellationReason, cancelledAt, serviceCode sql.NullString
+
+	err := row.Scan(
+		&schedule.ID, &caregiverID, &schedule.ClientID, &schedule.ClientName, &shiftStart, &shiftEnd, &timezone,
+		&schedule.Latitude, &schedule.Longitude, &schedule.Status, &createdAt, &updatedAt,
+		&cancellationReason, &cancelledAt, &templateID, &serviceCode,
+	)
+	if err != nil {
+		return schedule, err
+	}
+
+	schedule.CaregiverID = nullableInt(caregiverID)
+	schedule.ShiftStart = utils.ParseTime(shiftStart)
+	schedule.ShiftEnd = utils.ParseTime(shiftEnd)
+	schedule.Timezone = utils.Location(timezone.String).String()
+	schedule.CreatedAt = utils.ParseTime(createdAt)
+	schedule.UpdatedAt = utils.ParseTime(updatedAt)
+	schedule.CancellationReason = cancellationReason.String
+	schedule.CancelledAt = nullableTime(cancelledAt)
+	schedule.TemplateID = nullableInt(templateID)
+	schedule.ServiceCode = serviceCode.String
+	return schedule, nil
+}
+
+// querySchedules runs a schedule query and scans every row
+func (s *SQLStore) querySchedules(query string, args ...interface{}) ([]models.Schedule, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []models.Schedule{}
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+// scheduleConditions returns the WHERE conditions and arguments selecting the
+// schedules matching a filter. Day only narrows the shifts to those that could fall on
+// the date in some time zone; callers check each one in its client's zone.
+func scheduleConditions(filter ScheduleFilter) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if !filter.Day.IsZero() {
+		from, to := dayWindow(filter.Day)
+		conditions = append(conditions, "s.shift_start >= ? AND s.shift_start < ?")
+		args = append(args, from, to)
+	}
+	if filter.CaregiverID != nil {
+		conditions = append(conditions, "s.caregiver_id = ?")
+		args = append(args, *filter.CaregiverID)
+	}
+	if filter.ClientID != nil {
+		conditions = append(conditions, "s.client_id = ?")
+		args = append(args, *filter.ClientID)
+	}
+	if len(filter.Statuses) > 0 {
+		conditions = append(conditions, "s.status IN (?"+strings.Repeat(", ?", len(filter.Statuses)-1)+")")
+		for _, status := range filter.Statuses {
+			args = append(args, status)
+		}
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "s.shift_start >= ?")
+		args = append(args, formatTime(filter.From))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "s.shift_start < ?")
+		args = append(args, formatTime(filter.To))
+	}
+	return conditions, args
+}
+
+// whereClause joins conditions into a WHERE clause, or returns "" when there are none
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "\n\tWHERE " + strings.Join(conditions, " AND ")
+}
+
+// ListSchedules returns the schedules matching the filter, earliest shift first
+func (s *SQLStore) ListSchedules(filter ScheduleFilter) ([]models.Schedule, error) {
+	conditions, args := scheduleConditions(filter)
+	query := "SELECT " + scheduleColumns + scheduleFrom + whereClause(conditions)
+	query += "\n\tORDER BY s.shift_start ASC"
+
+	schedules, err := s.querySchedules(query, args...)
+	if err != nil || filter.Day.IsZero() {
+		return schedules, err
+	}
+
+	onDay := []models.Schedule{}
+	for _, schedule := range schedules {
+		if sameLocalDate(schedule.ShiftStart, filter.Day, schedule.Timezone) {
+			onDay = append(onDay, schedule)
+		}
+	}
+	return onDay, nil
+}
+
+// scheduleSortColumns maps each sort to the column it orders by
+var scheduleSortColumns = map[string]string{
+	SortShiftStart: "s.shift_start",
+	SortShiftEnd:   "s.shift_end",
+	SortCreatedAt:  "s.created_at",
+	SortUpdatedAt:  "s.updated_at",
+	SortClientName: "c.name",
+	SortStatus:     "s.status",
+}
+
+// scheduleSortValue returns a schedule's value of the sort column, as stored. Times
+// keep any fractional seconds, which PostgreSQL defaults record.
+func scheduleSortValue(schedule models.Schedule, sort string) string {
+	const layout = "2006-01-02 15:04:05.999999"
+	switch sort {
+	case SortShiftEnd:
+		return schedule.ShiftEnd.UTC().Format(layout)
+	case SortCreatedAt:
+		return schedule.CreatedAt.UTC().Format(layout)
+	case SortUpdatedAt:
+		return schedule.UpdatedAt.UTC().Format(layout)
+	case SortClientName:
+		return schedule.ClientName
+	case SortStatus:
+		return schedule.Status
+	}
+	return schedule.ShiftStart.UTC().Format(layout)
+}
+
+// ListSchedulePage returns one page of the schedules matching the filter in the page's
+// order, how many match in all, and the cursor of the next page, nil on the last page.
+// Pages are keyed on the sort column and ID, so rows added or removed between requests
+// do not shift later pages.
+func (s *SQLStore) ListSchedulePage(filter ScheduleFilter, page SchedulePage) ([]models.Schedule, int, *ScheduleCursor, error) {
+	column, ok := scheduleSortColumns[page.Sort]
+	if !ok {
+		column, page.Sort = scheduleSortColumns[SortShiftStart], SortShiftStart
+	}
+	conditions, args := scheduleConditions(filter)
+
+	var total int
+	err := s.db.QueryRow("SELECT COUNT(*)"+scheduleFrom+whereClause(conditions), args...).Scan(&total)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	direction, comparison := "ASC", ">"
+	if page.Desc {
+		direction, comparison = "DESC", "<"
+	}
+	if page.After != nil {
+		conditions = append(conditions,
+			"("+column+" "+comparison+" ? OR ("+column+" = ? AND s.id "+comparison+" ?))")
+		args = append(args, page.After.Value, page.After.Value, page.After.ID)
+	}
+
+	// Fetch one extra row to learn whether there is a next page
+	query := "SELECT " + scheduleColumns + scheduleFrom + whereClause(conditions) +
+		"\n\tORDER BY " + column + " " + direction + ", s.id " + direction + "\n\tLIMIT ?"
+	schedules, err := s.querySchedules(query, append(args, page.Limit+1)...)
+	if err != nil || len(schedules) <= page.Limit {
+		return schedules, total, nil, err
+	}
+
+	schedules = schedules[:page.Limit]
+	last := schedules[len(schedules)-1]
+	return schedules, total, &ScheduleCursor{Value: scheduleSortValue(last, page.Sort), ID: last.ID}, nil
+}
+
+// GetSchedule returns a schedule, or sql.ErrNoRows when it does not exist
+func (s *SQLStore) GetSchedule(id int) (models.Schedule, error) {
+	row := s.db.QueryRow("SELECT "+scheduleColumns+scheduleFrom+"\n\tWHERE s.id = ?", id)
+	return scanSchedule(row)
+}
+
+// GetStats counts schedules for the dashboard. Today is the date at now in each
+// schedule's client's time zone.
+func (s *SQLStore) GetStats(now time.Time) (models.StatsResponse, error) {
+	var stats models.StatsResponse
+
+	err := s.db.QueryRow("SELECT COUNT(*) FROM schedules").Scan(&stats.TotalSchedules)
+	if err != nil {
+		return stats, err
+	}
+
+	err = s.db.QueryRow("SELECT COUNT(*) FROM schedules WHERE status = ?", models.StatusMissed).Scan(&stats.MissedSchedules)
+	if err != nil {
+		return stats, err
+	}
+
+	from, to := dayWindow(now)
+	rows, err := s.db.Query(`
+		SELECT s.shift_start, s.status, c.timezone
+		FROM schedules s
+		JOIN clients c ON c.id = s.client_id
+		WHERE s.shift_start >= ? AND s.shift_start < ? AND s.status IN (?, ?)`,
+		from, to, models.StatusUpcoming, models.StatusCompleted)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var shiftStart, status string
+		var timezone sql.NullString
+		if err := rows.Scan(&shiftStart, &status, &timezone); err != nil {
+			return stats, err
+		}
+		if !sameLocalDate(utils.ParseTime(shiftStart), now, timezone.String) {
+			continue
+		}
+		if status == models.StatusUpcoming {
+			stats.UpcomingToday++
+		} else {
+			stats.CompletedToday++
+		}
+	}
+	return stats, rows.Err()
+}
+
+// CreateSchedule creates an upcoming schedule with its visit record and tasks and
+// returns its ID. It returns an *OverlapError when the caregiver is already booked
+// and ErrTemplateShiftExists when the template already generated the shift.
+func (s *SQLStore) CreateSchedule(schedule ScheduleInput, actor StatusActor) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := checkOverlap(tx, schedule, 0); err != nil {
+		return 0, err
+	}
+
+	scheduleID, err := insertSchedule(tx, schedule)
+	if err != nil {
+		return 0, err
+	}
+	if err := Audit(tx, actor, "schedules", scheduleID, nil); err != nil {
+		return 0, err
+	}
+
+	visitID, err := tx.Insert("INSERT INTO visits (schedule_id) VALUES (?)", scheduleID)
+	if err != nil {
+		return 0, err
+	}
+	if err := Audit(tx, actor, "visits", int(visitID), nil); err != nil {
+		return 0, err
+	}
+	if err := insertTasks(tx, scheduleID, schedule.Tasks, actor); err != nil {
+		return 0, err
+	}
+	if err := insertCarePlanTasks(tx, scheduleID, schedule, actor); err != nil {
+		return 0, err
+	}
+	if err := recordStatusChange(tx, scheduleID, "", StatusChange{To: models.StatusUpcoming}, actor); err != nil {
+		return 0, err
+	}
+
+	return scheduleID, tx.Commit()
+}
+
+// insertSchedule inserts an upcoming schedule row and returns its ID. A template's
+// shift is inserted only if no schedule holds the same template and shift start, which
+// the unique index enforces even when instances generate shifts concurrently.
+func insertSchedule(tx *database.Tx, schedule ScheduleInput) (int, error) {
+	timestamp := now()
+	args := []interface{}{
+		schedule.CaregiverID, schedule.ClientID, formatTime(schedule.ShiftStart), formatTime(schedule.ShiftEnd),
+		models.StatusUpcoming, schedule.TemplateID, nullableString(schedule.ServiceCode), timestamp, timestamp,
+	}
+	insert := `
+		INSERT INTO schedules (caregiver_id, client_id, shift_start, shift_end, status, template_id, service_code, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	if schedule.TemplateID == nil {
+		id, err := tx.Insert(insert, args...)
+		return int(id), err
+	}
+
+	result, err := tx.Exec(insert+`
+		ON CONFLICT (template_id, shift_start) DO NOTHING`, args...)
+	if err != nil {
+		return 0, err
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if inserted == 0 {
+		return 0, ErrTemplateShiftExists
+	}
+
+	var id int
+	err = tx.QueryRow(
+		"SELECT id FROM schedules WHERE template_id = ? AND shift_start = ?",
+		*schedule.TemplateID, formatTime(schedule.ShiftStart)).Scan(&id)
+	return id, err
+}
+
+// UpdateSchedule reschedules or reassigns an upcoming schedule. Tasks replace the ones
+// not copied from the client's care plan; nil tasks keep the existing ones.
+func (s *SQLStore) UpdateSchedule(id int, schedule ScheduleInput, actor StatusActor) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status, shiftStart string
+	var clientID int
+	err = tx.QueryRow("SELECT status, client_id, shift_start FROM schedules WHERE id = ?", id).Scan(&status, &clientID, &shiftStart)
+	if err != nil {
+		return err
+	}
+	if status != models.StatusUpcoming {
+		return ErrScheduleNotEditable
+	}
+
+	if err := checkOverlap(tx, schedule, id); err != nil {
+		return err
+	}
+
+	before, err := Snapshot(tx, "schedules", id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		UPDATE schedules
+		SET caregiver_id = ?, client_id = ?, shift_start = ?, shift_end = ?, service_code = ?, updated_at = ?
+		WHERE id = ?`,
+		schedule.CaregiverID, schedule.ClientID, formatTime(schedule.ShiftStart), formatTime(schedule.ShiftEnd),
+		nullableString(schedule.ServiceCode), now(), id)
+	if err != nil {
+		return err
+	}
+	if err := Audit(tx, actor, "schedules", id, before); err != nil {
+		return err
+	}
+
+	// Care-plan tasks are due per client and, for daily and weekly ones, per local date,
+	// so a shift moved to another client or date takes the tasks due there instead
+	moved := clientID != schedule.ClientID
+	if !moved {
+		var timezone sql.NullString
+		if err := tx.QueryRow("SELECT timezone FROM clients WHERE id = ?", clientID).Scan(&timezone); err != nil {
+			return err
+		}
+		moved = !sameLocalDate(utils.ParseTime(shiftStart), schedule.ShiftStart, timezone.String)
+	}
+	if moved {
+		if err := replaceCarePlanTasks(tx, id, schedule, actor); err != nil {
+			return err
+		}
+	}
+
+	if schedule.Tasks != nil {
+		adHoc := "schedule_id = ? AND care_plan_task_id IS NULL"
+		if err := AuditDeletes(tx, actor, "tasks", adHoc, id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM tasks WHERE "+adHoc, id); err != nil {
+			return err
+		}
+
+		// Care-plan tasks stay, so leave out the ones listed again
+		planned, err := taskDescriptions(tx, id)
+		if err != nil {
+			return err
+		}
+		var tasks []string
+		for _, description := range schedule.Tasks {
+			if !planned[description] {
+				tasks = append(tasks, description)
+			}
+		}
+		if err := insertTasks(tx, id, tasks, actor); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CancelSchedule cancels a schedule with a reason through the state machine
+func (s *SQLStore) CancelSchedule(id int, reason string, actor StatusActor) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := Snapshot(tx, "schedules", id)
+	if err != nil {
+		return err
+	}
+	_, err = transitionSchedule(tx, id, StatusChange{To: models.StatusCancelled, Reason: reason}, actor)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE schedules SET cancellation_reason = ?, cancelled_at = ? WHERE id = ?",
+		reason, now(), id)
+	if err != nil {
+		return err
+	}
+	if err := Audit(tx, actor, "schedules", id, before); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListOverdueSchedules returns schedules in one of the statuses whose shift started at
+// or before cutoff, earliest first
+func (s *SQLStore) ListOverdueSchedules(cutoff time.Time, statuses []string) ([]models.Schedule, error) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(statuses)), ", ")
+	args := []interface{}{formatTime(cutoff)}
+	for _, status := range statuses {
+		args = append(args, status)
+	}
+
+	return s.querySchedules("SELECT "+scheduleColumns+scheduleFrom+`
+	WHERE s.shift_start <= ? AND s.status IN (`+placeholders+`)
+	ORDER BY s.shift_start ASC`, args...)
+}
+
+// TemplateScheduleExists reports whether a template already generated the shift
+// starting at shiftStart, even if it has since been cancelled
+func (s *SQLStore) TemplateScheduleExists(templateID int, shiftStart time.Time) (bool, error) {
+	var count int
+	err := s.db.QueryRow(
+		"SELECT COUNT(*) FROM schedules WHERE template_id = ? AND shift_start = ?",
+		templateID, formatTime(shiftStart)).Scan(&count)
+	return count > 0, err
+}
+
+// CancelTemplateSchedules cancels a template's upcoming schedules starting within
+// [from, to) through the state machine so they can be regenerated after the template
+// changes; a zero to leaves the range open. The cancelled schedules keep their history
+// but are detached from the template, so the shifts they held can be generated again.
+// Shifts that have already started are kept.
+func (s *SQLStore) CancelTemplateSchedules(templateID int, from, to time.Time, reason string, actor StatusActor) error {
+	query := `SELECT id FROM schedules WHERE template_id = ? AND status = ? AND shift_start >= ?`
+	args := []interface{}{templateID, models.StatusUpcoming, formatTime(from)}
+	if !to.IsZero() {
+		query += ` AND shift_start < ?`
+		args = append(args, formatTime(to))
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		before, err := Snapshot(tx, "schedules", id)
+		if err != nil {
+			return err
+		}
+		_, err = transitionSchedule(tx, id, StatusChange{To: models.StatusCancelled, Reason: reason}, actor)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(
+			"UPDATE schedules SET cancellation_reason = ?, cancelled_at = ?, template_id = NULL WHERE id = ?",
+			reason, now(), id)
+		if err != nil {
+			return err
+		}
+		if err := Audit(tx, actor, "schedules", id, before); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// checkOverlap returns an *OverlapError when the schedule's caregiver already has a
+// non-cancelled shift overlapping it. excludeID skips the shift being edited. The
+// caregiver's row stays locked until the transaction ends, so concurrent bookings of the
+// same caregiver are checked one after the other rather than both passing.
+func checkOverlap(tx *database.Tx, schedule ScheduleInput, excludeID int) error {
+	if schedule.CaregiverID == nil {
+		return nil
+	}
+
+	var caregiverID int
+	err := tx.QueryRow("SELECT id FROM caregivers WHERE id = ?"+tx.Dialect.ForUpdate(), *schedule.CaregiverID).Scan(&caregiverID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	var conflictID int
+	err = tx.QueryRow(`
+		SELECT id FROM schedules
+		WHERE caregiver_id = ? AND status != ? AND id != ?
+		  AND shift_start < ? AND shift_end > ?
+		ORDER BY shift_start ASC
+		LIMIT 1`,
+		*schedule.CaregiverID, models.StatusCancelled, excludeID,
+		formatTime(schedule.ShiftEnd), formatTime(schedule.ShiftStart)).Scan(&conflictID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return &OverlapError{CaregiverID: *schedule.CaregiverID, ScheduleID: conflictID}
+}
+
+// insertTasks adds pending tasks to a schedule inside a transaction
+func insertTasks(tx *database.Tx, scheduleID int, descriptions []string, actor StatusActor) error {
+	for _, description := range descriptions {
+		id, err := tx.Insert(`
+			INSERT INTO tasks (schedule_id, description, status)
+			VALUES (?, ?, 'pending')`,
+			scheduleID, description)
+		if err != nil {
+			return err
+		}
+		if err := Audit(tx, actor, "tasks", int(id), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package store
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestCreateScheduleGeneratesTemplateShiftOnce(t *testing.T) {
+	s := newTestStore(t)
+	clientID := createClient(t, s, "Client")
+	templateID := createTemplate(t, s, clientID)
+	otherTemplateID := createTemplate(t, s, clientID)
+	start := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+
+	shift := func(templateID *int, start time.Time) ScheduleInput {
+		return ScheduleInput{ClientID: clientID, TemplateID: templateID, ShiftStart: start, ShiftEnd: start.Add(2 * time.Hour)}
+	}
+	first := createSchedule(t, s, shift(&templateID, start))
+
+	tests := []struct {
+		name    string
+		input   ScheduleInput
+		wantErr error
+	}{
+		{"same template and start", shift(&templateID, start), ErrTemplateShiftExists},
+		{"same template, next day", shift(&templateID, start.AddDate(0, 0, 1)), nil},
+		{"other template, same start", shift(&otherTemplateID, start), nil},
+		{"no template, same start", shift(nil, start), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := s.CreateSchedule(tt.input, SystemActor)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateSchedule() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && id == first {
+				t.Errorf("CreateSchedule() returned the existing schedule %d", first)
+			}
+		})
+	}
+
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM visits WHERE schedule_id IN (SELECT id FROM schedules WHERE template_id = ?)", templateID).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("template has %d visits, want 2", count)
+	}
+}
+
+func TestCancelTemplateSchedulesKeepsHistory(t *testing.T) {
+	s := newTestStore(t)
+	clientID := createClient(t, s, "Client")
+	templateID := createTemplate(t, s, clientID)
+	day := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+
+	shift := func(start time.Time) ScheduleInput {
+		return ScheduleInput{ClientID: clientID, TemplateID: &templateID, ShiftStart: start, ShiftEnd: start.Add(2 * time.Hour)}
+	}
+	started := createSchedule(t, s, shift(day))
+	startVisit(t, s, started, day)
+	replaced := createSchedule(t, s, shift(day.AddDate(0, 0, 1)))
+	later := createSchedule(t, s, shift(day.AddDate(0, 0, 2)))
+
+	err := s.CancelTemplateSchedules(templateID, day, day.AddDate(0, 0, 2), "Template changed", SystemActor)
+	if err != nil {
+		t.Fatalf("CancelTemplateSchedules() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		scheduleID   int
+		wantStatus   string
+		wantTemplate bool
+	}{
+		{"started shift is kept", started, "in_progress", true},
+		{"upcoming shift in range is cancelled and detached", replaced, "cancelled", false},
+		{"upcoming shift after range is kept", later, "upcoming", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := s.GetSchedule(tt.scheduleID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if schedule.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", schedule.Status, tt.wantStatus)
+			}
+			if (schedule.TemplateID != nil) != tt.wantTemplate {
+				t.Errorf("template_id = %v, want attached %v", schedule.TemplateID, tt.wantTemplate)
+			}
+		})
+	}
+
+	history, err := s.GetStatusHistory(replaced)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[1].ToStatus != "cancelled" {
+		t.Fatalf("history = %+v, want the creation and the cancellation", history)
+	}
+	if _, err := s.CreateSchedule(shift(day.AddDate(0, 0, 1)), SystemActor); err != nil {
+		t.Errorf("regenerating the cancelled shift: %v", err)
+	}
+}
+
+func TestCreateScheduleRejectsOverlappingShifts(t *testing.T) {
+	s := newTestStore(t)
+	clientID := createClient(t, s, "Client")
+	caregiverID := createCaregiver(t, s, "carer@example.com")
+	otherCaregiverID := createCaregiver(t, s, "other@example.com")
+	start := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+
+	shift := func(caregiverID int, start time.Time, hours int) ScheduleInput {
+		return ScheduleInput{ClientID: clientID, CaregiverID: &caregiverID, ShiftStart: start, ShiftEnd: start.Add(time.Duration(hours) * time.Hour)}
+	}
+	booked := createSchedule(t, s, shift(caregiverID, start, 2))
+	cancelled := createSchedule(t, s, shift(caregiverID, start.Add(4*time.Hour), 2))
+	if err := s.CancelSchedule(cancelled, "Client away", SystemActor); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		input       ScheduleInput
+		wantOverlap int
+	}{
+		{"overlaps the start", shift(caregiverID, start.Add(-time.Hour), 2), booked},
+		{"inside the shift", shift(caregiverID, start.Add(30*time.Minute), 1), booked},
+		{"ends as the shift starts", shift(caregiverID, start.Add(-2*time.Hour), 2), 0},
+		{"starts as the shift ends", shift(caregiverID, start.Add(2*time.Hour), 1), 0},
+		{"over a cancelled shift", shift(caregiverID, start.Add(4*time.Hour), 2), 0},
+		{"another caregiver", shift(otherCaregiverID, start, 2), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.CreateSchedule(tt.input, SystemActor)
+			var overlap *OverlapError
+			switch {
+			case tt.wantOverlap == 0 && err != nil:
+				t.Fatalf("CreateSchedule() error = %v, want none", err)
+			case tt.wantOverlap != 0 && !errors.As(err, &overlap):
+				t.Fatalf("CreateSchedule() error = %v, want an *OverlapError", err)
+			case tt.wantOverlap != 0 && overlap.ScheduleID != tt.wantOverlap:
+				t.Errorf("conflicting schedule = %d, want %d", overlap.ScheduleID, tt.wantOverlap)
+			}
+		})
+	}
+}
+
+func TestUpdateScheduleReplacesCarePlanTasks(t *testing.T) {
+	s := newTestStore(t)
+	clientID := createClient(t, s, "Client")
+	otherClientID := createClient(t, s, "Other client")
+	createCarePlanTask(t, s, clientID, "Medication", "every_visit")
+	createCarePlanTask(t, s, clientID, "Laundry", "daily")
+	createCarePlanTask(t, s, otherClientID, "Shopping", "weekly")
+
+	monday := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	shift := func(clientID int, start time.Time) ScheduleInput {
+		return ScheduleInput{ClientID: clientID, ShiftStart: start, ShiftEnd: start.Add(2 * time.Hour)}
+	}
+	moved := shift(clientID, monday)
+	moved.Tasks = []string{"Chat"}
+	id := createSchedule(t, s, moved)
+	wednesday := monday.AddDate(0, 0, 2)
+	createSchedule(t, s, shift(clientID, wednesday))
+
+	tests := []struct {
+		name  string
+		input ScheduleInput
+		want  []string
+	}{
+		{"later the same day", shift(clientID, monday.Add(time.Hour)), []string{"Chat", "Laundry", "Medication"}},
+		{"another client", shift(otherClientID, monday), []string{"Chat", "Shopping"}},
+		{"back on another day", shift(clientID, monday.AddDate(0, 0, 1)), []string{"Chat", "Laundry", "Medication"}},
+		{"a day another shift has the daily task", shift(clientID, wednesday.Add(-4*time.Hour)), []string{"Chat", "Medication"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.UpdateSchedule(id, tt.input, SystemActor); err != nil {
+				t.Fatalf("UpdateSchedule() error = %v", err)
+			}
+			tasks, err := s.ListTasks(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, task := range tasks {
+				got = append(got, task.Description)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tasks = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListSchedulePageWalksKeyset(t *testing.T) {
+	s := newTestStore(t)
+	clients := []int{createClient(t, s, "Bob"), createClient(t, s, "Alice")}
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	// Each shift starts at the same time as another, so pages have to break ties on ID
+	for day := 0; day < 4; day++ {
+		for _, clientID := range clients {
+			shiftStart := start.AddDate(0, 0, day)
+			createSchedule(t, s, ScheduleInput{ClientID: clientID, ShiftStart: shiftStart, ShiftEnd: shiftStart.Add(2 * time.Hour)})
+		}
+	}
+	for _, id := range []int{2, 5} {
+		if err := s.CancelSchedule(id, "Client away", SystemActor); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// walk follows the cursors from page to the last page and returns the IDs in order
+	walk := func(t *testing.T, page SchedulePage) []int {
+		t.Helper()
+		var ids []int
+		for pages := 0; ; pages++ {
+			if pages > 10 {
+				t.Fatalf("still paging after %d pages: %v", pages, ids)
+			}
+			schedules, total, next, err := s.ListSchedulePage(ScheduleFilter{}, page)
+			if err != nil {
+				t.Fatalf("ListSchedulePage() error = %v", err)
+			}
+			if total != 8 {
+				t.Errorf("total = %d, want 8", total)
+			}
+			for _, schedule := range schedules {
+				ids = append(ids, schedule.ID)
+			}
+			if next == nil {
+				return ids
+			}
+			page.After = next
+		}
+	}
+
+	tests := []struct {
+		sort string
+		desc bool
+		want []int
+	}{
+		{SortShiftStart, false, []int{1, 2, 3, 4, 5, 6, 7, 8}},
+		{SortShiftStart, true, []int{8, 7, 6, 5, 4, 3, 2, 1}},
+		{SortShiftEnd, false, []int{1, 2, 3, 4, 5, 6, 7, 8}},
+		{SortClientName, false, []int{2, 4, 6, 8, 1, 3, 5, 7}},
+		{SortClientName, true, []int{7, 5, 3, 1, 8, 6, 4, 2}},
+		{SortStatus, false, []int{2, 5, 1, 3, 4, 6, 7, 8}},
+	}
+	for _, tt := range tests {
+		name := tt.sort
+		if tt.desc {
+			name = "-" + name
+		}
+		t.Run(name, func(t *testing.T) {
+			for _, limit := range []int{1, 3, 8, 50} {
+				got := walk(t, SchedulePage{Sort: tt.sort, Desc: tt.desc, Limit: limit})
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("limit %d: IDs = %v, want %v", limit, got, tt.want)
+				}
+			}
+		})
+	}
+
+	t.Run("schedules added before the cursor", func(t *testing.T) {
+		page := SchedulePage{Sort: SortShiftStart, Limit: 3}
+		schedules, _, next, err := s.ListSchedulePage(ScheduleFilter{}, page)
+		if err != nil || len(schedules) != 3 || next == nil {
+			t.Fatalf("first page = %d schedules, next %v, error %v", len(schedules), next, err)
+		}
+
+		earlier := start.Add(-24 * time.Hour)
+		createSchedule(t, s, ScheduleInput{ClientID: clients[0], ShiftStart: earlier, ShiftEnd: earlier.Add(2 * time.Hour)})
+
+		page.After, page.Limit = next, 50
+		schedules, _, _, err = s.ListSchedulePage(ScheduleFilter{}, page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []int
+		for _, schedule := range schedules {
+			got = append(got, schedule.ID)
+		}
+		if want := []int{4, 5, 6, 7, 8}; !reflect.DeepEqual(got, want) {
+			t.Errorf("next page IDs = %v, want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"visit-tracker-api/database"
+	"visit-tracker-api/utils"
+)
+
+// SQLStore implements every store on top of the SQL database, SQLite or PostgreSQL
+type SQLStore struct {
+	db *database.Conn
+}
+
+// NewSQLStore returns a store backed by an open database connection
+func NewSQLStore(db *database.Conn) *SQLStore {
+	return &SQLStore{db: db}
+}
+
+var (
+	_ ScheduleStore  = (*SQLStore)(nil)
+	_ VisitStore     = (*SQLStore)(nil)
+	_ TaskStore      = (*SQLStore)(nil)
+	_ NoteStore      = (*SQLStore)(nil)
+	_ IncidentStore  = (*SQLStore)(nil)
+	_ ActivityStore  = (*SQLStore)(nil)
+	_ SyncStore      = (*SQLStore)(nil)
+	_ EVVStore       = (*SQLStore)(nil)
+	_ ClientStore    = (*SQLStore)(nil)
+	_ CaregiverStore = (*SQLStore)(nil)
+	_ TemplateStore  = (*SQLStore)(nil)
+	_ CarePlanStore  = (*SQLStore)(nil)
+	_ AuditStore     = (*SQLStore)(nil)
+	_ UserStore      = (*SQLStore)(nil)
+	_ JobStore       = (*SQLStore)(nil)
+)
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// queryer is implemented by both *database.Conn and *database.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// now returns the current time formatted for storage
+func now() string {
+	return utils.FormatTime(time.Now())
+}
+
+// formatTime formats a time for storage in UTC
+func formatTime(t time.Time) string {
+	return utils.FormatTime(t)
+}
+
+// dayWindow returns the stored range of times that falls on the same date as t in at
+// least one time zone. UTC offsets run from -12 to +14 hours, and a date lasts up to 25
+// hours across a daylight saving change.
+func dayWindow(t time.Time) (string, string) {
+	return formatTime(t.Add(-26 * time.Hour)), formatTime(t.Add(26 * time.Hour))
+}
+
+// sameLocalDate reports whether a and b fall on the same date in the named time zone,
+// or the agency's when it is empty
+func sameLocalDate(a, b time.Time, timezone string) bool {
+	loc := utils.Location(timezone)
+	return utils.LocalDate(a, loc).Equal(utils.LocalDate(b, loc))
+}
+
+// nullableString stores empty strings as NULL
+func nullableString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// nullableInt converts a nullable integer column to a pointer
+func nullableInt(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	id := int(value.Int64)
+	return &id
+}
+
+// nullableFloat converts a nullable real column to a pointer
+func nullableFloat(value sql.NullFloat64) *float64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Float64
+}
+
+// nullableTime parses a nullable timestamp column to a pointer
+func nullableTime(value sql.NullString) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	t := utils.ParseTime(value.String)
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,148 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+
+	"visit-tracker-api/database"
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+)
+
+// TransitionSchedule moves a schedule to a new status, returning the previous status or
+// a *models.TransitionError when the state machine does not allow it
+func (s *SQLStore) TransitionSchedule(id int, change StatusChange, actor StatusActor) (string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	before, err := Snapshot(tx, "schedules", id)
+	if err != nil {
+		return "", err
+	}
+	from, err := transitionSchedule(tx, id, change, actor)
+	if err != nil {
+		return from, err
+	}
+	if err := Audit(tx, actor, "schedules", id, before); err != nil {
+		return from, err
+	}
+	return from, tx.Commit()
+}
+
+// transitionSchedule moves a schedule to a new status inside a transaction, enforcing the
+// schedule state machine and recording the change in the status history. Callers add the
+// change to the audit log.
+func transitionSchedule(tx *database.Tx, scheduleID int, change StatusChange, actor StatusActor) (string, error) {
+	var from string
+	if err := tx.QueryRow("SELECT status FROM schedules WHERE id = ?", scheduleID).Scan(&from); err != nil {
+		return "", err
+	}
+	if !models.CanTransition(from, change.To) {
+		reconciled, err := reconcilesStatus(tx, scheduleID, from, change)
+		if err != nil {
+			return from, err
+		}
+		if !reconciled {
+			return from, &models.TransitionError{From: from, To: change.To}
+		}
+		note := "Recorded offline at " + formatTime(change.RecordedAt) + " UTC, before the schedule was marked " + from
+		if change.Reason != "" {
+			note += "; " + change.Reason
+		}
+		change.Reason = note
+	}
+
+	result, err := tx.Exec(
+		"UPDATE schedules SET status = ?, updated_at = ? WHERE id = ? AND status = ?",
+		change.To, now(), scheduleID, from)
+	if err != nil {
+		return from, err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return from, err
+	} else if affected == 0 {
+		return from, &models.TransitionError{From: from, To: change.To}
+	}
+
+	return from, recordStatusChange(tx, scheduleID, from, change, actor)
+}
+
+// reconcilesStatus reports whether a change a device recorded offline happened before the
+// schedule moved to its current status, and the state machine allows such a change from it
+func reconcilesStatus(tx *database.Tx, scheduleID int, from string, change StatusChange) (bool, error) {
+	if change.RecordedAt.IsZero() || !models.CanReconcile(from, change.To) {
+		return false, nil
+	}
+
+	var changedAt string
+	err := tx.QueryRow(`
+		SELECT changed_at FROM schedule_status_history
+		WHERE schedule_id = ? AND to_status = ?
+		ORDER BY changed_at DESC, id DESC
+		LIMIT 1`, scheduleID, from).Scan(&changedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return change.RecordedAt.Before(utils.ParseTime(changedAt)), nil
+}
+
+// recordStatusChange appends an entry to a schedule's status history. An empty from
+// records the status a schedule was created with.
+func recordStatusChange(tx *database.Tx, scheduleID int, from string, change StatusChange, actor StatusActor) error {
+	_, err := tx.Exec(`
+		INSERT INTO schedule_status_history
+			(schedule_id, from_status, to_status, changed_by, actor_role, reason, latitude, longitude, ip_address, request_id, changed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		scheduleID, nullableString(from), change.To, actor.UserID, actor.Role, nullableString(change.Reason),
+		change.Latitude, change.Longitude, nullableString(actor.IPAddress), nullableString(actor.RequestID),
+		now())
+	return err
+}
+
+// GetStatusHistory returns a schedule's status changes, oldest first
+func (s *SQLStore) GetStatusHistory(id int) ([]models.ScheduleStatusChange, error) {
+	rows, err := s.db.Query(`
+		SELECT id, schedule_id, from_status, to_status, changed_by, actor_role, reason,
+		       latitude, longitude, ip_address, request_id, changed_at
+		FROM schedule_status_history
+		WHERE schedule_id = ?
+		ORDER BY changed_at ASC, id ASC`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.ScheduleStatusChange{}
+	for rows.Next() {
+		var change models.ScheduleStatusChange
+		var fromStatus, reason, ipAddress, requestID sql.NullString
+		var changedBy sql.NullInt64
+		var latitude, longitude sql.NullFloat64
+		var changedAt string
+
+		err := rows.Scan(
+			&change.ID, &change.ScheduleID, &fromStatus, &change.ToStatus, &changedBy, &change.ActorRole, &reason,
+			&latitude, &longitude, &ipAddress, &requestID, &changedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		change.FromStatus = fromStatus.String
+		change.ChangedBy = nullableInt(changedBy)
+		change.Reason = reason.String
+		change.Latitude = nullableFloat(latitude)
+		change.Longitude = nullableFloat(longitude)
+		change.IPAddress = ipAddress.String
+		change.RequestID = requestID.String
+		change.ChangedAt = utils.ParseTime(changedAt)
+		history = append(history, change)
+	}
+	return history, rows.Err()
+}
@@ -0,0 +1,487 @@
+// Package store defines the persistence interfaces the handlers depend on and their
+// SQL implementation, which runs on SQLite or PostgreSQL. Handlers receive the stores through their constructors so they
+// can be exercised against in-memory fakes.
+package store
+
+import (
+	"errors"
+	"time"
+
+	"visit-tracker-api/models"
+)
+
+// ScheduleStore persists schedules and their status history
+type ScheduleStore interface {
+	// ListSchedules returns the schedules matching the filter, earliest shift first
+	ListSchedules(filter ScheduleFilter) ([]models.Schedule, error)
+	// ListSchedulePage returns one page of the schedules matching the filter in the
+	// page's order, how many match in all, and the cursor of the next page, nil on the
+	// last page
+	ListSchedulePage(filter ScheduleFilter, page SchedulePage) ([]models.Schedule, int, *ScheduleCursor, error)
+	// CountSchedulesByDay counts the schedules matching the filter on each date in
+	// [from, to), both midnight UTC, by the date each shift starts on in its client's time
+	// zone. Every date is returned, in order.
+	CountSchedulesByDay(filter ScheduleFilter, from, to time.Time) ([]models.CalendarDay, error)
+	// GetSchedule returns a schedule, or sql.ErrNoRows when it does not exist
+	GetSchedule(id int) (models.Schedule, error)
+	// GetStats counts schedules for the dashboard, taking today in each client's time zone
+	GetStats(now time.Time) (models.StatsResponse, error)
+
+	// CreateSchedule creates an upcoming schedule with its visit record and tasks and
+	// returns its ID. It returns an *OverlapError when the caregiver is already booked
+	// and ErrTemplateShiftExists when the template already generated the shift.
+	CreateSchedule(schedule ScheduleInput, actor StatusActor) (int, error)
+	// UpdateSchedule reschedules or reassigns an upcoming schedule; nil tasks keep the
+	// existing ones. It returns ErrScheduleNotEditable once the schedule has moved on
+	// and an *OverlapError when the caregiver is already booked.
+	UpdateSchedule(id int, schedule ScheduleInput, actor StatusActor) error
+	// CancelSchedule cancels a schedule with a reason through the state machine
+	CancelSchedule(id int, reason string, actor StatusActor) error
+	// TransitionSchedule moves a schedule to a new status, returning the previous status
+	// or a *models.TransitionError when the state machine does not allow it
+	TransitionSchedule(id int, change StatusChange, actor StatusActor) (string, error)
+	// GetStatusHistory returns a schedule's status changes, oldest first
+	GetStatusHistory(id int) ([]models.ScheduleStatusChange, error)
+
+	// ListOverdueSchedules returns schedules in one of the statuses whose shift started
+	// at or before cutoff
+	ListOverdueSchedules(cutoff time.Time, statuses []string) ([]models.Schedule, error)
+	// TemplateScheduleExists reports whether a template already generated the shift
+	// starting at shiftStart, even if it has since been cancelled
+	TemplateScheduleExists(templateID int, shiftStart time.Time) (bool, error)
+	// CancelTemplateSchedules cancels a template's upcoming schedules starting within
+	// [from, to) with the reason and detaches them from the template, so their shifts
+	// can be generated again; a zero to leaves the range open
+	CancelTemplateSchedules(templateID int, from, to time.Time, reason string, actor StatusActor) error
+}
+
+// VisitStore persists the clock-in and clock-out records of schedules
+type VisitStore interface {
+	// GetVisit returns the visit record of a schedule, or sql.ErrNoRows when it has none
+	GetVisit(scheduleID int) (models.Visit, error)
+	// StartVisit records the clock-in and moves the schedule to in_progress
+	StartVisit(scheduleID int, checkpoint VisitCheckpoint, actor StatusActor) error
+	// EndVisit records the clock-out and moves the schedule to completed. Tasks still
+	// pending are marked not_completed when the checkpoint sets CloseTasks; otherwise it
+	// returns a *PendingTasksError listing them and saves nothing.
+	EndVisit(scheduleID int, checkpoint VisitCheckpoint, actor StatusActor) ([]models.Task, error)
+	// AdjustVisit corrects a visit's clock-in and clock-out times, recording the times it
+	// replaces. Setting the end of a visit in progress moves the schedule to completed,
+	// applying the task completion policy as EndVisit does.
+	AdjustVisit(scheduleID int, adjustment VisitAdjustmentInput, actor StatusActor) (models.VisitAdjustment, error)
+	// ListVisitAdjustments returns the adjustments made to a schedule's visit, oldest first
+	ListVisitAdjustments(scheduleID int) ([]models.VisitAdjustment, error)
+}
+
+// TaskStore persists the care tasks of schedules
+type TaskStore interface {
+	// ListTasks returns a schedule's tasks in the order they were added
+	ListTasks(scheduleID int) ([]models.Task, error)
+	// GetTask returns a task, or sql.ErrNoRows when it does not exist
+	GetTask(id int) (models.Task, error)
+	// UpdateTask sets a task's status and reason and returns the updated task
+	UpdateTask(id int, status, reason string, actor StatusActor) (models.Task, error)
+	// UpdateTasks applies every update to a schedule's tasks atomically and returns the
+	// schedule's tasks. It returns ErrVisitNotInProgress unless the visit is in progress.
+	UpdateTasks(scheduleID int, updates []TaskUpdate, actor StatusActor) ([]models.Task, error)
+}
+
+// NoteStore persists the narrative notes written about visits
+type NoteStore interface {
+	// ListVisitNotes returns the notes written about a schedule's visit, oldest first
+	ListVisitNotes(scheduleID int) ([]models.VisitNote, error)
+	// AddVisitNote appends a note to a schedule's visit. It returns ErrVisitNotStarted
+	// unless the visit is in progress or completed.
+	AddVisitNote(scheduleID int, category, body string, actor StatusActor) (models.VisitNote, error)
+}
+
+// IncidentStore persists the incidents filed against visits
+type IncidentStore interface {
+	// ListIncidents returns the incidents matching the filter, most severe first
+	ListIncidents(filter IncidentFilter) ([]models.Incident, error)
+	// GetIncident returns an incident, or sql.ErrNoRows when it does not exist
+	GetIncident(id int) (models.Incident, error)
+	// CreateIncident files an open incident against a schedule. It returns
+	// ErrIncidentBeforeVisit unless the visit is in progress or completed.
+	CreateIncident(scheduleID int, input IncidentInput, actor StatusActor) (models.Incident, error)
+	// UpdateIncident applies changes to an incident's classification and follow-up and
+	// returns it. It returns ErrInjuryDetailsRequired or ErrFollowUpRequired when the
+	// incident would be left without them.
+	UpdateIncident(id int, update IncidentUpdate, actor StatusActor) (models.Incident, error)
+}
+
+// ActivityStore persists the activities logged against schedules
+type ActivityStore interface {
+	// ListActivities returns a schedule's activities, oldest first
+	ListActivities(scheduleID int) ([]models.Activity, error)
+	// GetActivity returns an activity, or sql.ErrNoRows when it does not exist
+	GetActivity(id int) (models.Activity, error)
+	// CreateActivity logs an unresolved activity against a schedule
+	CreateActivity(scheduleID int, req models.CreateActivityRequest, actor StatusActor) (models.Activity, error)
+	// UpdateActivity records whether an activity was resolved and returns it
+	UpdateActivity(id int, req models.UpdateActivityRequest, actor StatusActor) (models.Activity, error)
+}
+
+// SyncStore records the offline events devices sync, so a retried batch is not applied twice
+type SyncStore interface {
+	// ClaimSyncEvent reserves the user's idempotency key for an event. When the key was
+	// already used it returns the earlier record and false instead.
+	ClaimSyncEvent(event SyncEventRecord) (SyncEventRecord, bool, error)
+	// CompleteSyncEvent stores the outcome of a claimed event
+	CompleteSyncEvent(id int, result models.SyncEventResult) error
+	// ReleaseSyncEvent forgets a claimed event that could not be processed, so it can be retried
+	ReleaseSyncEvent(id int) error
+}
+
+// EVVStore reads the visits reported in EVV exports
+type EVVStore interface {
+	// ListEVVVisits returns the visits whose service date, the date of clock-in in the
+	// client's time zone, is within [from, to), earliest first, and whose schedules are
+	// in progress or completed. from and to are dates at midnight UTC.
+	ListEVVVisits(from, to time.Time) ([]models.EVVVisit, error)
+}
+
+// ClientStore persists the clients that schedules are booked for
+type ClientStore interface {
+	// ListClients returns the clients by name with their emergency contacts, only those
+	// with the given active flag unless it is nil
+	ListClients(active *bool) ([]models.Client, error)
+	// GetClient returns a client with its emergency contacts, or sql.ErrNoRows when it
+	// does not exist
+	GetClient(id int) (models.Client, error)
+	// CreateClient registers a client with its emergency contacts and returns it
+	CreateClient(client ClientInput, actor StatusActor) (models.Client, error)
+	// UpdateClient saves a client and replaces its emergency contacts, returning the
+	// client, or returns sql.ErrNoRows when it does not exist
+	UpdateClient(id int, client ClientInput, actor StatusActor) (models.Client, error)
+}
+
+// CaregiverStore persists the caregivers that schedules are assigned to
+type CaregiverStore interface {
+	// ListCaregivers returns the caregivers by name, only those with the given active
+	// flag unless it is nil
+	ListCaregivers(active *bool) ([]models.Caregiver, error)
+	// GetCaregiver returns a caregiver, or sql.ErrNoRows when it does not exist
+	GetCaregiver(id int) (models.Caregiver, error)
+	// CaregiverEmailTaken reports whether a caregiver other than excludeID uses the email
+	CaregiverEmailTaken(email string, excludeID int) (bool, error)
+	// CreateCaregiver registers a caregiver and returns it
+	CreateCaregiver(caregiver CaregiverInput, actor StatusActor) (models.Caregiver, error)
+	// UpdateCaregiver saves a caregiver and returns it, or returns sql.ErrNoRows when it
+	// does not exist
+	UpdateCaregiver(id int, caregiver CaregiverInput, actor StatusActor) (models.Caregiver, error)
+}
+
+// TemplateStore persists the recurring templates that schedules are generated from
+type TemplateStore interface {
+	// ListScheduleTemplates returns every template with its tasks and skipped dates, or
+	// only the active ones, ordered by client name
+	ListScheduleTemplates(activeOnly bool) ([]models.ScheduleTemplate, error)
+	// GetScheduleTemplate returns a template with its tasks and skipped dates, or
+	// sql.ErrNoRows when it does not exist
+	GetScheduleTemplate(id int) (models.ScheduleTemplate, error)
+	// CreateScheduleTemplate saves a template with its default tasks and returns its ID
+	CreateScheduleTemplate(template TemplateInput, actor StatusActor) (int, error)
+	// UpdateScheduleTemplate saves a template, replacing its default tasks unless they
+	// are nil, or returns sql.ErrNoRows when it does not exist
+	UpdateScheduleTemplate(id int, template TemplateInput, actor StatusActor) error
+	// AddTemplateException skips a date, YYYY-MM-DD, of a template
+	AddTemplateException(templateID int, date, reason string, actor StatusActor) error
+	// DeleteTemplateException restores a skipped date of a template, or returns
+	// sql.ErrNoRows when the template has no such exception
+	DeleteTemplateException(templateID, id int, actor StatusActor) error
+}
+
+// CarePlanStore persists the care plans whose tasks are copied onto clients' shifts
+type CarePlanStore interface {
+	// ListCarePlanTasks returns a client's care-plan tasks in the order they were added,
+	// only those with the given active flag unless it is nil
+	ListCarePlanTasks(clientID int, active *bool) ([]models.CarePlanTask, error)
+	// GetCarePlanTask returns a task of a client's care plan, or sql.ErrNoRows when the
+	// client has no such task
+	GetCarePlanTask(clientID, id int) (models.CarePlanTask, error)
+	// CreateCarePlanTask adds a task to a client's care plan and returns it
+	CreateCarePlanTask(clientID int, task CarePlanTaskInput, actor StatusActor) (models.CarePlanTask, error)
+	// UpdateCarePlanTask saves a task of a client's care plan and returns it, or returns
+	// sql.ErrNoRows when the client has no such task
+	UpdateCarePlanTask(clientID, id int, task CarePlanTaskInput, actor StatusActor) (models.CarePlanTask, error)
+}
+
+// AuditStore reads the audit log that every write appends to
+type AuditStore interface {
+	// ListAuditEvents returns the audit events matching the filter, newest first
+	ListAuditEvents(filter AuditFilter) ([]models.AuditEvent, error)
+	// VerifyAuditChain recomputes the hash chain and reports the first event that breaks it
+	VerifyAuditChain() (models.AuditVerification, error)
+}
+
+// UserStore persists the accounts that sign in to the API
+type UserStore interface {
+	// GetUser returns a user, or sql.ErrNoRows when it does not exist
+	GetUser(id int) (models.User, error)
+	// GetUserByEmail returns the user signing in with the email, or sql.ErrNoRows when
+	// there is none
+	GetUserByEmail(email string) (models.User, error)
+	// ListUsers returns every user ordered by email
+	ListUsers() ([]models.User, error)
+	// CreateUser creates an active account with the password hashed and returns it
+	CreateUser(user UserInput, actor StatusActor) (models.User, error)
+	// EnsureAdminUser creates an admin account with the email and password unless a user
+	// with the email exists, and reports whether it did
+	EnsureAdminUser(email, password string) (bool, error)
+}
+
+// JobStore coordinates the background jobs every API instance runs
+type JobStore interface {
+	// RunExclusive runs fn unless another instance is already running the named job,
+	// and reports whether it ran
+	RunExclusive(job string, fn func() error) (bool, error)
+}
+
+// AuditFilter narrows the events returned by ListAuditEvents
+type AuditFilter struct {
+	Entity   string    // only writes to this table, e.g. tasks
+	EntityID *int      // only writes to this row of Entity
+	From     time.Time // only events at or after From, unless zero
+	To       time.Time // only events before To, unless zero
+	Limit    int
+}
+
+// SyncEventRecord is a synced event as recorded against its idempotency key
+type SyncEventRecord struct {
+	ID             int
+	UserID         int
+	IdempotencyKey string
+	DeviceID       string
+	EventType      string
+	ScheduleID     *int
+	OccurredAt     time.Time
+	Status         string                  // processing until the outcome is stored
+	Result         *models.SyncEventResult // nil while processing
+}
+
+// SyncProcessing is the status of a claimed event whose outcome is not stored yet
+const SyncProcessing = "processing"
+
+// ScheduleFilter narrows the schedules returned by ListSchedules
+type ScheduleFilter struct {
+	CaregiverID *int      // only shifts assigned to this caregiver
+	ClientID    *int      // only shifts for this client
+	Statuses    []string  // only shifts in one of these statuses
+	From        time.Time // only shifts starting at or after From, unless zero
+	To          time.Time // only shifts starting before To, unless zero
+	Day         time.Time // only shifts starting on the date this falls on in their client's time zone
+}
+
+// Columns a page of schedules can be sorted by
+const (
+	SortShiftStart = "shift_start"
+	SortShiftEnd   = "shift_end"
+	SortCreatedAt  = "created_at"
+	SortUpdatedAt  = "updated_at"
+	SortClientName = "client_name"
+	SortStatus     = "status"
+)
+
+// SchedulePage selects one page of a schedule listing
+type SchedulePage struct {
+	Sort  string          // one of the Sort constants
+	Desc  bool            // sort in descending order
+	After *ScheduleCursor // start after this position, or at the beginning when nil
+	Limit int
+}
+
+// ScheduleCursor is the position of the last schedule of a page: its value of the sort
+// column and, to break ties, its ID
+type ScheduleCursor struct {
+	Value string
+	ID    int
+}
+
+// ScheduleInput holds the fields of a schedule being created or edited
+type ScheduleInput struct {
+	ClientID    int
+	CaregiverID *int
+	TemplateID  *int
+	ShiftStart  time.Time
+	ShiftEnd    time.Time
+	ServiceCode string
+	Tasks       []string
+}
+
+// VisitCheckpoint is the time and place a caregiver clocked in or out
+type VisitCheckpoint struct {
+	Time           time.Time
+	Latitude       float64
+	Longitude      float64
+	Geofence       models.GeofenceResult
+	OverrideReason string
+	CloseTasks     bool // on clock-out, mark pending tasks not_completed instead of refusing
+	Offline        bool // recorded by a device while offline, at Time
+}
+
+// IncidentFilter narrows ListIncidents; zero fields match every incident
+type IncidentFilter struct {
+	ScheduleID *int
+	ClientID   *int
+	Statuses   []string
+	Severities []string
+}
+
+// ClientInput is the full, validated state of a client
+type ClientInput struct {
+	Name              string
+	Address           string
+	Latitude          float64
+	Longitude         float64
+	CarePlanNotes     string
+	MedicaidID        string
+	Timezone          string
+	Active            bool
+	EmergencyContacts []models.EmergencyContactRequest
+}
+
+// CaregiverInput is the full, validated state of a caregiver
+type CaregiverInput struct {
+	Name   string
+	Email  string
+	Phone  string
+	Active bool
+}
+
+// TemplateInput is the full, validated state of a schedule template
+type TemplateInput struct {
+	ClientID    int
+	CaregiverID *int
+	RRule       string
+	StartTime   string // HH:MM
+	EndTime     string // HH:MM
+	StartsOn    string // YYYY-MM-DD
+	EndsOn      *string
+	Active      bool
+	Tasks       []string // nil leaves an existing template's tasks unchanged
+}
+
+// UserInput is a validated account to create
+type UserInput struct {
+	Email       string
+	Password    string
+	Role        string
+	CaregiverID *int
+}
+
+// CarePlanTaskInput is the full, validated state of a care-plan task
+type CarePlanTaskInput struct {
+	Description string
+	Frequency   string
+	Required    bool
+	Active      bool
+}
+
+// IncidentInput is a validated incident to file
+type IncidentInput struct {
+	Category      string
+	Severity      string
+	Description   string
+	OccurredAt    time.Time
+	Injury        bool
+	InjuryDetails string
+}
+
+// IncidentUpdate holds the validated changes to an incident; nil fields are left unchanged
+type IncidentUpdate struct {
+	Category      *string
+	Severity      *string
+	Status        *string
+	Injury        *bool
+	InjuryDetails *string
+	FollowUpNotes *string
+}
+
+// TaskUpdate is a new status, with its reason, for one of a schedule's tasks
+type TaskUpdate struct {
+	ID     int
+	Status string
+	Reason string
+}
+
+// VisitAdjustmentInput holds the corrected times of a visit; nil times are left unchanged
+type VisitAdjustmentInput struct {
+	StartTime  *time.Time
+	EndTime    *time.Time
+	ReasonCode string
+	Note       string
+	CloseTasks bool // when the adjustment completes the visit, as for VisitCheckpoint
+}
+
+// ActorRoleSystem identifies status changes made by background jobs
+const ActorRoleSystem = "system"
+
+// StatusActor identifies who made a status change or other write, and from where
+type StatusActor struct {
+	UserID    *int
+	Role      string
+	IPAddress string
+	RequestID string
+}
+
+// SystemActor is the actor recorded for background jobs
+var SystemActor = StatusActor{Role: ActorRoleSystem}
+
+// StatusChange describes a requested schedule status transition
+type StatusChange struct {
+	To         string
+	Reason     string
+	Latitude   *float64
+	Longitude  *float64
+	RecordedAt time.Time // when a device recorded the change offline; zero when it happens now
+}
+
+// ErrScheduleNotEditable is returned when editing a schedule that is no longer upcoming
+var ErrScheduleNotEditable = errors.New("Only upcoming schedules can be edited")
+
+// ErrTemplateShiftExists is returned when a template's shift has already been generated,
+// possibly by another API instance
+var ErrTemplateShiftExists = errors.New("The template has already generated this shift")
+
+// AutoClosedTaskReason is recorded on the tasks EndVisit marks not_completed
+const AutoClosedTaskReason = "Not completed before the visit ended"
+
+// PendingTasksError is returned by EndVisit when tasks are still pending
+type PendingTasksError struct {
+	Tasks []models.Task
+}
+
+func (e *PendingTasksError) Error() string {
+	return "Every task must be completed or marked not completed before ending the visit"
+}
+
+// ErrVisitNotInProgress is returned when updating tasks outside the visit
+var ErrVisitNotInProgress = errors.New("Tasks can only be updated while the visit is in progress")
+
+// ErrVisitNotStarted is returned when adding a note to a visit that has not started
+var ErrVisitNotStarted = errors.New("Notes can only be added once the visit has started")
+
+// ErrIncidentBeforeVisit is returned when filing an incident for a visit that has not started
+var ErrIncidentBeforeVisit = errors.New("Incidents can only be filed once the visit has started")
+
+// ErrInjuryDetailsRequired is returned when an incident would record an injury without
+// describing it
+var ErrInjuryDetailsRequired = errors.New("injury_details is required when injury is true")
+
+// ErrFollowUpRequired is returned when an incident would be resolved without follow-up notes
+var ErrFollowUpRequired = errors.New("follow_up_notes is required to resolve an incident")
+
+// ErrVisitNotAdjustable is returned when adjusting a visit that has not started or whose
+// schedule was cancelled or missed
+var ErrVisitNotAdjustable = errors.New("Only visits in progress or completed can be adjusted")
+
+// OverlapError is returned when a caregiver already has a shift during the requested time
+type OverlapError struct {
+	CaregiverID int
+	ScheduleID  int // the conflicting shift
+}
+
+func (e *OverlapError) Error() string {
+	return "Caregiver already has a shift during this time"
+}
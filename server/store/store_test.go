@@ -0,0 +1,101 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"visit-tracker-api/config"
+	"visit-tracker-api/database"
+)
+
+// newTestStore returns a store backed by a fresh, fully migrated SQLite database
+func newTestStore(t *testing.T) *SQLStore {
+	t.Helper()
+
+	database.Open(config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "visits.db")})
+	db := database.DB
+	t.Cleanup(func() { db.Close() })
+	if _, err := database.MigrateUp(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return NewSQLStore(db)
+}
+
+// mustExec runs a fixture statement, failing the test on error
+func mustExec(t *testing.T, s *SQLStore, query string, args ...interface{}) {
+	t.Helper()
+	if _, err := s.db.Exec(query, args...); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
+
+// createClient adds a client at a fixed location and returns its ID
+func createClient(t *testing.T, s *SQLStore, name string) int {
+	t.Helper()
+	id, err := s.db.Insert("INSERT INTO clients (name, latitude, longitude) VALUES (?, 40.7282, -73.9942)", name)
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	return int(id)
+}
+
+// createCaregiver adds a caregiver and returns its ID
+func createCaregiver(t *testing.T, s *SQLStore, email string) int {
+	t.Helper()
+	id, err := s.db.Insert("INSERT INTO caregivers (name, email) VALUES (?, ?)", email, email)
+	if err != nil {
+		t.Fatalf("create caregiver: %v", err)
+	}
+	return int(id)
+}
+
+// createTemplate adds a daily template for a client and returns its ID
+func createTemplate(t *testing.T, s *SQLStore, clientID int) int {
+	t.Helper()
+	id, err := s.db.Insert(`
+		INSERT INTO schedule_templates (client_id, rrule, start_time, end_time, starts_on)
+		VALUES (?, 'FREQ=DAILY', '09:00', '11:00', '2026-01-01')`, clientID)
+	if err != nil {
+		t.Fatalf("create template: %v", err)
+	}
+	return int(id)
+}
+
+// createCarePlanTask adds an active, required task to a client's care plan
+func createCarePlanTask(t *testing.T, s *SQLStore, clientID int, description, frequency string) {
+	t.Helper()
+	mustExec(t, s, `
+		INSERT INTO care_plan_tasks (client_id, description, frequency, required, active, created_at, updated_at)
+		VALUES (?, ?, ?, TRUE, TRUE, ?, ?)`,
+		clientID, description, frequency, now(), now())
+}
+
+// createSchedule adds an upcoming schedule with the given tasks and returns its ID
+func createSchedule(t *testing.T, s *SQLStore, input ScheduleInput) int {
+	t.Helper()
+	id, err := s.CreateSchedule(input, SystemActor)
+	if err != nil {
+		t.Fatalf("create schedule: %v", err)
+	}
+	return id
+}
+
+// startVisit clocks in to a schedule at the client's home
+func startVisit(t *testing.T, s *SQLStore, scheduleID int, at time.Time) {
+	t.Helper()
+	checkpoint := VisitCheckpoint{Time: at, Latitude: 40.7282, Longitude: -73.9942}
+	if err := s.StartVisit(scheduleID, checkpoint, SystemActor); err != nil {
+		t.Fatalf("start visit: %v", err)
+	}
+}
+
+// scheduleStatus returns a schedule's current status
+func scheduleStatus(t *testing.T, s *SQLStore, scheduleID int) string {
+	t.Helper()
+	schedule, err := s.GetSchedule(scheduleID)
+	if err != nil {
+		t.Fatalf("get schedule: %v", err)
+	}
+	return schedule.Status
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+)
+
+// ClaimSyncEvent reserves the user's idempotency key for an event. When the key was
+// already used it returns the earlier record and false instead.
+func (s *SQLStore) ClaimSyncEvent(event SyncEventRecord) (SyncEventRecord, bool, error) {
+	result, err := s.db.Exec(`
+		INSERT INTO sync_events (user_id, idempotency_key, device_id, event_type, schedule_id, occurred_at, status, received_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, idempotency_key) DO NOTHING`,
+		event.UserID, event.IdempotencyKey, nullableString(event.DeviceID), event.EventType, event.ScheduleID,
+		formatTime(event.OccurredAt), SyncProcessing, now())
+	if err != nil {
+		return event, false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return event, false, err
+	}
+
+	record, err := s.getSyncEvent(event.UserID, event.IdempotencyKey)
+	return record, affected == 1, err
+}
+
+// getSyncEvent returns the event a user synced under an idempotency key
+func (s *SQLStore) getSyncEvent(userID int, key string) (SyncEventRecord, error) {
+	var record SyncEventRecord
+	var deviceID, result sql.NullString
+	var scheduleID sql.NullInt64
+	var occurredAt string
+
+	err := s.db.QueryRow(`
+		SELECT id, user_id, idempotency_key, device_id, event_type, schedule_id, occurred_at, status, result
+		FROM sync_events
+		WHERE user_id = ? AND idempotency_key = ?`, userID, key).Scan(
+		&record.ID, &record.UserID, &record.IdempotencyKey, &deviceID, &record.EventType, &scheduleID,
+		&occurredAt, &record.Status, &result,
+	)
+	if err != nil {
+		return record, err
+	}
+
+	record.DeviceID = deviceID.String
+	record.ScheduleID = nullableInt(scheduleID)
+	record.OccurredAt = utils.ParseTime(occurredAt)
+	if result.Valid {
+		var stored models.SyncEventResult
+		if err := json.Unmarshal([]byte(result.String), &stored); err != nil {
+			return record, err
+		}
+		record.Result = &stored
+	}
+	return record, nil
+}
+
+// CompleteSyncEvent stores the outcome of a claimed event
+func (s *SQLStore) CompleteSyncEvent(id int, result models.SyncEventResult) error {
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec("UPDATE sync_events SET status = ?, result = ? WHERE id = ?", result.Status, string(encoded), id)
+	return err
+}
+
+// ReleaseSyncEvent forgets a claimed event that could not be processed, so it can be retried
+func (s *SQLStore) ReleaseSyncEvent(id int) error {
+	_, err := s.db.Exec("DELETE FROM sync_events WHERE id = ?", id)
+	return err
+}
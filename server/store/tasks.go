@@ -0,0 +1,147 @@
+package store
+
+import (
+	"database/sql"
+
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+)
+
+const taskColumns = `id, schedule_id, description, status, reason, required, care_plan_task_id, created_at, updated_at`
+
+// scanTask scans a task row selected with taskColumns
+func scanTask(row rowScanner) (models.Task, error) {
+	var task models.Task
+	var reason sql.NullString
+	var carePlanTaskID sql.NullInt64
+	var createdAt, updatedAt string
+
+	err := row.Scan(
+		&task.ID, &task.ScheduleID, &task.Description, &task.Status,
+		&reason, &task.Required, &carePlanTaskID, &createdAt, &updatedAt,
+	)
+	if err != nil {
+		return task, err
+	}
+
+	task.Reason = reason.String
+	task.CarePlanTaskID = nullableInt(carePlanTaskID)
+	task.CreatedAt = utils.ParseTime(createdAt)
+	task.UpdatedAt = utils.ParseTime(updatedAt)
+	return task, nil
+}
+
+// ListTasks returns a schedule's tasks in the order they were added
+func (s *SQLStore) ListTasks(scheduleID int) ([]models.Task, error) {
+	return listTasks(s.db, scheduleID)
+}
+
+// listTasks returns a schedule's tasks in the order they were added
+func listTasks(db queryer, scheduleID int) ([]models.Task, error) {
+	rows, err := db.Query(`
+		SELECT `+taskColumns+`
+		FROM tasks
+		WHERE schedule_id = ?
+		ORDER BY id ASC`, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// GetTask returns a task, or sql.ErrNoRows when it does not exist
+func (s *SQLStore) GetTask(id int) (models.Task, error) {
+	return scanTask(s.db.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = ?", id))
+}
+
+// UpdateTask sets a task's status and reason and returns the updated task
+func (s *SQLStore) UpdateTask(id int, status, reason string, actor StatusActor) (models.Task, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.Task{}, err
+	}
+	defer tx.Rollback()
+
+	before, err := Snapshot(tx, "tasks", id)
+	if err != nil {
+		return models.Task{}, err
+	}
+	_, err = tx.Exec(`
+		UPDATE tasks
+		SET status = ?, reason = ?, updated_at = ?
+		WHERE id = ?`,
+		status, nullableString(reason), now(), id)
+	if err != nil {
+		return models.Task{}, err
+	}
+	if err := Audit(tx, actor, "tasks", id, before); err != nil {
+		return models.Task{}, err
+	}
+
+	task, err := scanTask(tx.QueryRow("SELECT "+taskColumns+" FROM tasks WHERE id = ?", id))
+	if err != nil {
+		return task, err
+	}
+	return task, tx.Commit()
+}
+
+// UpdateTasks applies every update to a schedule's tasks in one transaction, so either
+// all of them are saved or none is, and returns the schedule's tasks afterwards. It
+// returns ErrVisitNotInProgress unless the visit is in progress, and sql.ErrNoRows when
+// a task does not belong to the schedule.
+func (s *SQLStore) UpdateTasks(scheduleID int, updates []TaskUpdate, actor StatusActor) ([]models.Task, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var status string
+	if err := tx.QueryRow("SELECT status FROM schedules WHERE id = ?", scheduleID).Scan(&status); err != nil {
+		return nil, err
+	}
+	if status != models.StatusInProgress {
+		return nil, ErrVisitNotInProgress
+	}
+
+	timestamp := now()
+	for _, update := range updates {
+		before, err := Snapshot(tx, "tasks", update.ID)
+		if err != nil {
+			return nil, err
+		}
+		result, err := tx.Exec(`
+			UPDATE tasks
+			SET status = ?, reason = ?, updated_at = ?
+			WHERE id = ? AND schedule_id = ?`,
+			update.Status, nullableString(update.Reason), timestamp, update.ID, scheduleID)
+		if err != nil {
+			return nil, err
+		}
+		if updated, err := result.RowsAffected(); err != nil || updated == 0 {
+			if err == nil {
+				err = sql.ErrNoRows
+			}
+			return nil, err
+		}
+		if err := Audit(tx, actor, "tasks", update.ID, before); err != nil {
+			return nil, err
+		}
+	}
+
+	tasks, err := listTasks(tx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	return tasks, tx.Commit()
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTaskSchedule adds a schedule with two tasks and returns its ID and task IDs
+func newTaskSchedule(t *testing.T, s *SQLStore, shiftStart time.Time) (int, []int) {
+	t.Helper()
+	scheduleID := createSchedule(t, s, ScheduleInput{
+		ClientID:   createClient(t, s, "Client"),
+		ShiftStart: shiftStart,
+		ShiftEnd:   shiftStart.Add(2 * time.Hour),
+		Tasks:      []string{"Medication", "Light housekeeping"},
+	})
+	tasks, err := s.ListTasks(scheduleID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]int, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	return scheduleID, ids
+}
+
+func TestUpdateTasks(t *testing.T) {
+	s := newTestStore(t)
+	shiftStart := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	scheduleID, taskIDs := newTaskSchedule(t, s, shiftStart)
+	_, otherTaskIDs := newTaskSchedule(t, s, shiftStart)
+
+	updates := []TaskUpdate{
+		{ID: taskIDs[0], Status: "completed"},
+		{ID: taskIDs[1], Status: "not_completed", Reason: "Client declined"},
+	}
+	if _, err := s.UpdateTasks(scheduleID, updates, SystemActor); !errors.Is(err, ErrVisitNotInProgress) {
+		t.Fatalf("UpdateTasks before clock-in: error = %v, want ErrVisitNotInProgress", err)
+	}
+
+	startVisit(t, s, scheduleID, shiftStart)
+
+	foreign := []TaskUpdate{
+		{ID: taskIDs[0], Status: "completed"},
+		{ID: otherTaskIDs[0], Status: "completed"},
+	}
+	if _, err := s.UpdateTasks(scheduleID, foreign, SystemActor); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("UpdateTasks with another schedule's task: error = %v, want sql.ErrNoRows", err)
+	}
+	for _, id := range []int{taskIDs[0], otherTaskIDs[0]} {
+		task, err := s.GetTask(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if task.Status != "pending" {
+			t.Errorf("task %d status = %s after a failed bulk update, want pending", id, task.Status)
+		}
+	}
+
+	tasks, err := s.UpdateTasks(scheduleID, updates, SystemActor)
+	if err != nil {
+		t.Fatalf("UpdateTasks: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].Status != "completed" || tasks[1].Status != "not_completed" || tasks[1].Reason != "Client declined" {
+		t.Errorf("tasks = %+v, want Medication completed and Light housekeeping declined", tasks)
+	}
+}
+
+func TestTaskReasonStoredAsNull(t *testing.T) {
+	s := newTestStore(t)
+	shiftStart := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	scheduleID, taskIDs := newTaskSchedule(t, s, shiftStart)
+	startVisit(t, s, scheduleID, shiftStart)
+
+	if _, err := s.UpdateTask(taskIDs[0], "completed", "", SystemActor); err != nil {
+		t.Fatalf("UpdateTask: %v", err)
+	}
+	if _, err := s.UpdateTasks(scheduleID, []TaskUpdate{{ID: taskIDs[1], Status: "completed"}}, SystemActor); err != nil {
+		t.Fatalf("UpdateTasks: %v", err)
+	}
+
+	var withReason int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE schedule_id = ? AND reason IS NOT NULL", scheduleID).Scan(&withReason); err != nil {
+		t.Fatal(err)
+	}
+	if withReason != 0 {
+		t.Errorf("%d tasks completed without a reason store one, want NULL for both", withReason)
+	}
+
+	task, err := s.GetTask(taskIDs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Status != "completed" || task.Reason != "" {
+		t.Errorf("task = %+v, want completed without a reason", task)
+	}
+}
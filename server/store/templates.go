@@ -0,0 +1,275 @@
+package store
+
+import (
+	"database/sql"
+
+	"visit-tracker-api/database"
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+)
+
+const templateQuery = `
+	SELECT t.id, t.client_id, c.name, c.timezone, t.caregiver_id, t.rrule, t.start_time, t.end_time,
+	       t.starts_on, t.ends_on, t.active, t.created_at, t.updated_at
+	FROM schedule_templates t
+	JOIN clients c ON c.id = t.client_id`
+
+// formatDate normalises a DATE column to YYYY-MM-DD
+func formatDate(value string) string {
+	return utils.ParseTime(value).Format("2006-01-02")
+}
+
+// scanScheduleTemplate scans a template row selected with templateQuery
+func scanScheduleTemplate(row rowScanner) (models.ScheduleTemplate, error) {
+	var template models.ScheduleTemplate
+	var caregiverID sql.NullInt64
+	var startsOn, createdAt, updatedAt string
+	var timezone, endsOn sql.NullString
+
+	err := row.Scan(
+		&template.ID, &template.ClientID, &template.ClientName, &timezone, &caregiverID, &template.RRule,
+		&template.StartTime, &template.EndTime, &startsOn, &endsOn, &template.Active, &createdAt, &updatedAt,
+	)
+	if err != nil {
+		return template, err
+	}
+
+	template.CaregiverID = nullableInt(caregiverID)
+	template.Timezone = utils.Location(timezone.String).String()
+	template.StartsOn = formatDate(startsOn)
+	if endsOn.Valid {
+		date := formatDate(endsOn.String)
+		template.EndsOn = &date
+	}
+	template.CreatedAt = utils.ParseTime(createdAt)
+	template.UpdatedAt = utils.ParseTime(updatedAt)
+	template.Tasks = []string{}
+	template.Exceptions = []models.ScheduleTemplateException{}
+	return template, nil
+}
+
+// loadTemplateDetails loads a template's default tasks and skipped dates
+func (s *SQLStore) loadTemplateDetails(template *models.ScheduleTemplate) error {
+	rows, err := s.db.Query(`
+		SELECT description FROM schedule_template_tasks
+		WHERE template_id = ?
+		ORDER BY position ASC, id ASC`, template.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var description string
+		if err := rows.Scan(&description); err != nil {
+			return err
+		}
+		template.Tasks = append(template.Tasks, description)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	exceptionRows, err := s.db.Query(`
+		SELECT id, template_id, exception_date, reason, created_at
+		FROM schedule_template_exceptions
+		WHERE template_id = ?
+		ORDER BY exception_date ASC`, template.ID)
+	if err != nil {
+		return err
+	}
+	defer exceptionRows.Close()
+
+	for exceptionRows.Next() {
+		var exception models.ScheduleTemplateException
+		var date, createdAt string
+		var reason sql.NullString
+
+		if err := exceptionRows.Scan(&exception.ID, &exception.TemplateID, &date, &reason, &createdAt); err != nil {
+			return err
+		}
+		exception.Date = formatDate(date)
+		exception.Reason = reason.String
+		exception.CreatedAt = utils.ParseTime(createdAt)
+		template.Exceptions = append(template.Exceptions, exception)
+	}
+	return exceptionRows.Err()
+}
+
+// ListScheduleTemplates returns every template with its tasks and skipped dates, or only
+// the active ones, ordered by client name
+func (s *SQLStore) ListScheduleTemplates(activeOnly bool) ([]models.ScheduleTemplate, error) {
+	query := templateQuery
+	if activeOnly {
+		query += " WHERE t.active = TRUE"
+	}
+	query += " ORDER BY c.name ASC, t.id ASC"
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []models.ScheduleTemplate{}
+	for rows.Next() {
+		template, err := scanScheduleTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range templates {
+		if err := s.loadTemplateDetails(&templates[i]); err != nil {
+			return nil, err
+		}
+	}
+	return templates, nil
+}
+
+// GetScheduleTemplate returns a template with its tasks and skipped dates, or
+// sql.ErrNoRows when it does not exist
+func (s *SQLStore) GetScheduleTemplate(id int) (models.ScheduleTemplate, error) {
+	template, err := scanScheduleTemplate(s.db.QueryRow(templateQuery+" WHERE t.id = ?", id))
+	if err != nil {
+		return template, err
+	}
+	return template, s.loadTemplateDetails(&template)
+}
+
+// CreateScheduleTemplate saves a template with its default tasks and returns its ID
+func (s *SQLStore) CreateScheduleTemplate(template TemplateInput, actor StatusActor) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	created := now()
+	id, err := tx.Insert(`
+		INSERT INTO schedule_templates (client_id, caregiver_id, rrule, start_time, end_time, starts_on, ends_on, active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		template.ClientID, template.CaregiverID, template.RRule, template.StartTime, template.EndTime,
+		template.StartsOn, template.EndsOn, template.Active, created, created)
+	if err != nil {
+		return 0, err
+	}
+	if err := Audit(tx, actor, "schedule_templates", int(id), nil); err != nil {
+		return 0, err
+	}
+	if err := replaceTemplateTasks(tx, int(id), template.Tasks, actor); err != nil {
+		return 0, err
+	}
+	return int(id), tx.Commit()
+}
+
+// UpdateScheduleTemplate saves a template, replacing its default tasks unless they are
+// nil, or returns sql.ErrNoRows when it does not exist. Schedules it already generated
+// are not changed.
+func (s *SQLStore) UpdateScheduleTemplate(id int, template TemplateInput, actor StatusActor) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow("SELECT id FROM schedule_templates WHERE id = ?", id).Scan(&id); err != nil {
+		return err
+	}
+	before, err := Snapshot(tx, "schedule_templates", id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		UPDATE schedule_templates
+		SET client_id = ?, caregiver_id = ?, rrule = ?, start_time = ?, end_time = ?, starts_on = ?, ends_on = ?, active = ?, updated_at = ?
+		WHERE id = ?`,
+		template.ClientID, template.CaregiverID, template.RRule, template.StartTime, template.EndTime,
+		template.StartsOn, template.EndsOn, template.Active, now(), id)
+	if err != nil {
+		return err
+	}
+	if err := Audit(tx, actor, "schedule_templates", id, before); err != nil {
+		return err
+	}
+
+	if template.Tasks != nil {
+		if err := replaceTemplateTasks(tx, id, template.Tasks, actor); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// AddTemplateException skips a date, YYYY-MM-DD, of a template
+func (s *SQLStore) AddTemplateException(templateID int, date, reason string, actor StatusActor) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	id, err := tx.Insert(`
+		INSERT INTO schedule_template_exceptions (template_id, exception_date, reason, created_at)
+		VALUES (?, ?, ?, ?)`,
+		templateID, date, nullableString(reason), now())
+	if err != nil {
+		return err
+	}
+	if err := Audit(tx, actor, "schedule_template_exceptions", int(id), nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteTemplateException restores a skipped date of a template, or returns
+// sql.ErrNoRows when the template has no such exception
+func (s *SQLStore) DeleteTemplateException(templateID, id int, actor StatusActor) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	where := "id = ? AND template_id = ?"
+	if err := AuditDeletes(tx, actor, "schedule_template_exceptions", where, id, templateID); err != nil {
+		return err
+	}
+	result, err := tx.Exec("DELETE FROM schedule_template_exceptions WHERE "+where, id, templateID)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return sql.ErrNoRows
+	}
+	return tx.Commit()
+}
+
+// replaceTemplateTasks swaps a template's default task list
+func replaceTemplateTasks(tx *database.Tx, templateID int, tasks []string, actor StatusActor) error {
+	if err := AuditDeletes(tx, actor, "schedule_template_tasks", "template_id = ?", templateID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM schedule_template_tasks WHERE template_id = ?", templateID); err != nil {
+		return err
+	}
+
+	for position, description := range tasks {
+		id, err := tx.Insert(`
+			INSERT INTO schedule_template_tasks (template_id, description, position)
+			VALUES (?, ?, ?)`,
+			templateID, description, position+1)
+		if err != nil {
+			return err
+		}
+		if err := Audit(tx, actor, "schedule_template_tasks", int(id), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
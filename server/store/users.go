@@ -0,0 +1,134 @@
+package store
+
+import (
+	"database/sql"
+
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const userQuery = `
+	SELECT id, email, password_hash, role, caregiver_id, active, created_at, updated_at
+	FROM users`
+
+// scanUser scans a user row selected with userQuery
+func scanUser(row rowScanner) (models.User, error) {
+	var user models.User
+	var caregiverID sql.NullInt64
+	var createdAt, updatedAt string
+
+	err := row.Scan(
+		&user.ID, &user.Email, &user.PasswordHash, &user.Role, &caregiverID,
+		&user.Active, &createdAt, &updatedAt,
+	)
+	if err != nil {
+		return user, err
+	}
+
+	user.CaregiverID = nullableInt(caregiverID)
+	user.CreatedAt = utils.ParseTime(createdAt)
+	user.UpdatedAt = utils.ParseTime(updatedAt)
+	return user, nil
+}
+
+// GetUser returns a user, or sql.ErrNoRows when it does not exist
+func (s *SQLStore) GetUser(id int) (models.User, error) {
+	return scanUser(s.db.QueryRow(userQuery+" WHERE id = ?", id))
+}
+
+// GetUserByEmail returns the user signing in with the email, or sql.ErrNoRows when there
+// is none
+func (s *SQLStore) GetUserByEmail(email string) (models.User, error) {
+	return scanUser(s.db.QueryRow(userQuery+" WHERE email = ?", email))
+}
+
+// ListUsers returns every user ordered by email
+func (s *SQLStore) ListUsers() ([]models.User, error) {
+	rows, err := s.db.Query(userQuery + " ORDER BY email ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// CreateUser creates an active account with the password hashed and returns it
+func (s *SQLStore) CreateUser(user UserInput, actor StatusActor) (models.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return models.User{}, err
+	}
+	defer tx.Rollback()
+
+	timestamp := now()
+	id, err := tx.Insert(`
+		INSERT INTO users (email, password_hash, role, caregiver_id, active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, TRUE, ?, ?)`,
+		user.Email, string(hash), user.Role, user.CaregiverID, timestamp, timestamp)
+	if err != nil {
+		return models.User{}, err
+	}
+	if err := Audit(tx, actor, "users", int(id), nil); err != nil {
+		return models.User{}, err
+	}
+
+	created, err := scanUser(tx.QueryRow(userQuery+" WHERE id = ?", id))
+	if err != nil {
+		return models.User{}, err
+	}
+	return created, tx.Commit()
+}
+
+// EnsureAdminUser creates an active admin account with the email and password unless a
+// user with the email exists, recording it in the audit log as created by the system.
+// It reports whether the account was created.
+func (s *SQLStore) EnsureAdminUser(email, password string) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM users WHERE email = ?", email).Scan(&count); err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return false, err
+	}
+
+	timestamp := now()
+	id, err := tx.Insert(`
+		INSERT INTO users (email, password_hash, role, active, created_at, updated_at)
+		VALUES (?, ?, ?, TRUE, ?, ?)`,
+		email, string(hash), models.RoleAdmin, timestamp, timestamp)
+	if err != nil {
+		return false, err
+	}
+	if err := Audit(tx, SystemActor, "users", int(id), nil); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"strings"
+	"testing"
+
+	"visit-tracker-api/models"
+)
+
+func TestEnsureAdminUserIsAudited(t *testing.T) {
+	s := newTestStore(t)
+
+	tests := []struct {
+		name        string
+		email       string
+		wantCreated bool
+		wantEvents  int
+	}{
+		{"creates the admin", "admin@agency.example", true, 1},
+		{"keeps an existing account", "admin@agency.example", false, 1},
+		{"creates another admin", "second@agency.example", true, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created, err := s.EnsureAdminUser(tt.email, "password123")
+			if err != nil {
+				t.Fatalf("EnsureAdminUser() error = %v", err)
+			}
+			if created != tt.wantCreated {
+				t.Errorf("created = %v, want %v", created, tt.wantCreated)
+			}
+
+			events, err := s.ListAuditEvents(AuditFilter{Entity: "users", Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != tt.wantEvents {
+				t.Fatalf("%d user audit events, want %d", len(events), tt.wantEvents)
+			}
+			latest := events[0]
+			if latest.Action != models.AuditCreate || latest.ActorRole != ActorRoleSystem {
+				t.Errorf("event = %s by %s, want create by system", latest.Action, latest.ActorRole)
+			}
+			if strings.Contains(string(latest.After), "$2a$") || !strings.Contains(string(latest.After), "[redacted]") {
+				t.Errorf("after = %s, want the password hash redacted", latest.After)
+			}
+		})
+	}
+}
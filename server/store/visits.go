@@ -0,0 +1,191 @@
+package store
+
+import (
+	"database/sql"
+
+	"visit-tracker-api/database"
+	"visit-tracker-api/models"
+	"visit-tracker-api/utils"
+)
+
+// GetVisit returns the visit record of a schedule, or sql.ErrNoRows when it has none
+func (s *SQLStore) GetVisit(scheduleID int) (models.Visit, error) {
+	visit := models.Visit{ScheduleID: scheduleID}
+	var startTime, endTime sql.NullString
+	var startLat, startLng, endLat, endLng sql.NullFloat64
+	var startDistance, endDistance sql.NullFloat64
+	var startGeofence, startOverride, endGeofence, endOverride sql.NullString
+	var createdAt, updatedAt string
+
+	err := s.db.QueryRow(`
+		SELECT id, start_time, end_time, start_lat, start_lng, end_lat, end_lng,
+			start_distance_meters, start_geofence_status, start_override_reason,
+			end_distance_meters, end_geofence_status, end_override_reason,
+			created_at, updated_at
+		FROM visits
+		WHERE schedule_id = ?`, scheduleID).Scan(
+		&visit.ID, &startTime, &endTime, &startLat, &startLng, &endLat, &endLng,
+		&startDistance, &startGeofence, &startOverride,
+		&endDistance, &endGeofence, &endOverride,
+		&createdAt, &updatedAt,
+	)
+	if err != nil {
+		return visit, err
+	}
+
+	visit.StartTime = nullableTime(startTime)
+	visit.EndTime = nullableTime(endTime)
+	visit.StartLat = nullableFloat(startLat)
+	visit.StartLng = nullableFloat(startLng)
+	visit.EndLat = nullableFloat(endLat)
+	visit.EndLng = nullableFloat(endLng)
+	visit.StartDistanceMeters = nullableFloat(startDistance)
+	visit.EndDistanceMeters = nullableFloat(endDistance)
+	visit.StartGeofenceStatus = startGeofence.String
+	visit.StartOverrideReason = startOverride.String
+	visit.EndGeofenceStatus = endGeofence.String
+	visit.EndOverrideReason = endOverride.String
+	visit.CreatedAt = utils.ParseTime(createdAt)
+	visit.UpdatedAt = utils.ParseTime(updatedAt)
+	return visit, nil
+}
+
+// StartVisit records the clock-in with its geofence result and moves the schedule to
+// in_progress, recording who clocked in and where
+func (s *SQLStore) StartVisit(scheduleID int, checkpoint VisitCheckpoint, actor StatusActor) error {
+	return s.recordCheckpoint(scheduleID, `
+		UPDATE visits
+		SET start_time = ?, start_lat = ?, start_lng = ?,
+			start_distance_meters = ?, start_geofence_status = ?, start_override_reason = ?,
+			updated_at = ?
+		WHERE schedule_id = ?`, checkpoint, models.StatusInProgress, actor, nil)
+}
+
+// EndVisit records the clock-out with its geofence result and moves the schedule to
+// completed, recording who clocked out and where. When the checkpoint sets CloseTasks,
+// tasks still pending are marked not_completed with AutoClosedTaskReason; otherwise any
+// pending task means nothing is saved and a *PendingTasksError is returned instead.
+// It returns the tasks it closed.
+func (s *SQLStore) EndVisit(scheduleID int, checkpoint VisitCheckpoint, actor StatusActor) ([]models.Task, error) {
+	var closed []models.Task
+	err := s.recordCheckpoint(scheduleID, `
+		UPDATE visits
+		SET end_time = ?, end_lat = ?, end_lng = ?,
+			end_distance_meters = ?, end_geofence_status = ?, end_override_reason = ?,
+			updated_at = ?
+		WHERE schedule_id = ?`, checkpoint, models.StatusCompleted, actor,
+		func(tx *database.Tx) error {
+			var err error
+			closed, err = closePendingTasks(tx, scheduleID, checkpoint.CloseTasks, actor)
+			return err
+		})
+	if err != nil {
+		return nil, err
+	}
+	return closed, nil
+}
+
+// closePendingTasks marks a schedule's pending tasks not_completed at clock-out. Unless
+// closeTasks is set, pending tasks are left alone and reported in a *PendingTasksError.
+func closePendingTasks(tx *database.Tx, scheduleID int, closeTasks bool, actor StatusActor) ([]models.Task, error) {
+	tasks, err := listTasks(tx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []models.Task
+	for _, task := range tasks {
+		if task.Status == "pending" {
+			pending = append(pending, task)
+		}
+	}
+	if len(pending) > 0 && !closeTasks {
+		return nil, &PendingTasksError{Tasks: pending}
+	}
+
+	closed := make([]models.Task, 0, len(pending))
+	timestamp := now()
+	for _, task := range pending {
+		before, err := Snapshot(tx, "tasks", task.ID)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`
+			UPDATE tasks
+			SET status = 'not_completed', reason = ?, updated_at = ?
+			WHERE id = ?`, AutoClosedTaskReason, timestamp, task.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := Audit(tx, actor, "tasks", task.ID, before); err != nil {
+			return nil, err
+		}
+
+		task.Status = "not_completed"
+		task.Reason = AutoClosedTaskReason
+		task.UpdatedAt = utils.ParseTime(timestamp)
+		closed = append(closed, task)
+	}
+	return closed, nil
+}
+
+// recordCheckpoint updates the visit record and transitions the schedule in one
+// transaction. The update takes the checkpoint's time, place and geofence result, the
+// update time and the schedule ID. prepare, when given, runs first inside the same
+// transaction.
+func (s *SQLStore) recordCheckpoint(scheduleID int, update string, checkpoint VisitCheckpoint, status string, actor StatusActor, prepare func(tx *database.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var visitID int
+	if err := tx.QueryRow("SELECT id FROM visits WHERE schedule_id = ?", scheduleID).Scan(&visitID); err != nil {
+		return err
+	}
+	visitBefore, err := Snapshot(tx, "visits", visitID)
+	if err != nil {
+		return err
+	}
+	scheduleBefore, err := Snapshot(tx, "schedules", scheduleID)
+	if err != nil {
+		return err
+	}
+
+	if prepare != nil {
+		if err := prepare(tx); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(update,
+		formatTime(checkpoint.Time), checkpoint.Latitude, checkpoint.Longitude,
+		checkpoint.Geofence.DistanceMeters, checkpoint.Geofence.Status, nullableString(checkpoint.OverrideReason),
+		now(), scheduleID)
+	if err != nil {
+		return err
+	}
+
+	change := StatusChange{
+		To:        status,
+		Reason:    checkpoint.OverrideReason,
+		Latitude:  &checkpoint.Latitude,
+		Longitude: &checkpoint.Longitude,
+	}
+	if checkpoint.Offline {
+		change.RecordedAt = checkpoint.Time
+	}
+	_, err = transitionSchedule(tx, scheduleID, change, actor)
+	if err != nil {
+		return err
+	}
+
+	if err := Audit(tx, actor, "visits", visitID, visitBefore); err != nil {
+		return err
+	}
+	if err := Audit(tx, actor, "schedules", scheduleID, scheduleBefore); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,145 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"visit-tracker-api/models"
+)
+
+func TestEndVisitTaskCompletionPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		optional   []string // descriptions of tasks added as optional
+		completed  []string // descriptions of tasks completed before clock-out
+		closeTasks bool
+		wantErr    bool
+		wantClosed []string
+	}{
+		{
+			name:    "reject with a required task pending",
+			wantErr: true,
+		},
+		{
+			name:      "reject with only an optional task pending",
+			optional:  []string{"Light housekeeping"},
+			completed: []string{"Medication"},
+			wantErr:   true,
+		},
+		{
+			name:      "reject with every task resolved",
+			completed: []string{"Medication", "Light housekeeping"},
+		},
+		{
+			name:       "auto_close marks pending tasks not_completed",
+			optional:   []string{"Light housekeeping"},
+			completed:  []string{"Medication"},
+			closeTasks: true,
+			wantClosed: []string{"Light housekeeping"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t)
+			shiftStart := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+			scheduleID := createSchedule(t, s, ScheduleInput{
+				ClientID:   createClient(t, s, "Client"),
+				ShiftStart: shiftStart,
+				ShiftEnd:   shiftStart.Add(2 * time.Hour),
+				Tasks:      []string{"Medication", "Light housekeeping"},
+			})
+			for _, description := range tt.optional {
+				mustExec(t, s, "UPDATE tasks SET required = ? WHERE schedule_id = ? AND description = ?", false, scheduleID, description)
+			}
+			startVisit(t, s, scheduleID, shiftStart)
+			for _, description := range tt.completed {
+				mustExec(t, s, "UPDATE tasks SET status = 'completed' WHERE schedule_id = ? AND description = ?", scheduleID, description)
+			}
+
+			checkpoint := VisitCheckpoint{Time: time.Now(), Latitude: 40.7282, Longitude: -73.9942, CloseTasks: tt.closeTasks}
+			closed, err := s.EndVisit(scheduleID, checkpoint, SystemActor)
+
+			var pending *PendingTasksError
+			if tt.wantErr {
+				if !errors.As(err, &pending) {
+					t.Fatalf("EndVisit error = %v, want *PendingTasksError", err)
+				}
+				if len(pending.Tasks) == 0 {
+					t.Error("PendingTasksError lists no tasks")
+				}
+				if status := scheduleStatus(t, s, scheduleID); status != models.StatusInProgress {
+					t.Errorf("status = %s after a refused clock-out, want %s", status, models.StatusInProgress)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EndVisit: %v", err)
+			}
+			if status := scheduleStatus(t, s, scheduleID); status != models.StatusCompleted {
+				t.Errorf("status = %s, want %s", status, models.StatusCompleted)
+			}
+			if len(closed) != len(tt.wantClosed) {
+				t.Fatalf("closed %d tasks, want %d", len(closed), len(tt.wantClosed))
+			}
+			for i, task := range closed {
+				if task.Description != tt.wantClosed[i] || task.Status != "not_completed" || task.Reason != AutoClosedTaskReason {
+					t.Errorf("closed[%d] = %q %s %q, want %q not_completed", i, task.Description, task.Status, task.Reason, tt.wantClosed[i])
+				}
+			}
+		})
+	}
+}
+
+func TestStartVisitReconcilesOfflineClockIn(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string        // the schedule's status when the clock-in syncs
+		clockIn    time.Duration // when the device clocked in, relative to the status change
+		offline    bool
+		wantStatus string
+	}{
+		{"offline clock-in before the schedule was marked missed", models.StatusMissed, -10 * time.Minute, true, models.StatusInProgress},
+		{"offline clock-in after the schedule was marked missed", models.StatusMissed, time.Minute, true, models.StatusMissed},
+		{"online clock-in on a missed schedule", models.StatusMissed, -10 * time.Minute, false, models.StatusMissed},
+		{"offline clock-in before the schedule was cancelled", models.StatusCancelled, -10 * time.Minute, true, models.StatusCancelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t)
+			clientID := createClient(t, s, "Client")
+			start := time.Now().Add(-time.Hour)
+			scheduleID := createSchedule(t, s, ScheduleInput{ClientID: clientID, ShiftStart: start, ShiftEnd: start.Add(2 * time.Hour)})
+
+			changedAt := time.Now()
+			if _, err := s.TransitionSchedule(scheduleID, StatusChange{To: tt.status, Reason: "Not started"}, SystemActor); err != nil {
+				t.Fatal(err)
+			}
+
+			checkpoint := VisitCheckpoint{Time: changedAt.Add(tt.clockIn), Latitude: 40.7282, Longitude: -73.9942, Offline: tt.offline}
+			err := s.StartVisit(scheduleID, checkpoint, SystemActor)
+
+			var transitionErr *models.TransitionError
+			if tt.wantStatus == models.StatusInProgress && err != nil {
+				t.Fatalf("StartVisit() error = %v", err)
+			}
+			if tt.wantStatus != models.StatusInProgress && !errors.As(err, &transitionErr) {
+				t.Fatalf("StartVisit() error = %v, want a *models.TransitionError", err)
+			}
+			if got := scheduleStatus(t, s, scheduleID); got != tt.wantStatus {
+				t.Errorf("status = %q, want %q", got, tt.wantStatus)
+			}
+
+			history, err := s.GetStatusHistory(scheduleID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			last := history[len(history)-1]
+			if tt.wantStatus == models.StatusInProgress && (last.FromStatus != models.StatusMissed || last.ToStatus != models.StatusInProgress) {
+				t.Errorf("last change = %s -> %s, want missed -> in_progress", last.FromStatus, last.ToStatus)
+			}
+		})
+	}
+}
@@ -129,6 +129,27 @@ func HandleDatabaseError(c *gin.Context, err error, operation string) {
 	c.Error(apiErr)
 }
 
+// HandleConflictError handles requests that clash with existing data
+func HandleConflictError(c *gin.Context, message string, details interface{}) {
+	requestID := c.GetString("request_id")
+
+	apiErr := &middleware.APIError{
+		Code:       "CONFLICT",
+		Message:    message,
+		Details:    details,
+		StatusCode: http.StatusConflict,
+	}
+
+	LogWarn("Conflict error", logrus.Fields{
+		"request_id": requestID,
+		"method":     c.Request.Method,
+		"path":       c.Request.URL.Path,
+		"error":      message,
+	})
+
+	c.Error(apiErr)
+}
+
 // Helper functions to identify error types
 func isValidationError(err error) bool {
 	// Add logic to identify validation errors
@@ -155,6 +176,16 @@ func JSONSuccess(c *gin.Context, data interface{}) {
 	c.JSON(http.StatusOK, response)
 }
 
+// Page response helper: a page of a list with the pagination details beside it
+func JSONPage(c *gin.Context, data interface{}, pagination interface{}) {
+	c.JSON(http.StatusOK, gin.H{
+		"data":       data,
+		"pagination": pagination,
+		"request_id": c.GetString("request_id"),
+		"timestamp":  getCurrentTimestamp(),
+	})
+}
+
 // Created response helper
 func JSONCreated(c *gin.Context, data interface{}) {
 	requestID := c.GetString("request_id")
@@ -169,5 +200,5 @@ func JSONCreated(c *gin.Context, data interface{}) {
 }
 
 func getCurrentTimestamp() string {
-	return time.Now().Format("2006-01-02T15:04:05Z07:00")
+	return time.Now().UTC().Format("2006-01-02T15:04:05Z07:00")
 } 
\ No newline at end of file
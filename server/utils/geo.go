@@ -0,0 +1,22 @@
+package utils
+
+import "math"
+
+// earthRadiusMeters is the mean radius of the Earth used by the haversine formula
+const earthRadiusMeters = 6371000.0
+
+// HaversineDistance returns the great-circle distance in meters between two coordinates
+func HaversineDistance(lat1, lng1, lat2, lng2 float64) float64 {
+	toRadians := func(deg float64) float64 {
+		return deg * math.Pi / 180
+	}
+
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+
+	return earthRadiusMeters * c
+}
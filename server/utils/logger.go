@@ -3,6 +3,8 @@ package utils
 import (
 	"os"
 
+	"visit-tracker-api/config"
+
 	"github.com/sirupsen/logrus"
 )
 
@@ -10,11 +12,14 @@ import (
 var Logger *logrus.Logger
 
 // InitLogger initializes the global logger
-func InitLogger() *logrus.Logger {
+func InitLogger(cfg config.LogConfig) *logrus.Logger {
 	logger := logrus.New()
 
 	// Set log level
-	level := os.Getenv("LOG_LEVEL")
+	level := cfg.Level
+	if cfg.Debug {
+		level = "debug"
+	}
 	switch level {
 	case "debug":
 		logger.SetLevel(logrus.DebugLevel)
@@ -29,7 +34,7 @@ func InitLogger() *logrus.Logger {
 	}
 
 	// Set log format
-	if os.Getenv("GIN_MODE") == "release" {
+	if cfg.Format == config.LogFormatJSON {
 		// Production format (JSON)
 		logger.SetFormatter(&logrus.JSONFormatter{
 			TimestampFormat: "2006-01-02T15:04:05Z07:00",
@@ -54,7 +59,7 @@ func InitLogger() *logrus.Logger {
 // GetLogger returns the global logger instance
 func GetLogger() *logrus.Logger {
 	if Logger == nil {
-		return InitLogger()
+		return InitLogger(config.Default().Log)
 	}
 	return Logger
 }
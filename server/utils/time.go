@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// DateTimeLayout is the format timestamps are written to the database in, always in UTC
+const DateTimeLayout = time.DateTime
+
+// agencyLocation is the time zone of clients that do not have one of their own
+var agencyLocation = time.UTC
+
+// locations caches the time zones loaded by Location
+var locations sync.Map
+
+// SetAgencyLocation sets the agency's time zone, used for clients without their own
+func SetAgencyLocation(loc *time.Location) {
+	agencyLocation = loc
+}
+
+// AgencyLocation returns the agency's time zone
+func AgencyLocation() *time.Location {
+	return agencyLocation
+}
+
+// Location returns the IANA time zone with the given name, or the agency's time zone
+// when the name is empty or not a known zone
+func Location(name string) *time.Location {
+	if name == "" {
+		return agencyLocation
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return agencyLocation
+	}
+	locations.Store(name, loc)
+	return loc
+}
+
+// FormatTime formats a time for storage, converting it to UTC
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(DateTimeLayout)
+}
+
+// LocalDate returns the calendar date t falls on in loc, as midnight UTC on that date
+func LocalDate(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ParseTime parses a timestamp read from the database. SQLite and PostgreSQL timestamp
+// and date columns are read back as RFC3339, while values written by the API use DateTimeLayout. It returns the
+// zero time when the value cannot be parsed. Timestamps without a zone are in UTC.
+func ParseTime(value string) time.Time {
+	layouts := []string{
+		time.DateTime,    // "2006-01-02 15:04:05"
+		time.RFC3339,     // "2006-01-02T15:04:05Z07:00"
+		time.RFC3339Nano, // "2006-01-02T15:04:05.999999999Z07:00"
+		time.DateOnly,    // "2006-01-02"
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC()
+		}
+	}
+
+	return time.Time{}
+}